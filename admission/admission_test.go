@@ -0,0 +1,186 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package admission
+
+import (
+	"crypto/rand"
+	"net/http"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ed25519"
+
+	"vuvuzela.io/alpenhorn/edtls"
+)
+
+func TestBlindTokens(t *testing.T) {
+	key, err := GenerateKey(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err = UnmarshalPrivateKey(key.PrivateBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := UnmarshalPublicKey(key.PublicKey.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := pub.Blind(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := key.SignBlinded(7, req.Blinded())
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := req.Unblind(sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pub.Verify(token) {
+		t.Fatalf("token does not verify")
+	}
+
+	// The token is only valid in its own epoch.
+	token.Epoch = 8
+	if pub.Verify(token) {
+		t.Fatalf("token verifies in a different epoch")
+	}
+
+	// A signature from another epoch's key does not unblind.
+	req, err = pub.Blind(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err = key.SignBlinded(8, req.Blinded())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := req.Unblind(sig); err == nil {
+		t.Fatalf("expected error for signature from another epoch")
+	}
+}
+
+func TestIssuer(t *testing.T) {
+	tokenKey, err := GenerateKey(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuerPublic, issuerPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	userPublic, userPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	_, otherPrivate, _ := ed25519.GenerateKey(rand.Reader)
+
+	issuer := &Issuer{
+		Key:            tokenKey,
+		EpochDuration:  time.Hour,
+		TokensPerEpoch: 3,
+		Users:          []ed25519.PublicKey{userPublic},
+	}
+	listener, err := edtls.Listen("tcp", "localhost:0", issuerPrivate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go http.Serve(listener, issuer)
+
+	wallet := &Wallet{
+		IssuerAddress: listener.Addr().String(),
+		IssuerKey:     issuerPublic,
+		Key:           userPrivate,
+		BatchSize:     2,
+	}
+	verifier := &Verifier{
+		Key:           &tokenKey.PublicKey,
+		EpochDuration: time.Hour,
+	}
+
+	for i := 0; i < 2; i++ {
+		token, err := wallet.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := verifier.Spend(token); err != nil {
+			t.Fatalf("token %d: %s", i, err)
+		}
+		if err := verifier.Spend(token); err == nil {
+			t.Fatalf("token %d was spent twice", i)
+		}
+	}
+
+	// The next batch would go over the user's tokens for the epoch.
+	if _, err := wallet.Token(); err == nil {
+		t.Fatalf("expected error after running out of tokens")
+	}
+
+	stranger := &Wallet{
+		IssuerAddress: listener.Addr().String(),
+		IssuerKey:     issuerPublic,
+		Key:           otherPrivate,
+	}
+	if _, err := stranger.Token(); err == nil {
+		t.Fatalf("expected error for unknown user")
+	}
+
+	if err := verifier.Spend(nil); err == nil {
+		t.Fatalf("expected error for missing token")
+	}
+}
+
+func TestIssuerUsers(t *testing.T) {
+	user, _, _ := ed25519.GenerateKey(rand.Reader)
+	other, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	// Without users, nobody gets tokens.
+	issuer := new(Issuer)
+	if issuer.allowed(user) {
+		t.Fatalf("issuer without users allowed a key")
+	}
+
+	issuer.Users = []ed25519.PublicKey{user}
+	if !issuer.allowed(user) || issuer.allowed(other) {
+		t.Fatalf("issuer did not follow its user list")
+	}
+
+	issuer.OpenIssuance = true
+	if !issuer.allowed(other) {
+		t.Fatalf("open issuer did not allow a new key")
+	}
+}
+
+func TestPuzzle(t *testing.T) {
+	p := NewPuzzle(3, 12)
+	bundle := [][]byte{[]byte("onion 1"), []byte("onion 2")}
+	nonce, err := p.Solve(bundle, time.Now().Add(10*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest, ok := p.Check(bundle, nonce)
+	if !ok {
+		t.Fatalf("solution does not check")
+	}
+
+	// The solution is bound to the round and the bundle.
+	other := *p
+	other.Round = 4
+	if d, _ := other.Check(bundle, nonce); d == digest {
+		t.Fatalf("same digest for another round")
+	}
+	if d, _ := p.Check(bundle[:1], nonce); d == digest {
+		t.Fatalf("same digest for another bundle")
+	}
+
+	if _, err := NewPuzzle(3, 60).Solve(bundle, time.Now().Add(time.Second)); err == nil {
+		t.Fatalf("expected error for puzzle that is too hard")
+	}
+	hard := NewPuzzle(3, 32)
+	if _, err := hard.Solve(bundle, time.Now()); err == nil {
+		t.Fatalf("expected error after deadline")
+	}
+	if hard.SolveBudget() <= p.SolveBudget() {
+		t.Fatalf("harder puzzle has a smaller budget")
+	}
+}
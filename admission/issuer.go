@@ -0,0 +1,179 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package admission
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ed25519"
+
+	"vuvuzela.io/alpenhorn/edtls"
+	"vuvuzela.io/alpenhorn/log"
+)
+
+// An Issuer hands out blind-signed tokens over HTTPS. It must be served
+// over edtls so that it knows the key of the client asking for tokens.
+//
+// The issuer only counts tokens per client key in memory, so clients
+// can get another TokensPerEpoch tokens after the issuer restarts.
+type Issuer struct {
+	Key           *PrivateKey
+	EpochDuration time.Duration
+
+	// TokensPerEpoch is the number of tokens a client key can get per
+	// epoch. It should be at least the number of rounds per epoch.
+	TokensPerEpoch int
+
+	// Users lists the client keys that can get tokens.
+	Users []ed25519.PublicKey
+
+	// OpenIssuance lets any client key get tokens, not just Users.
+	// Anyone can generate keys, so this only limits the tokens per key
+	// and does not stop a sybil attack.
+	OpenIssuance bool
+
+	mu     sync.Mutex
+	issued map[uint32]map[[ed25519.PublicKeySize]byte]int
+}
+
+// KeyInfo is the issuer's response to /key.
+type KeyInfo struct {
+	Key           []byte
+	EpochDuration time.Duration
+}
+
+type IssueRequest struct {
+	Epoch   uint32
+	Blinded [][]byte
+}
+
+type IssueResponse struct {
+	Signatures [][]byte
+}
+
+// maxIssueBatch is the maximum number of tokens per request.
+const maxIssueBatch = 1000
+
+func (iss *Issuer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	switch {
+	case strings.HasPrefix(req.URL.Path, "/key"):
+		iss.keyHandler(w, req)
+	case strings.HasPrefix(req.URL.Path, "/issue"):
+		iss.issueHandler(w, req)
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+func (iss *Issuer) keyHandler(w http.ResponseWriter, req *http.Request) {
+	info := &KeyInfo{
+		Key:           iss.Key.PublicKey.Bytes(),
+		EpochDuration: iss.EpochDuration,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(info)
+}
+
+func (iss *Issuer) issueHandler(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		http.Error(w, "expecting POST", http.StatusBadRequest)
+		return
+	}
+	if req.TLS == nil || len(req.TLS.PeerCertificates) == 0 {
+		http.Error(w, "no peer certificate", http.StatusBadRequest)
+		return
+	}
+	userKey := edtls.GetSigningKey(req.TLS.PeerCertificates[0])
+	if len(userKey) != ed25519.PublicKeySize {
+		http.Error(w, "invalid peer key", http.StatusBadRequest)
+		return
+	}
+	if !iss.allowed(userKey) {
+		http.Error(w, "unknown user", http.StatusForbidden)
+		return
+	}
+
+	body := http.MaxBytesReader(w, req.Body, 1<<20)
+	args := new(IssueRequest)
+	if err := json.NewDecoder(body).Decode(args); err != nil {
+		http.Error(w, "error decoding json", http.StatusBadRequest)
+		return
+	}
+	if len(args.Blinded) > maxIssueBatch {
+		http.Error(w, fmt.Sprintf("too many tokens in one request (limit %d)", maxIssueBatch), http.StatusBadRequest)
+		return
+	}
+
+	// Clients can fetch tokens for the next epoch ahead of time.
+	epoch := Epoch(time.Now(), iss.EpochDuration)
+	if args.Epoch != epoch && args.Epoch != epoch+1 {
+		http.Error(w, fmt.Sprintf("invalid epoch %d: current epoch is %d", args.Epoch, epoch), http.StatusBadRequest)
+		return
+	}
+
+	if !iss.reserve(epoch, args.Epoch, userKey, len(args.Blinded)) {
+		http.Error(w, "token limit reached for this epoch", http.StatusTooManyRequests)
+		return
+	}
+
+	sigs := make([][]byte, len(args.Blinded))
+	for i, blinded := range args.Blinded {
+		sig, err := iss.Key.SignBlinded(args.Epoch, blinded)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("token %d: %s", i, err), http.StatusBadRequest)
+			return
+		}
+		sigs[i] = sig
+	}
+
+	log.WithFields(log.Fields{"epoch": args.Epoch, "tokens": len(sigs)}).Info("Issued tokens")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&IssueResponse{Signatures: sigs})
+}
+
+func (iss *Issuer) allowed(key ed25519.PublicKey) bool {
+	if iss.OpenIssuance {
+		return true
+	}
+	for _, u := range iss.Users {
+		if bytes.Equal(u, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// reserve counts n tokens for the user in the given epoch, unless that
+// would go over TokensPerEpoch.
+func (iss *Issuer) reserve(current, epoch uint32, key ed25519.PublicKey, n int) bool {
+	var k [ed25519.PublicKeySize]byte
+	copy(k[:], key)
+
+	iss.mu.Lock()
+	defer iss.mu.Unlock()
+
+	if iss.issued == nil {
+		iss.issued = make(map[uint32]map[[ed25519.PublicKeySize]byte]int)
+	}
+	for e := range iss.issued {
+		if e < current {
+			delete(iss.issued, e)
+		}
+	}
+	if iss.issued[epoch] == nil {
+		iss.issued[epoch] = make(map[[ed25519.PublicKeySize]byte]int)
+	}
+	if iss.issued[epoch][k]+n > iss.TokensPerEpoch {
+		return false
+	}
+	iss.issued[epoch][k] += n
+	return true
+}
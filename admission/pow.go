@@ -0,0 +1,148 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package admission
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"vuvuzela.io/alpenhorn/errors"
+	"vuvuzela.io/crypto/rand"
+)
+
+// A Puzzle is a hashcash-style proof-of-work puzzle for one round.
+// A solution is a nonce such that the SHA-256 digest of the round,
+// the seed, the onion bundle, and the nonce starts with Difficulty
+// zero bits. Solutions are bound to the bundle, so a solution can
+// only be reused by sending the same bundle again.
+type Puzzle struct {
+	Round      uint32
+	Seed       []byte
+	Difficulty int
+}
+
+// MaxDifficulty is the largest difficulty a client will try to solve.
+const MaxDifficulty = 40
+
+const puzzleSeedSize = 16
+
+// NewPuzzle returns a puzzle with a fresh seed, so that clients can
+// not solve the round's puzzle before it is announced.
+func NewPuzzle(round uint32, difficulty int) *Puzzle {
+	seed := make([]byte, puzzleSeedSize)
+	rand.Read(seed)
+	return &Puzzle{
+		Round:      round,
+		Seed:       seed,
+		Difficulty: difficulty,
+	}
+}
+
+func bundleDigest(bundle [][]byte) [32]byte {
+	h := sha256.New()
+	var n [4]byte
+	for _, onion := range bundle {
+		binary.BigEndian.PutUint32(n[:], uint32(len(onion)))
+		h.Write(n[:])
+		h.Write(onion)
+	}
+	var sum [32]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// puzzleInput is the message that is hashed with the nonce. The
+// nonce goes in the last 8 bytes.
+func (p *Puzzle) puzzleInput(bundle [][]byte) []byte {
+	msg := make([]byte, 0, 16+4+len(p.Seed)+32+8)
+	msg = append(msg, "VuvuzelaPuzzle\x00\x00"...)
+	var r [4]byte
+	binary.BigEndian.PutUint32(r[:], p.Round)
+	msg = append(msg, r[:]...)
+	msg = append(msg, p.Seed...)
+	b := bundleDigest(bundle)
+	msg = append(msg, b[:]...)
+	return append(msg, make([]byte, 8)...)
+}
+
+func leadingZeros(sum [32]byte) int {
+	n := 0
+	for _, b := range sum {
+		if b == 0 {
+			n += 8
+			continue
+		}
+		for b&0x80 == 0 {
+			n++
+			b <<= 1
+		}
+		break
+	}
+	return n
+}
+
+// Check returns the digest for the bundle and nonce, and whether it
+// solves the puzzle. The digest identifies the solution, so that the
+// caller can reject solutions that are used twice.
+func (p *Puzzle) Check(bundle [][]byte, nonce uint64) ([32]byte, bool) {
+	msg := p.puzzleInput(bundle)
+	binary.BigEndian.PutUint64(msg[len(msg)-8:], nonce)
+	sum := sha256.Sum256(msg)
+	return sum, leadingZeros(sum) >= p.Difficulty
+}
+
+// Solve finds a solution for the bundle. It gives up and returns an
+// error if it has not found one by the deadline.
+func (p *Puzzle) Solve(bundle [][]byte, deadline time.Time) (uint64, error) {
+	if p.Difficulty > MaxDifficulty {
+		return 0, errors.New("puzzle difficulty %d is too high (max %d)", p.Difficulty, MaxDifficulty)
+	}
+	msg := p.puzzleInput(bundle)
+	nonceBytes := msg[len(msg)-8:]
+	for nonce := uint64(0); ; nonce++ {
+		// Checking the time for every hash would slow down solving.
+		if nonce%4096 == 0 && time.Now().After(deadline) {
+			return 0, errors.New("no puzzle solution after %d tries", nonce)
+		}
+		binary.BigEndian.PutUint64(nonceBytes, nonce)
+		if leadingZeros(sha256.Sum256(msg)) >= p.Difficulty {
+			return nonce, nil
+		}
+	}
+}
+
+var (
+	hashRateOnce sync.Once
+	hashRate     float64
+)
+
+// measureHashRate estimates the number of puzzle hashes per second.
+func measureHashRate() float64 {
+	hashRateOnce.Do(func() {
+		p := &Puzzle{Seed: make([]byte, puzzleSeedSize)}
+		msg := p.puzzleInput(nil)
+		const n = 1 << 14
+		start := time.Now()
+		for i := uint64(0); i < n; i++ {
+			binary.BigEndian.PutUint64(msg[len(msg)-8:], i)
+			sha256.Sum256(msg)
+		}
+		hashRate = n / time.Since(start).Seconds()
+	})
+	return hashRate
+}
+
+// SolveBudget estimates how long it takes to solve the puzzle on this
+// machine. It allows for 4 times the expected number of tries, so that
+// Solve finishes within the budget about 98% of the time.
+func (p *Puzzle) SolveBudget() time.Duration {
+	if p.Difficulty <= 0 || p.Difficulty > MaxDifficulty {
+		return 0
+	}
+	tries := 4 * float64(uint64(1)<<uint(p.Difficulty))
+	return time.Duration(tries / measureHashRate() * float64(time.Second))
+}
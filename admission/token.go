@@ -0,0 +1,267 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+// Package admission implements anonymous access tokens for the
+// coordinator.
+//
+// Tokens are RSA blind signatures (Chaum) with a full-domain hash.
+// A client picks a random nonce, blinds the hash of the nonce and
+// sends it to the issuer, which authenticates the client, limits how
+// many tokens it gets, and signs the blinded hash without seeing it.
+// The client unblinds the signature and later attaches the token to
+// an OnionMsg. The coordinator checks the signature and that the token
+// was not spent before, but can not link the token to the client that
+// got it, even with the issuer's help.
+//
+// Tokens are only valid in the epoch they were issued for. The epoch
+// can not be part of the signed message, since the issuer does not see
+// it, so every epoch has its own public exponent instead: a signature
+// for one epoch does not verify in any other.
+package admission
+
+import (
+	cryptoRand "crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"math/big"
+	"time"
+
+	"vuvuzela.io/alpenhorn/errors"
+)
+
+// NonceSize is the size of a token's nonce.
+const NonceSize = 32
+
+// A Token admits one onion bundle to the coordinator.
+type Token struct {
+	Epoch     uint32
+	Nonce     []byte
+	Signature []byte
+}
+
+// Epoch returns the epoch that contains t, for epochs of length d.
+func Epoch(t time.Time, d time.Duration) uint32 {
+	return uint32(t.UnixNano() / int64(d))
+}
+
+type PublicKey struct {
+	N *big.Int
+}
+
+type PrivateKey struct {
+	PublicKey
+	rsa *rsa.PrivateKey
+}
+
+// GenerateKey generates an issuer key with a modulus of the given size.
+func GenerateKey(bits int) (*PrivateKey, error) {
+	key, err := rsa.GenerateKey(cryptoRand.Reader, bits)
+	if err != nil {
+		return nil, err
+	}
+	return &PrivateKey{
+		PublicKey: PublicKey{N: key.N},
+		rsa:       key,
+	}, nil
+}
+
+// Bytes returns the public key as a big-endian modulus.
+func (pk *PublicKey) Bytes() []byte {
+	return pk.N.Bytes()
+}
+
+func UnmarshalPublicKey(data []byte) (*PublicKey, error) {
+	if len(data) < 256 {
+		return nil, errors.New("issuer key is too short: %d bytes", len(data))
+	}
+	return &PublicKey{N: new(big.Int).SetBytes(data)}, nil
+}
+
+// PrivateBytes returns the private key in PKCS #1 form.
+func (k *PrivateKey) PrivateBytes() []byte {
+	return x509.MarshalPKCS1PrivateKey(k.rsa)
+}
+
+func UnmarshalPrivateKey(data []byte) (*PrivateKey, error) {
+	key, err := x509.ParsePKCS1PrivateKey(data)
+	if err != nil {
+		return nil, err
+	}
+	return &PrivateKey{
+		PublicKey: PublicKey{N: key.N},
+		rsa:       key,
+	}, nil
+}
+
+// size is the size in bytes of signatures and blinded messages.
+func (pk *PublicKey) size() int {
+	return (pk.N.BitLen() + 7) / 8
+}
+
+// epochExponent returns the public exponent for the epoch: the first
+// prime after a 64-bit number derived from the epoch. Exponents of
+// different epochs are distinct primes, so a signature for one epoch
+// can not be turned into a signature for another.
+func epochExponent(epoch uint32) *big.Int {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], epoch)
+	h := sha256.New()
+	h.Write([]byte("vuvuzela admission exponent"))
+	h.Write(buf[:])
+	sum := h.Sum(nil)
+
+	e := new(big.Int).SetBytes(sum[:8])
+	e.SetBit(e, 63, 1)
+	e.SetBit(e, 0, 1)
+	two := big.NewInt(2)
+	for !e.ProbablyPrime(20) {
+		e.Add(e, two)
+	}
+	return e
+}
+
+// hash is the full-domain hash of the nonce into Z_N.
+func (pk *PublicKey) hash(epoch uint32, nonce []byte) *big.Int {
+	var buf [8]byte
+	binary.BigEndian.PutUint32(buf[4:], epoch)
+
+	out := make([]byte, 0, pk.size()+sha256.Size+16)
+	for counter := uint32(0); len(out) < pk.size()+16; counter++ {
+		binary.BigEndian.PutUint32(buf[0:4], counter)
+		h := sha256.New()
+		h.Write([]byte("vuvuzela admission token"))
+		h.Write(buf[:])
+		h.Write(nonce)
+		out = h.Sum(out)
+	}
+	m := new(big.Int).SetBytes(out)
+	return m.Mod(m, pk.N)
+}
+
+// pad encodes x as a big-endian number of the key's size.
+func (pk *PublicKey) pad(x *big.Int) []byte {
+	buf := make([]byte, pk.size())
+	xb := x.Bytes()
+	copy(buf[len(buf)-len(xb):], xb)
+	return buf
+}
+
+// verify checks the token's signature using the epoch's exponent e.
+func (pk *PublicKey) verify(t *Token, e *big.Int) bool {
+	if len(t.Nonce) != NonceSize || len(t.Signature) != pk.size() {
+		return false
+	}
+	s := new(big.Int).SetBytes(t.Signature)
+	if s.Sign() == 0 || s.Cmp(pk.N) >= 0 {
+		return false
+	}
+	return s.Exp(s, e, pk.N).Cmp(pk.hash(t.Epoch, t.Nonce)) == 0
+}
+
+// Verify reports whether the token's signature is valid. It does not
+// check whether the token was spent.
+func (pk *PublicKey) Verify(t *Token) bool {
+	return pk.verify(t, epochExponent(t.Epoch))
+}
+
+// A BlindRequest is a token waiting for the issuer's signature.
+type BlindRequest struct {
+	key     *PublicKey
+	token   *Token
+	r       *big.Int
+	blinded []byte
+}
+
+// Blind creates a new token for the epoch and blinds it.
+func (pk *PublicKey) Blind(epoch uint32) (*BlindRequest, error) {
+	nonce := make([]byte, NonceSize)
+	if _, err := cryptoRand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	var r *big.Int
+	one := big.NewInt(1)
+	for {
+		var err error
+		r, err = cryptoRand.Int(cryptoRand.Reader, pk.N)
+		if err != nil {
+			return nil, err
+		}
+		if r.Sign() > 0 && new(big.Int).GCD(nil, nil, r, pk.N).Cmp(one) == 0 {
+			break
+		}
+	}
+
+	m := pk.hash(epoch, nonce)
+	b := new(big.Int).Exp(r, epochExponent(epoch), pk.N)
+	b.Mul(b, m).Mod(b, pk.N)
+
+	return &BlindRequest{
+		key:     pk,
+		token:   &Token{Epoch: epoch, Nonce: nonce},
+		r:       r,
+		blinded: pk.pad(b),
+	}, nil
+}
+
+// Blinded is the value that the issuer signs.
+func (b *BlindRequest) Blinded() []byte {
+	return b.blinded
+}
+
+// Unblind turns the issuer's signature of the blinded value into a
+// token, and checks that the token is valid.
+func (b *BlindRequest) Unblind(blindSig []byte) (*Token, error) {
+	if len(blindSig) != b.key.size() {
+		return nil, errors.New("invalid blind signature size: %d", len(blindSig))
+	}
+	s := new(big.Int).SetBytes(blindSig)
+	rInv := new(big.Int).ModInverse(b.r, b.key.N)
+	s.Mul(s, rInv).Mod(s, b.key.N)
+
+	t := &Token{
+		Epoch:     b.token.Epoch,
+		Nonce:     b.token.Nonce,
+		Signature: b.key.pad(s),
+	}
+	if !b.key.Verify(t) {
+		return nil, errors.New("issuer returned an invalid signature")
+	}
+	return t, nil
+}
+
+// SignBlinded signs a blinded value for the epoch.
+func (k *PrivateKey) SignBlinded(epoch uint32, blinded []byte) ([]byte, error) {
+	if len(blinded) != k.size() {
+		return nil, errors.New("invalid blinded value size: %d", len(blinded))
+	}
+	b := new(big.Int).SetBytes(blinded)
+	if b.Cmp(k.N) >= 0 {
+		return nil, errors.New("blinded value is out of range")
+	}
+
+	d, err := k.epochD(epoch)
+	if err != nil {
+		return nil, err
+	}
+	return k.pad(b.Exp(b, d, k.N)), nil
+}
+
+// epochD returns the private exponent for the epoch's public exponent.
+func (k *PrivateKey) epochD(epoch uint32) (*big.Int, error) {
+	one := big.NewInt(1)
+	lambda := big.NewInt(1)
+	for _, p := range k.rsa.Primes {
+		pm1 := new(big.Int).Sub(p, one)
+		g := new(big.Int).GCD(nil, nil, lambda, pm1)
+		lambda.Mul(lambda, pm1).Div(lambda, g)
+	}
+	d := new(big.Int).ModInverse(epochExponent(epoch), lambda)
+	if d == nil {
+		return nil, errors.New("epoch %d: exponent is not invertible", epoch)
+	}
+	return d, nil
+}
@@ -0,0 +1,94 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package admission
+
+import (
+	"math/big"
+	"sync"
+	"time"
+
+	"vuvuzela.io/alpenhorn/errors"
+)
+
+// A Verifier checks tokens and remembers which ones were spent.
+// Tokens from the current and the previous epoch are accepted, so
+// that tokens fetched just before an epoch ends can still be used.
+type Verifier struct {
+	Key           *PublicKey
+	EpochDuration time.Duration
+
+	mu        sync.Mutex
+	spent     map[uint32]map[[NonceSize]byte]struct{}
+	exponents map[uint32]*big.Int
+}
+
+// Spend checks that the token is valid and unspent, and marks it as
+// spent.
+func (v *Verifier) Spend(t *Token) error {
+	if t == nil {
+		return errors.New("missing admission token")
+	}
+	epoch := Epoch(time.Now(), v.EpochDuration)
+	if t.Epoch != epoch && t.Epoch+1 != epoch {
+		return errors.New("admission token is for epoch %d, but the current epoch is %d", t.Epoch, epoch)
+	}
+	if len(t.Nonce) != NonceSize {
+		return errors.New("invalid admission token nonce")
+	}
+	var nonce [NonceSize]byte
+	copy(nonce[:], t.Nonce)
+
+	v.mu.Lock()
+	v.expire(epoch)
+	if _, ok := v.spent[t.Epoch][nonce]; ok {
+		v.mu.Unlock()
+		return errors.New("admission token was already spent")
+	}
+	e := v.exponent(t.Epoch)
+	v.mu.Unlock()
+
+	if !v.Key.verify(t, e) {
+		return errors.New("invalid admission token signature")
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	// Check again, in case the token was spent concurrently.
+	if _, ok := v.spent[t.Epoch][nonce]; ok {
+		return errors.New("admission token was already spent")
+	}
+	if v.spent[t.Epoch] == nil {
+		v.spent[t.Epoch] = make(map[[NonceSize]byte]struct{})
+	}
+	v.spent[t.Epoch][nonce] = struct{}{}
+	return nil
+}
+
+// expire forgets tokens that are too old to be spent.
+func (v *Verifier) expire(epoch uint32) {
+	if v.spent == nil {
+		v.spent = make(map[uint32]map[[NonceSize]byte]struct{})
+		v.exponents = make(map[uint32]*big.Int)
+	}
+	for e := range v.spent {
+		if e+1 < epoch {
+			delete(v.spent, e)
+		}
+	}
+	for e := range v.exponents {
+		if e+1 < epoch {
+			delete(v.exponents, e)
+		}
+	}
+}
+
+func (v *Verifier) exponent(epoch uint32) *big.Int {
+	e, ok := v.exponents[epoch]
+	if !ok {
+		e = epochExponent(epoch)
+		v.exponents[epoch] = e
+	}
+	return e
+}
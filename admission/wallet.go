@@ -0,0 +1,163 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package admission
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ed25519"
+
+	"vuvuzela.io/alpenhorn/edtls"
+	"vuvuzela.io/alpenhorn/errors"
+)
+
+// A Wallet fetches tokens from an issuer in batches and hands them out
+// one at a time.
+type Wallet struct {
+	IssuerAddress string
+	IssuerKey     ed25519.PublicKey
+
+	// Key authenticates the client to the issuer.
+	Key ed25519.PrivateKey
+
+	// BatchSize is the number of tokens fetched at once.
+	BatchSize int
+
+	mu     sync.Mutex
+	client *http.Client
+	info   *KeyInfo
+	key    *PublicKey
+	epoch  uint32
+	tokens []*Token
+}
+
+const defaultBatchSize = 64
+
+// Token returns an unspent token for the current epoch, fetching more
+// tokens from the issuer if needed.
+func (w *Wallet) Token() (*Token, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.info == nil {
+		if err := w.fetchKey(); err != nil {
+			return nil, err
+		}
+	}
+
+	epoch := Epoch(time.Now(), w.info.EpochDuration)
+	if epoch != w.epoch {
+		w.epoch = epoch
+		w.tokens = nil
+	}
+	if len(w.tokens) == 0 {
+		tokens, err := w.fetchTokens(epoch)
+		if err != nil {
+			return nil, err
+		}
+		w.tokens = tokens
+	}
+
+	t := w.tokens[0]
+	w.tokens = w.tokens[1:]
+	return t, nil
+}
+
+func (w *Wallet) httpClient() *http.Client {
+	if w.client == nil {
+		w.client = &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: edtls.NewTLSClientConfig(w.Key, w.IssuerKey),
+			},
+			Timeout: 30 * time.Second,
+		}
+	}
+	return w.client
+}
+
+func (w *Wallet) fetchKey() error {
+	resp, err := w.httpClient().Get(fmt.Sprintf("https://%s/key", w.IssuerAddress))
+	if err != nil {
+		return errors.Wrap(err, "fetching issuer key")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return errors.New("fetching issuer key: %s: %q", resp.Status, msg)
+	}
+
+	info := new(KeyInfo)
+	if err := json.NewDecoder(resp.Body).Decode(info); err != nil {
+		return errors.Wrap(err, "decoding issuer key")
+	}
+	if info.EpochDuration <= 0 {
+		return errors.New("invalid epoch duration: %s", info.EpochDuration)
+	}
+	key, err := UnmarshalPublicKey(info.Key)
+	if err != nil {
+		return err
+	}
+	w.info = info
+	w.key = key
+	return nil
+}
+
+func (w *Wallet) fetchTokens(epoch uint32) ([]*Token, error) {
+	n := w.BatchSize
+	if n <= 0 {
+		n = defaultBatchSize
+	}
+	reqs := make([]*BlindRequest, n)
+	args := &IssueRequest{
+		Epoch:   epoch,
+		Blinded: make([][]byte, n),
+	}
+	for i := range reqs {
+		r, err := w.key.Blind(epoch)
+		if err != nil {
+			return nil, err
+		}
+		reqs[i] = r
+		args.Blinded[i] = r.Blinded()
+	}
+
+	body, err := json.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := w.httpClient().Post(fmt.Sprintf("https://%s/issue", w.IssuerAddress), "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "fetching tokens")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return nil, errors.New("fetching tokens: %s: %q", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	reply := new(IssueResponse)
+	if err := json.NewDecoder(resp.Body).Decode(reply); err != nil {
+		return nil, errors.Wrap(err, "decoding tokens")
+	}
+	if len(reply.Signatures) != n {
+		return nil, errors.New("expected %d tokens, got %d", n, len(reply.Signatures))
+	}
+
+	tokens := make([]*Token, n)
+	for i, sig := range reply.Signatures {
+		t, err := reqs[i].Unblind(sig)
+		if err != nil {
+			return nil, err
+		}
+		tokens[i] = t
+	}
+	return tokens, nil
+}
@@ -6,54 +6,110 @@ package vuvuzela
 
 import (
 	"fmt"
-	"strings"
 	"sync"
 	"time"
 
 	"github.com/davidlazar/go-crypto/encoding/base32"
 	"golang.org/x/crypto/ed25519"
 	"golang.org/x/crypto/nacl/box"
+	"golang.org/x/net/context"
 
 	"vuvuzela.io/alpenhorn/config"
 	"vuvuzela.io/alpenhorn/errors"
 	"vuvuzela.io/alpenhorn/typesocket"
 	"vuvuzela.io/crypto/onionbox"
+	"vuvuzela.io/vuvuzela/admission"
 	"vuvuzela.io/vuvuzela/convo"
 	"vuvuzela.io/vuvuzela/coordinator"
 	"vuvuzela.io/vuvuzela/mixnet"
 )
 
 type Client struct {
-	PersistPath        string
-	CoordinatorLatency time.Duration // Eventually we will measure this.
+	PersistPath string
+
+	// CoordinatorLatency is the time it takes to send a message to the
+	// coordinator, until the client has measured it (see clock.go).
+	CoordinatorLatency time.Duration
 
 	ConfigClient *config.Client
 	Handler      ConvoHandler
 
+	// Tokens, if set, provides an admission token for every round,
+	// for coordinators that require them.
+	Tokens TokenSource
+
+	// OutgoingTimeout is how long a ContextConvoHandler has to return
+	// a round's outgoing messages. If zero, it is 100ms.
+	OutgoingTimeout time.Duration
+
 	mu          sync.Mutex
 	rounds      map[uint32]*roundState
 	conn        typesocket.Conn
 	latestRound uint32
+	schedule    coordinator.RoundSchedule
 
 	convoConfig     *config.SignedConfig
 	convoConfigHash string
+
+	// shownAnnouncements maps the signatures of the announcements
+	// passed to the handler to their expiry. The coordinator sends the
+	// active announcements again when the client reconnects.
+	shownAnnouncements map[string]time.Time
+
+	clock clockEstimator
+
+	// gaps finds the rounds that the coordinator skipped, for configs
+	// with a mix pool.
+	gaps roundGaps
+
+	// runCtx is the context of Run, if the client was started with Run.
+	runCtx context.Context
 }
 
 type roundState struct {
 	Config       *convo.ConvoConfig
 	ConfigParent *config.SignedConfig
 
-	mu        sync.Mutex
-	OnionKeys [][]*[32]byte // [msg][mixer]
+	mu          sync.Mutex
+	OnionKeys   [][]*[32]byte // [msg][mixer]
+	OnionChains []int         // [msg]
 }
 
 type ConvoHandler interface {
+	// Outgoing returns the messages to send in a round. The number of
+	// messages is visible to the coordinator, so it should be the same
+	// in every round; see EventHandler.
 	Outgoing(round uint32) []*convo.DeadDropMessage
 	Replies(round uint32, messages [][]byte)
 	NewConfig(chain []*config.SignedConfig)
+
+	// Error and DebugError are called with errors that the user
+	// should see and with errors that only matter when debugging.
+	// Errors from the coordinator are *coordinator.RoundError, and
+	// their ErrorCode says what went wrong.
 	Error(err error)
 	DebugError(err error)
-	GlobalAnnouncement(message string)
+
+	// GlobalAnnouncement is called with announcements that are signed
+	// by a guardian of the current convo config. Other announcements
+	// are dropped with an Error.
+	GlobalAnnouncement(a *coordinator.GlobalAnnouncement)
+}
+
+// A ContextConvoHandler is a ConvoHandler that gets a deadline for
+// its outgoing messages. The client calls OutgoingContext instead of
+// Outgoing, OutgoingTimeout before it needs the messages. The context
+// is also canceled when the client's Run returns. OutgoingContext
+// should return as many messages as usual even when ctx is done.
+type ContextConvoHandler interface {
+	ConvoHandler
+
+	OutgoingContext(ctx context.Context, round uint32) []*convo.DeadDropMessage
+}
+
+// A TokenSource provides admission tokens, such as an admission.Wallet.
+type TokenSource interface {
+	Token() (*admission.Token, error)
 }
 
 func (c *Client) ConnectConvo() (chan error, error) {
@@ -82,6 +138,7 @@ func (c *Client) ConnectConvo() (chan error, error) {
 
 	c.mu.Lock()
 	c.conn = conn
+	c.gaps.reset()
 	c.mu.Unlock()
 
 	disconnect := make(chan error, 1)
@@ -91,10 +148,39 @@ func (c *Client) ConnectConvo() (chan error, error) {
 		c.conn = nil
 		c.mu.Unlock()
 	}()
+	go c.pingLoop(conn)
 
 	return disconnect, nil
 }
 
+// Run connects to the convo service and takes part in rounds until
+// ctx is done or the connection to the coordinator fails. It returns
+// the reason it stopped.
+func (c *Client) Run(ctx context.Context) error {
+	c.mu.Lock()
+	c.runCtx = ctx
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.runCtx = nil
+		c.mu.Unlock()
+	}()
+
+	disconnect, err := c.ConnectConvo()
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		c.CloseConvo()
+		<-disconnect
+		return ctx.Err()
+	case err := <-disconnect:
+		return err
+	}
+}
+
 func (c *Client) CloseConvo() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -116,41 +202,92 @@ func (c *Client) LatestRound() (uint32, error) {
 	return c.latestRound, nil
 }
 
-func (c *Client) setLatestRound(round uint32) {
+func (c *Client) setLatestRound(round uint32, schedule coordinator.RoundSchedule) {
 	c.mu.Lock()
 	if round > c.latestRound {
 		c.latestRound = round
+		c.schedule = schedule
 	}
 	c.mu.Unlock()
 }
 
+// Schedule returns the coordinator's round schedule, as announced
+// with the latest round.
+func (c *Client) Schedule() (coordinator.RoundSchedule, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return coordinator.RoundSchedule{}, errors.New("not connected to convo service")
+	}
+	return c.schedule, nil
+}
+
+// WallClock returns the wall-clock schedule of the client's convo
+// config, or nil if the coordinator decides when rounds end.
+func (c *Client) WallClock() *convo.Schedule {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.convoConfig == nil {
+		return nil
+	}
+	return c.convoConfig.Inner.(*convo.ConvoConfig).WallClock()
+}
+
 func (c *Client) convoMux() typesocket.Mux {
 	return typesocket.NewMux(map[string]interface{}{
 		"announcement": c.globalAnnouncement,
 		"newround":     c.newConvoRound,
 		"reply":        c.openReplyOnion,
 		"error":        c.convoRoundError,
+		"pong":         c.pong,
 	})
 }
 
 func (c *Client) globalAnnouncement(conn typesocket.Conn, v coordinator.GlobalAnnouncement) {
-	c.Handler.GlobalAnnouncement(v.Message)
+	c.mu.Lock()
+	conf := c.convoConfig
+	c.mu.Unlock()
+
+	now := time.Now()
+	if err := v.Verify(conf.Guardians, now); err != nil {
+		c.Handler.Error(errors.Wrap(err, "dropping global announcement"))
+		return
+	}
+
+	sig := string(v.Signature)
+	c.mu.Lock()
+	if c.shownAnnouncements == nil {
+		c.shownAnnouncements = make(map[string]time.Time)
+	}
+	for s, expires := range c.shownAnnouncements {
+		if !now.Before(expires) {
+			delete(c.shownAnnouncements, s)
+		}
+	}
+	_, shown := c.shownAnnouncements[sig]
+	c.shownAnnouncements[sig] = v.Expires
+	c.mu.Unlock()
+
+	if !shown {
+		c.Handler.GlobalAnnouncement(&v)
+	}
 }
 
 func (c *Client) convoRoundError(conn typesocket.Conn, v coordinator.RoundError) {
-	if strings.Contains(v.Err, "round is closed:") || strings.Contains(v.Err, "round not found") {
+	switch v.ErrorCode() {
+	case coordinator.CodeRoundNotFound, coordinator.CodeRoundClosed:
 		// The client now supports retransmission so it's safe to ignore these errors by default.
-		c.Handler.DebugError(errors.New("error from convo coordinator: round %d: %s", v.Round, v.Err))
-	} else {
-		c.Handler.Error(errors.New("error from convo coordinator: round %d: %s", v.Round, v.Err))
+		c.Handler.DebugError(&v)
+	default:
+		c.Handler.Error(&v)
 	}
 }
 
 func (c *Client) newConvoRound(conn typesocket.Conn, v coordinator.NewRound) {
-	c.setLatestRound(v.Round)
+	c.setLatestRound(v.Round, v.Schedule)
 
-	if time.Until(v.EndTime) < 20*time.Millisecond {
-		c.Handler.DebugError(errors.New("newConvoRound %d: skipping round (only %s left)", v.Round, time.Until(v.EndTime)))
+	if left := time.Until(c.localTime(v.EndTime)); left < 20*time.Millisecond {
+		c.Handler.DebugError(errors.New("newConvoRound %d: skipping round (only %s left)", v.Round, left))
 		return
 	}
 
@@ -200,48 +337,182 @@ func (c *Client) newConvoRound(conn typesocket.Conn, v coordinator.NewRound) {
 	return
 }
 
+// checkGaps reports the scheduled rounds that the coordinator skipped.
+// A mix pool picks every round's chains from the round number, so a
+// coordinator that runs only some rounds could pick the chains. The
+// check uses the local clock, since the coordinator could hide skipped
+// rounds by skewing the clock offset.
+func (c *Client) checkGaps(round uint32, sched *convo.Schedule) {
+	c.mu.Lock()
+	skipped := c.gaps.observe(round, sched, time.Now())
+	c.mu.Unlock()
+	if len(skipped) > 0 {
+		c.Handler.Error(errors.New("coordinator skipped %d scheduled rounds (%d to %d), which lets it choose the mix pool's chains", len(skipped), skipped[0], skipped[len(skipped)-1]))
+	}
+}
+
 func (c *Client) runRound(conn typesocket.Conn, st *roundState, v coordinator.NewRound) {
 	round := v.Round
-	settingsMsg := v.MixSettings.SigningMessage()
-
-	for i, mixer := range st.Config.MixServers {
-		if !ed25519.Verify(mixer.Key, settingsMsg, v.MixSignatures[i]) {
-			err := errors.New(
-				"round %d: failed to verify mixnet settings for key %s",
-				round, base32.EncodeToString(mixer.Key),
-			)
-			c.Handler.Error(err)
+	if sched := st.Config.WallClock(); sched != nil {
+		if deadline := sched.Deadline(round); !v.EndTime.Equal(deadline) {
+			c.Handler.Error(errors.New("round %d: coordinator strayed from the schedule: deadline is %s, want %s", round, v.EndTime, deadline))
+			return
+		}
+		if st.Config.MixPool != nil {
+			c.checkGaps(round, sched)
+		}
+	}
+	chains := st.Config.Chains(round)
+	if len(v.Chains) != len(chains) {
+		c.Handler.Error(errors.New("round %d: expected settings for %d chains, got %d", round, len(chains), len(v.Chains)))
+		return
+	}
+	for i, chain := range chains {
+		settings := v.Chains[i]
+		if len(settings.MixSignatures) != len(chain) || len(settings.MixSettings.OnionKeys) != len(chain) {
+			c.Handler.Error(errors.New("round %d: chain %d: wrong number of mixnet signatures or keys", round, i))
+			return
+		}
+		settingsMsg := settings.MixSettings.SigningMessage()
+		for j, mixer := range chain {
+			if !ed25519.Verify(mixer.Key, settingsMsg, settings.MixSignatures[j]) {
+				err := errors.New(
+					"round %d: failed to verify mixnet settings for key %s",
+					round, base32.EncodeToString(mixer.Key),
+				)
+				c.Handler.Error(err)
+				return
+			}
+		}
+	}
+
+	// Leave time to solve the round's puzzle, if there is one.
+	var solveTime time.Duration
+	if v.Puzzle != nil {
+		if v.Puzzle.Round != round {
+			c.Handler.Error(errors.New("round %d: puzzle is for round %d", round, v.Puzzle.Round))
 			return
 		}
+		if v.Puzzle.Difficulty > admission.MaxDifficulty {
+			c.Handler.Error(errors.New("round %d: puzzle difficulty %d is too high", round, v.Puzzle.Difficulty))
+			return
+		}
+		solveTime = v.Puzzle.SolveBudget()
 	}
 
-	if time.Until(v.EndTime) < c.CoordinatorLatency {
-		c.Handler.DebugError(errors.New("runRound %d: skipping round (only %s left)", v.Round, time.Until(v.EndTime)))
+	// The deadline is on the coordinator's clock, unless it comes
+	// from the wall-clock schedule, and onions take the coordinator
+	// latency to get there.
+	endTime := v.EndTime
+	if st.Config.WallClock() == nil {
+		endTime = c.localTime(v.EndTime)
+	}
+	latency := c.coordinatorLatency()
+	if time.Until(endTime) < latency+solveTime {
+		c.Handler.DebugError(errors.New("runRound %d: skipping round (only %s left)", v.Round, time.Until(endTime)))
 		return
 	}
 
-	time.Sleep(time.Until(v.EndTime) - c.CoordinatorLatency - 10*time.Millisecond)
+	var token *admission.Token
+	if c.Tokens != nil {
+		var err error
+		token, err = c.Tokens.Token()
+		if err != nil {
+			c.Handler.Error(errors.Wrap(err, "round %d: getting admission token", round))
+			return
+		}
+	}
 
-	outgoing := c.Handler.Outgoing(round)
+	sendTime := endTime.Add(-latency - solveTime - 10*time.Millisecond)
+	outgoing, ok := c.outgoing(round, sendTime)
+	if !ok {
+		return
+	}
+	if len(outgoing) == 0 {
+		c.Handler.Error(errors.New("round %d: handler returned no outgoing messages, which the coordinator can see", round))
+	}
 	onionKeys := make([][]*[32]byte, len(outgoing))
+	onionChains := make([]int, len(outgoing))
 	onions := make([][]byte, len(outgoing))
 	for i, deadDropMsg := range outgoing {
 		msg := deadDropMsg.Marshal()
-		onions[i], onionKeys[i] = onionbox.Seal(msg, mixnet.ForwardNonce(round), v.MixSettings.OnionKeys)
+		// Messages for the same dead drop must go through the same chain.
+		chain := deadDropMsg.DeadDrop.Chain(len(chains))
+		onionChains[i] = chain
+		onions[i], onionKeys[i] = onionbox.Seal(msg, mixnet.ForwardNonce(round), v.Chains[chain].MixSettings.OnionKeys)
 	}
 
 	st.mu.Lock()
 	st.OnionKeys = onionKeys
+	st.OnionChains = onionChains
 	st.mu.Unlock()
 
-	if time.Until(v.EndTime) < 10*time.Millisecond {
-		c.Handler.DebugError(errors.New("runRound %d: abandoning round (only %s left)", round, time.Until(v.EndTime)))
+	var solution uint64
+	if v.Puzzle != nil {
+		var err error
+		solution, err = v.Puzzle.Solve(onions, endTime.Add(-latency))
+		if err != nil {
+			c.Handler.DebugError(errors.Wrap(err, "runRound %d: abandoning round", round))
+			return
+		}
+	}
+
+	if time.Until(endTime) < 10*time.Millisecond {
+		c.Handler.DebugError(errors.New("runRound %d: abandoning round (only %s left)", round, time.Until(endTime)))
 		return
 	}
-	conn.Send("onion", coordinator.OnionMsg{
-		Round:  round,
-		Onions: onions,
-	})
+	msg := coordinator.OnionMsg{
+		Round:    round,
+		Onions:   onions,
+		Token:    token,
+		Solution: solution,
+	}
+	if len(chains) > 1 {
+		msg.Chains = onionChains
+	}
+	conn.Send("onion", msg)
+}
+
+// outgoing gets the round's outgoing messages from the handler by
+// sendTime. It returns false if the client stopped in the meantime.
+func (c *Client) outgoing(round uint32, sendTime time.Time) ([]*convo.DeadDropMessage, bool) {
+	c.mu.Lock()
+	ctx := c.runCtx
+	c.mu.Unlock()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	h, ok := c.Handler.(ContextConvoHandler)
+	if !ok {
+		if !sleepContext(ctx, time.Until(sendTime)) {
+			return nil, false
+		}
+		return c.Handler.Outgoing(round), true
+	}
+
+	timeout := c.OutgoingTimeout
+	if timeout == 0 {
+		timeout = 100 * time.Millisecond
+	}
+	if !sleepContext(ctx, time.Until(sendTime)-timeout) {
+		return nil, false
+	}
+	ctx, cancel := context.WithDeadline(ctx, sendTime)
+	defer cancel()
+	outgoing := h.OutgoingContext(ctx, round)
+	return outgoing, ctx.Err() != context.Canceled
+}
+
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
 }
 
 func (c *Client) openReplyOnion(conn typesocket.Conn, v coordinator.OnionMsg) {
@@ -249,12 +520,15 @@ func (c *Client) openReplyOnion(conn typesocket.Conn, v coordinator.OnionMsg) {
 	st, ok := c.rounds[v.Round]
 	c.mu.Unlock()
 	if !ok {
-		c.Handler.Error(errors.New("openReplyOnion: round %d not configured", v.Round))
+		// We did not send onions in the round, which happens if the
+		// client restarted during the round.
+		c.Handler.DebugError(errors.New("openReplyOnion: round %d not configured", v.Round))
 		return
 	}
 
 	st.mu.Lock()
 	onionKeys := st.OnionKeys
+	onionChains := st.OnionChains
 	st.mu.Unlock()
 
 	if onionKeys == nil {
@@ -262,21 +536,22 @@ func (c *Client) openReplyOnion(conn typesocket.Conn, v coordinator.OnionMsg) {
 		return
 	}
 
-	if len(st.OnionKeys) != len(v.Onions) {
-		err := errors.New("round %d: expected %d onions, got %d", v.Round, len(st.OnionKeys), len(v.Onions))
+	if len(onionKeys) != len(v.Onions) {
+		err := errors.New("round %d: expected %d onions, got %d", v.Round, len(onionKeys), len(v.Onions))
 		c.Handler.Error(err)
 		return
 	}
 
-	expectedOnionSize := convo.SizeEncryptedMessageBody + len(st.Config.MixServers)*box.Overhead
+	chains := st.Config.Chains(v.Round)
 	msgs := make([][]byte, len(v.Onions))
 	for i, onion := range v.Onions {
+		expectedOnionSize := convo.SizeEncryptedMessageBody + len(chains[onionChains[i]])*box.Overhead
 		if len(onion) != expectedOnionSize {
 			err := errors.New("convo round %d: received malformed onion: got %d bytes, want %d bytes", v.Round, len(onion), expectedOnionSize)
 			c.Handler.Error(err)
 			continue
 		}
-		msg, ok := onionbox.Open(onion, mixnet.BackwardNonce(v.Round), st.OnionKeys[i])
+		msg, ok := onionbox.Open(onion, mixnet.BackwardNonce(v.Round), onionKeys[i])
 		if !ok {
 			err := errors.New("convo round %d: failed to decrypt onion", v.Round)
 			c.Handler.Error(err)
@@ -0,0 +1,153 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package vuvuzela
+
+import (
+	"sync"
+	"time"
+
+	"vuvuzela.io/alpenhorn/typesocket"
+	"vuvuzela.io/vuvuzela/coordinator"
+)
+
+// pingInterval is how often the client pings the coordinator.
+const pingInterval = 10 * time.Second
+
+// maxPingRTT bounds the round-trip time of a useful ping. Pongs that
+// take longer, or that seem to arrive before their ping because the
+// local clock was set back, are ignored.
+const maxPingRTT = 10 * time.Second
+
+// ClockEstimate is the client's estimate of its connection to the
+// coordinator.
+type ClockEstimate struct {
+	// RTT is the smoothed round-trip time to the coordinator.
+	RTT time.Duration
+
+	// Offset is how far the coordinator's clock is ahead of the
+	// local clock.
+	Offset time.Duration
+}
+
+// maxClockSamples is the number of pings the offset is estimated from.
+const maxClockSamples = 8
+
+// rttWeight is the weight of the latest ping in the smoothed
+// round-trip time, as in TCP.
+const rttWeight = 0.125
+
+type clockSample struct {
+	rtt    time.Duration
+	offset time.Duration
+}
+
+// clockEstimator estimates the round-trip time to the coordinator and
+// the offset of the coordinator's clock from pings. A ping's offset is
+// off by at most half of its round-trip time, so the estimate uses the
+// offset of the fastest recent ping.
+type clockEstimator struct {
+	mu      sync.Mutex
+	rtt     time.Duration
+	samples []clockSample
+}
+
+// observe records a ping that was sent at sent, handled by the
+// coordinator at serverTime, and answered at received.
+func (e *clockEstimator) observe(sent, serverTime, received time.Time) {
+	rtt := received.Sub(sent)
+	if rtt < 0 || rtt > maxPingRTT {
+		return
+	}
+	offset := serverTime.Sub(sent.Add(rtt / 2))
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.samples) == 0 {
+		e.rtt = rtt
+	} else {
+		e.rtt += time.Duration(rttWeight * float64(rtt-e.rtt))
+	}
+	e.samples = append(e.samples, clockSample{rtt, offset})
+	if len(e.samples) > maxClockSamples {
+		e.samples = e.samples[1:]
+	}
+}
+
+func (e *clockEstimator) estimate() (ClockEstimate, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.samples) == 0 {
+		return ClockEstimate{}, false
+	}
+	best := e.samples[0]
+	for _, s := range e.samples[1:] {
+		if s.rtt < best.rtt {
+			best = s
+		}
+	}
+	return ClockEstimate{
+		RTT:    e.rtt,
+		Offset: best.offset,
+	}, true
+}
+
+// ClockEstimate returns the client's estimate of its round-trip time
+// to the coordinator and of the coordinator's clock offset. It returns
+// false until the coordinator has answered a ping.
+func (c *Client) ClockEstimate() (ClockEstimate, bool) {
+	return c.clock.estimate()
+}
+
+// coordinatorLatency returns the time it takes to send a message to
+// the coordinator.
+func (c *Client) coordinatorLatency() time.Duration {
+	if est, ok := c.clock.estimate(); ok {
+		return est.RTT / 2
+	}
+	return c.CoordinatorLatency
+}
+
+// maxClockOffset bounds the offset that localTime applies. The offset
+// comes from the coordinator, so without a bound, the coordinator could
+// move the client's deadlines wherever it likes.
+const maxClockOffset = 5 * time.Second
+
+// localTime converts a time on the coordinator's clock, such as a
+// round's EndTime, to the local clock. It is only a hint for when to
+// send onions: checks of the coordinator's behavior, such as the round
+// schedule, must use the local clock.
+func (c *Client) localTime(t time.Time) time.Time {
+	est, _ := c.clock.estimate()
+	offset := est.Offset
+	if offset > maxClockOffset {
+		offset = maxClockOffset
+	}
+	if offset < -maxClockOffset {
+		offset = -maxClockOffset
+	}
+	return t.Add(-offset)
+}
+
+// pingLoop pings the coordinator over conn until the client is no
+// longer connected with conn.
+func (c *Client) pingLoop(conn typesocket.Conn) {
+	for {
+		c.mu.Lock()
+		connected := c.conn == conn
+		c.mu.Unlock()
+		if !connected {
+			return
+		}
+
+		if err := conn.Send("ping", coordinator.Ping{ClientTime: time.Now()}); err != nil {
+			c.Handler.DebugError(err)
+		}
+		time.Sleep(pingInterval)
+	}
+}
+
+func (c *Client) pong(conn typesocket.Conn, v coordinator.Pong) {
+	c.clock.observe(v.ClientTime, v.ServerTime, time.Now())
+}
@@ -0,0 +1,58 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package vuvuzela
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClockEstimator(t *testing.T) {
+	var e clockEstimator
+	if _, ok := e.estimate(); ok {
+		t.Fatal("expected no estimate without pings")
+	}
+
+	// The coordinator's clock is 2s ahead. The request takes 50ms and
+	// the reply takes between 50ms and 250ms.
+	offset := 2 * time.Second
+	start := time.Now()
+	for i := 0; i < 20; i++ {
+		sent := start.Add(time.Duration(i) * pingInterval)
+		serverTime := sent.Add(50 * time.Millisecond).Add(offset)
+		reply := time.Duration(50+10*(i%21)) * time.Millisecond
+		if i == 17 {
+			reply = 50 * time.Millisecond
+		}
+		e.observe(sent, serverTime, sent.Add(50*time.Millisecond+reply))
+	}
+
+	est, ok := e.estimate()
+	if !ok {
+		t.Fatal("expected an estimate")
+	}
+	if est.Offset != offset {
+		t.Fatalf("offset: got %s, want %s", est.Offset, offset)
+	}
+	if est.RTT < 100*time.Millisecond || est.RTT > 300*time.Millisecond {
+		t.Fatalf("rtt: got %s, want between 100ms and 300ms", est.RTT)
+	}
+
+	// A pong that seems to arrive before its ping is ignored.
+	e.observe(start, start, start.Add(-time.Second))
+	if est2, _ := e.estimate(); est2 != est {
+		t.Fatalf("estimate changed from %v to %v", est, est2)
+	}
+}
+
+func TestLocalTimeOffsetCap(t *testing.T) {
+	c := new(Client)
+	now := time.Now()
+	// The coordinator claims that its clock is an hour ahead.
+	c.clock.observe(now, now.Add(time.Hour), now)
+	if got := c.localTime(now); !got.Equal(now.Add(-maxClockOffset)) {
+		t.Fatalf("localTime moved the time by %s, want at most %s", now.Sub(got), maxClockOffset)
+	}
+}
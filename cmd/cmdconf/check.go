@@ -0,0 +1,77 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package cmdconf
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/net/context"
+
+	"vuvuzela.io/alpenhorn/errors"
+	"vuvuzela.io/vuvuzela/convo"
+	"vuvuzela.io/vuvuzela/mixnet"
+)
+
+// checkTimeout bounds how long CheckMixers waits for each mixer.
+const checkTimeout = 10 * time.Second
+
+// CheckMixers calls the Status RPC on every mixer in the convo config,
+// using key as the client's edtls key, and writes a report to w. For
+// configs with a mixer pool, every mixer in the pool is checked. It
+// returns an error if any mixer can not be reached or does not present
+// the key listed in the config.
+func CheckMixers(w io.Writer, key ed25519.PrivateKey, conf *convo.ConvoConfig) error {
+	var chains [][]mixnet.PublicServerConfig
+	if conf.MixPool != nil {
+		chains = [][]mixnet.PublicServerConfig{conf.MixPool.Mixers}
+	} else {
+		chains = conf.Chains(0)
+	}
+
+	client := &mixnet.Client{
+		Key: key,
+	}
+
+	failed := 0
+	for i, chain := range chains {
+		if conf.MixPool != nil {
+			fmt.Fprintf(w, "mix pool (%d mixers):\n", len(chain))
+		} else {
+			fmt.Fprintf(w, "chain %d (%d mixers):\n", i, len(chain))
+		}
+		for pos, server := range chain {
+			ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+			status, err := client.Status(ctx, server)
+			cancel()
+			if err != nil {
+				failed++
+				fmt.Fprintf(w, "  %d %s FAIL: %s\n", pos, server.Address, err)
+				continue
+			}
+
+			rounds := make([]string, len(status.Rounds))
+			for j, r := range status.Rounds {
+				rounds[j] = fmt.Sprintf("%s/%d:%s", r.Service, r.Round, r.Phase)
+			}
+			state := "ok"
+			if status.Draining {
+				state = "draining"
+			}
+			fmt.Fprintf(w, "  %d %s %s: version=%s services=%s uptime=%s queue=%d rounds=[%s]\n",
+				pos, server.Address, state, status.Version, strings.Join(status.Services, ","),
+				time.Duration(status.UptimeSeconds)*time.Second, status.DecryptionQueue,
+				strings.Join(rounds, " "))
+		}
+	}
+
+	if failed > 0 {
+		return errors.New("%d mixers failed the check", failed)
+	}
+	return nil
+}
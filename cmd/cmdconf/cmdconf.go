@@ -14,6 +14,9 @@ import (
 
 	"vuvuzela.io/alpenhorn/encoding/toml"
 	"vuvuzela.io/crypto/rand"
+	"vuvuzela.io/vuvuzela/admission"
+	"vuvuzela.io/vuvuzela/coordinator"
+	"vuvuzela.io/vuvuzela/mixnet"
 )
 
 var funcMap = template.FuncMap{
@@ -27,7 +30,27 @@ type MixerConfig struct {
 	ListenAddr string
 	DebugAddr  string
 
+	// MetricsAddr is the address of the Prometheus metrics
+	// endpoint; metrics are not served if it is empty. The endpoint
+	// has no authentication, so new configs serve it on localhost.
+	MetricsAddr string
+
 	Noise rand.Laplace
+
+	// AuditShuffles makes the mixer commit to its shuffles so
+	// that the coordinator can audit them.
+	AuditShuffles bool
+
+	// ReplayWindow is the number of rounds that the mixer's replay
+	// filter remembers onions for.
+	ReplayWindow uint32
+
+	// RoundTTLs bounds how long the mixer keeps stale rounds.
+	RoundTTLs mixnet.RoundTTLs
+
+	// DrainTimeout is how long the mixer waits for open rounds
+	// to finish when it is shutting down.
+	DrainTimeout time.Duration
 }
 
 func NewMixerConfig() *MixerConfig {
@@ -40,13 +63,20 @@ func NewMixerConfig() *MixerConfig {
 		PublicKey:  publicKey,
 		PrivateKey: privateKey,
 
-		ListenAddr: "0.0.0.0:2718",
-		DebugAddr:  "0.0.0.0:6060",
+		ListenAddr:  "0.0.0.0:2718",
+		DebugAddr:   "0.0.0.0:6060",
+		MetricsAddr: "127.0.0.1:2719",
 
 		Noise: rand.Laplace{
 			Mu: 100,
 			B:  3.0,
 		},
+
+		ReplayWindow: 4,
+
+		RoundTTLs: mixnet.DefaultRoundTTLs,
+
+		DrainTimeout: 1 * time.Minute,
 	}
 
 	return conf
@@ -59,10 +89,31 @@ privateKey = {{.PrivateKey | base32 | printf "%q"}}
 
 listenAddr = {{.ListenAddr | printf "%q"}}
 debugAddr = {{.DebugAddr | printf "%q" }}
+# Metrics are served without authentication. Bind them to a public
+# address only if access to it is restricted.
+metricsAddr = {{.MetricsAddr | printf "%q"}}
+
+# Commit to every shuffle so that the coordinator can audit it.
+auditShuffles = {{.AuditShuffles}}
+
+# Number of rounds that the replay filter remembers onions for.
+replayWindow = {{.ReplayWindow}}
+
+# On SIGTERM or SIGINT, the mixer stops accepting new rounds and waits
+# this long for open rounds to finish before exiting. SIGUSR1 stops new
+# rounds without exiting.
+drainTimeout = {{.DrainTimeout | printf "%q"}}
 
 [noise]
 mu = {{.Noise.Mu | printf "%0.1f"}}
 b = {{.Noise.B | printf "%0.1f"}}
+
+# Rounds that stop making progress are deleted after spending this
+# long waiting for settings, open, or closed (waiting for DeleteRound).
+[roundTTLs]
+settings = {{.RoundTTLs.Settings | printf "%q"}}
+open = {{.RoundTTLs.Open | printf "%q"}}
+closed = {{.RoundTTLs.Closed | printf "%q"}}
 `
 
 func (c *MixerConfig) TOML() []byte {
@@ -82,9 +133,84 @@ type CoordinatorConfig struct {
 
 	ListenAddr string
 
+	// MetricsAddr is the address of the Prometheus metrics
+	// endpoint; metrics are not served if it is empty. The endpoint
+	// has no authentication, so new configs serve it on localhost.
+	MetricsAddr string
+
+	RoundDelay time.Duration
+
+	// Schedule bounds how the coordinator adapts the round delay and
+	// the number of rounds in flight to the time it takes to mix.
+	Schedule coordinator.ScheduleBounds
+
+	// Services lists the mix services that the coordinator runs. If
+	// empty, the coordinator runs only the Convo service.
+	Services []CoordinatorService
+
+	// NumTraps is the number of trap messages mixed into every
+	// round to detect mixers that drop or replace onions.
+	NumTraps int
+
+	// AuditShuffles enables random partial checking of the mixers'
+	// shuffles after every round. The mixers must have AuditShuffles
+	// enabled as well.
+	AuditShuffles bool
+
+	// AuditDir is the directory where the coordinator saves the
+	// evidence from shuffle audits. Evidence is not saved if empty.
+	AuditDir string
+
+	// Limits bounds the onions that clients can submit per round.
+	Limits coordinator.Limits
+
+	// TokenIssuerKey is the public key of the admission token issuer
+	// (see IssuerConfig). If set, clients need a token for every onion
+	// bundle. TokenEpoch must match the issuer's EpochDuration.
+	TokenIssuerKey []byte
+	TokenEpoch     time.Duration
+
+	// ProofOfWork makes clients solve a puzzle for every onion
+	// bundle, with the difficulty settings in PoW.
+	ProofOfWork bool
+	PoW         coordinator.PoWSettings
+}
+
+// CoordinatorService configures one of the coordinator's services.
+type CoordinatorService struct {
+	// Name is the name of the mix service, such as "Convo". The
+	// service is served under the URL prefix /<name>/, in lower case.
+	Name string
+
+	// ConfigService is the name of the service's config on the
+	// config server. If empty, it is the same as Name.
+	ConfigService string
+
+	// RoundDelay is the service's round delay. If zero, it is the
+	// coordinator's RoundDelay.
 	RoundDelay time.Duration
 }
 
+// ServiceConfigs returns the coordinator's services, with the defaults
+// filled in.
+func (c *CoordinatorConfig) ServiceConfigs() []CoordinatorService {
+	services := c.Services
+	if len(services) == 0 {
+		services = []CoordinatorService{{Name: "Convo"}}
+	}
+	configs := make([]CoordinatorService, len(services))
+	for i, s := range services {
+		if s.ConfigService == "" {
+			s.ConfigService = s.Name
+		}
+		if s.RoundDelay == 0 {
+			s.RoundDelay = c.RoundDelay
+		}
+		configs[i] = s
+	}
+	return configs
+}
+
 func NewCoordinatorConfig() *CoordinatorConfig {
 	publicKey, privateKey, err := ed25519.GenerateKey(cryptoRand.Reader)
 	if err != nil {
@@ -95,9 +221,25 @@ func NewCoordinatorConfig() *CoordinatorConfig {
 		PublicKey:  publicKey,
 		PrivateKey: privateKey,
 
-		ListenAddr: "0.0.0.0:8000",
+		ListenAddr:  "0.0.0.0:8000",
+		MetricsAddr: "127.0.0.1:8001",
 
 		RoundDelay: 800 * time.Millisecond,
+
+		Services: []CoordinatorService{
+			{
+				Name:          "Convo",
+				ConfigService: "Convo",
+			},
+		},
+
+		Schedule: coordinator.DefaultScheduleBounds,
+
+		Limits: coordinator.DefaultLimits,
+
+		TokenEpoch: defaultTokenEpoch,
+
+		PoW: coordinator.DefaultPoWSettings,
 	}
 
 	return conf
@@ -109,8 +251,63 @@ publicKey  = {{.PublicKey | base32 | printf "%q"}}
 privateKey = {{.PrivateKey | base32 | printf "%q"}}
 
 listenAddr = {{.ListenAddr | printf "%q"}}
+# Metrics are served without authentication. Bind them to a public
+# address only if access to it is restricted.
+metricsAddr = {{.MetricsAddr | printf "%q"}}
 
 roundDelay = {{.RoundDelay | printf "%q"}}
+
+# Number of trap messages per round (0 disables trap auditing).
+numTraps = {{.NumTraps}}
+
+# Audit the mixers' shuffles after every round, saving the evidence
+# to auditDir (leave empty to discard the evidence).
+auditShuffles = {{.AuditShuffles}}
+auditDir = {{.AuditDir | printf "%q"}}
+
+# Require an admission token from the issuer with this public key for
+# every onion bundle (leave empty to accept bundles without tokens).
+tokenIssuerKey = {{.TokenIssuerKey | base32 | printf "%q"}}
+tokenEpoch = {{.TokenEpoch | printf "%q"}}
+
+# Require a proof-of-work puzzle solution for every onion bundle.
+proofOfWork = {{.ProofOfWork}}
+
+# The round delay adapts to the mixing time between minRoundDelay and
+# maxRoundDelay ("0s" uses roundDelay and 10 times minRoundDelay), and
+# the number of rounds in flight between minInFlight and maxInFlight.
+[schedule]
+minRoundDelay = {{.Schedule.MinRoundDelay | printf "%q"}}
+maxRoundDelay = {{.Schedule.MaxRoundDelay | printf "%q"}}
+minInFlight = {{.Schedule.MinInFlight}}
+maxInFlight = {{.Schedule.MaxInFlight}}
+
+# Bundles that would exceed these limits are rejected: onions per round
+# from one connection, bytes per onion bundle, and bytes of onions per
+# round from all connections.
+[limits]
+connOnions = {{.Limits.ConnOnions}}
+bundleSize = {{.Limits.BundleSize}}
+roundMemory = {{.Limits.RoundMemory}}
+
+# Puzzle difficulty in bits, used if proofOfWork is true. The difficulty
+# goes up after rounds with more than targetOnions onions, and down after
+# rounds with less than half of targetOnions.
+[pow]
+minDifficulty = {{.PoW.MinDifficulty}}
+maxDifficulty = {{.PoW.MaxDifficulty}}
+targetOnions = {{.PoW.TargetOnions}}
+
+# The mix services to run, each with its own round loop. A service is
+# served under the URL prefix /<name>/ in lower case. The config service
+# defaults to the name, and a zero round delay uses roundDelay above.
+{{- range .Services}}
+
+[[services]]
+name = {{.Name | printf "%q"}}
+configService = {{.ConfigService | printf "%q"}}
+roundDelay = {{.RoundDelay | printf "%q"}}
+{{- end}}
 `
 
 func (c *CoordinatorConfig) TOML() []byte {
@@ -123,3 +320,87 @@ func (c *CoordinatorConfig) TOML() []byte {
 	}
 	return buf.Bytes()
 }
+
+type IssuerConfig struct {
+	PublicKey  ed25519.PublicKey
+	PrivateKey ed25519.PrivateKey
+
+	ListenAddr string
+
+	// TokenKey is the issuer's admission.PrivateKey in PKCS #1 form.
+	TokenKey []byte
+
+	EpochDuration  time.Duration
+	TokensPerEpoch int
+
+	// Users lists the client keys that can get tokens.
+	Users []ed25519.PublicKey
+
+	// OpenIssuance lets any client key get tokens, not just Users.
+	OpenIssuance bool
+}
+
+// defaultTokenEpoch is the default length of an admission token epoch.
+const defaultTokenEpoch = 1 * time.Hour
+
+func NewIssuerConfig() *IssuerConfig {
+	publicKey, privateKey, err := ed25519.GenerateKey(cryptoRand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	tokenKey, err := admission.GenerateKey(2048)
+	if err != nil {
+		panic(err)
+	}
+
+	conf := &IssuerConfig{
+		PublicKey:  publicKey,
+		PrivateKey: privateKey,
+
+		ListenAddr: "0.0.0.0:8080",
+
+		TokenKey: tokenKey.PrivateBytes(),
+
+		EpochDuration: defaultTokenEpoch,
+		// Enough for one token per round with 800ms rounds.
+		TokensPerEpoch: 5000,
+	}
+
+	return conf
+}
+
+const issuerTemplate = `# Vuvuzela admission token issuer config
+
+publicKey  = {{.PublicKey | base32 | printf "%q"}}
+privateKey = {{.PrivateKey | base32 | printf "%q"}}
+
+listenAddr = {{.ListenAddr | printf "%q"}}
+
+# The coordinator's tokenIssuerKey is printed by vuvuzela-issuer -init.
+tokenKey = {{.TokenKey | base32 | printf "%q"}}
+
+# Tokens are valid for one epoch. Every client key can get
+# tokensPerEpoch tokens per epoch.
+epochDuration = {{.EpochDuration | printf "%q"}}
+tokensPerEpoch = {{.TokensPerEpoch}}
+
+# Client keys that can get tokens.
+users = [{{range .Users}}
+  {{. | base32 | printf "%q"}},{{end}}
+]
+
+# Set openIssuance to give tokens to any client key. Anyone can
+# generate keys, so tokens then do not stop a sybil attack.
+openIssuance = {{.OpenIssuance}}
+`
+
+func (c *IssuerConfig) TOML() []byte {
+	tmpl := template.Must(template.New("issuer").Funcs(funcMap).Parse(issuerTemplate))
+
+	buf := new(bytes.Buffer)
+	err := tmpl.Execute(buf, c)
+	if err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,153 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package cmdconf
+
+import (
+	"bytes"
+	cryptoRand "crypto/rand"
+	"text/template"
+	"time"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+// WorldConfig describes a convo config for vuvuzela-config, which
+// builds the config from the servers' own config files.
+type WorldConfig struct {
+	// Coordinator is the path of the coordinator's config file.
+	// CoordinatorAddress is the address that clients and mixers use
+	// to reach it; if empty, it is the config's ListenAddr.
+	Coordinator        string
+	CoordinatorAddress string
+
+	Mixers []WorldMixer
+
+	// NumChains and ChainLength make the config pick every round's
+	// chains from a pool of the mixers, which needs a Period. If zero,
+	// the mixers form a single chain, in order.
+	NumChains   int
+	ChainLength int
+
+	// Genesis and Period fix round deadlines to the wall clock (see
+	// convo.Schedule). The coordinator decides the deadlines if Period
+	// is zero.
+	Genesis time.Time
+	Period  time.Duration
+
+	// Lifetime is how long the config is valid after it is built.
+	Lifetime time.Duration
+
+	// Guardians must sign the next config.
+	Guardians []WorldGuardian
+}
+
+type WorldMixer struct {
+	// Config is the path of the mixer's config file. Address is the
+	// address that the coordinator uses to reach the mixer; if empty,
+	// it is the config's ListenAddr.
+	Config  string
+	Address string
+}
+
+type WorldGuardian struct {
+	Username string
+	Key      ed25519.PublicKey
+}
+
+func NewWorldConfig() *WorldConfig {
+	return &WorldConfig{
+		Coordinator: "persist/coordinator.conf",
+		Mixers: []WorldMixer{
+			{Config: "persist_vzmix/mixer.conf"},
+		},
+		Lifetime: 30 * 24 * time.Hour,
+	}
+}
+
+const worldTemplate = `# Vuvuzela convo config description for vuvuzela-config
+
+# Paths of the server config files. An empty address uses the server's
+# listenAddr, which must then be reachable from the other servers.
+coordinator = {{.Coordinator | printf "%q"}}
+coordinatorAddress = {{.CoordinatorAddress | printf "%q"}}
+
+# Pick numChains chains of chainLength mixers for every round from the
+# mixers below (0 uses all mixers as a single chain, in order). A pool
+# needs a period.
+numChains = {{.NumChains}}
+chainLength = {{.ChainLength}}
+
+# Fix the deadline of round N to genesis + N*period ("0s" lets the
+# coordinator decide the deadlines).
+# genesis = 2018-03-01T00:00:00Z
+period = {{.Period | printf "%q"}}
+
+# How long the config is valid.
+lifetime = {{.Lifetime | printf "%q"}}
+{{- range .Mixers}}
+
+[[mixers]]
+config = {{.Config | printf "%q"}}
+address = {{.Address | printf "%q"}}
+{{- end}}
+
+# The guardians that sign the next config.
+{{- range .Guardians}}
+
+[[guardians]]
+username = {{.Username | printf "%q"}}
+key = {{.Key | base32 | printf "%q"}}
+{{- end}}
+`
+
+func (c *WorldConfig) TOML() []byte {
+	tmpl := template.Must(template.New("world").Funcs(funcMap).Parse(worldTemplate))
+
+	buf := new(bytes.Buffer)
+	err := tmpl.Execute(buf, c)
+	if err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+// GuardianConfig has a guardian's signing key for vuvuzela-config.
+type GuardianConfig struct {
+	Username   string
+	PublicKey  ed25519.PublicKey
+	PrivateKey ed25519.PrivateKey
+}
+
+func NewGuardianConfig(username string) *GuardianConfig {
+	publicKey, privateKey, err := ed25519.GenerateKey(cryptoRand.Reader)
+	if err != nil {
+		panic(err)
+	}
+
+	return &GuardianConfig{
+		Username:   username,
+		PublicKey:  publicKey,
+		PrivateKey: privateKey,
+	}
+}
+
+const guardianTemplate = `# Vuvuzela config guardian key
+
+username = {{.Username | printf "%q"}}
+
+publicKey  = {{.PublicKey | base32 | printf "%q"}}
+privateKey = {{.PrivateKey | base32 | printf "%q"}}
+`
+
+func (c *GuardianConfig) TOML() []byte {
+	tmpl := template.Must(template.New("guardian").Funcs(funcMap).Parse(guardianTemplate))
+
+	buf := new(bytes.Buffer)
+	err := tmpl.Execute(buf, c)
+	if err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,72 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+// Command vuvuzela-audit verifies the shuffle audit evidence
+// saved by the coordinator.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"vuvuzela.io/vuvuzela/mixnet"
+)
+
+func verify(path string) bool {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", path, err)
+		return false
+	}
+	evidence := new(mixnet.AuditEvidence)
+	if err := json.Unmarshal(data, evidence); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: error decoding evidence: %s\n", path, err)
+		return false
+	}
+
+	results, err := evidence.Verify()
+	if err != nil {
+		fmt.Printf("%s: %s round %d: invalid evidence: %s\n", path, evidence.Service, evidence.Round, err)
+		return false
+	}
+
+	ok := true
+	for _, r := range results {
+		status := "ok"
+		if !r.OK() {
+			status = "FAIL: " + r.Error
+			ok = false
+		}
+		fmt.Printf("%s: %s round %d: mixer %d (%s): revealed=%d noise=%d invalid=%d duplicates=%d replays=%d: %s\n",
+			path, evidence.Service, evidence.Round, r.Position, evidence.Chain[r.Position].Address,
+			r.Revealed, r.Noise, r.Invalid, r.Duplicates, r.Replays, status)
+	}
+	return ok
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s evidence.json...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ok := true
+	for _, path := range flag.Args() {
+		if !verify(path) {
+			ok = false
+		}
+	}
+	if !ok {
+		os.Exit(1)
+	}
+}
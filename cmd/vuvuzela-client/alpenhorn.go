@@ -10,6 +10,7 @@ import (
 	"vuvuzela.io/alpenhorn"
 	"vuvuzela.io/alpenhorn/config"
 	"vuvuzela.io/alpenhorn/log"
+	"vuvuzela.io/vuvuzela/coordinator"
 )
 
 func (gc *GuiClient) Error(err error) {
@@ -17,8 +18,18 @@ func (gc *GuiClient) Error(err error) {
 		log.Error(err)
 		return
 	}
-	// Ignore some errors by default and do it the hacky way since
-	// we don't have a great plan for errors anyway.
+	if e, ok := err.(*coordinator.RoundError); ok {
+		switch e.ErrorCode() {
+		case coordinator.CodeRateLimited:
+			gc.WarnfSync("The coordinator rejected messages in round %d: %s\n", e.Round, e.Err)
+			return
+		case coordinator.CodeServerError:
+			gc.WarnfSync("Round %d failed on the server; messages will be sent again\n", e.Round)
+			return
+		}
+	}
+	// Alpenhorn errors have no codes, so we ignore some of them
+	// the hacky way.
 	matched, _ := regexp.MatchString("round [0-9]+ not configured", err.Error())
 	if matched {
 		return
@@ -59,7 +70,7 @@ func (gc *GuiClient) SendingCall(call *alpenhorn.OutgoingCall) {
 		return
 	}
 	convo.WarnfSync("Calling %s ...\n", call.Username)
-	epochStart, intent := stdRoundSyncer.outgoingCallConvoRound(round)
+	epochStart, intent := newRoundSyncer(gc.convoClient.WallClock()).outgoingCallConvoRound(round)
 
 	call.UpdateIntent(intent)
 
@@ -87,7 +98,7 @@ func (gc *GuiClient) ReceivedCall(call *alpenhorn.IncomingCall) {
 	}
 	wheel := &keywheelStart{
 		sessionKey: call.SessionKey,
-		convoRound: stdRoundSyncer.incomingCallConvoRound(round, call.Intent),
+		convoRound: newRoundSyncer(gc.convoClient.WallClock()).incomingCallConvoRound(round, call.Intent),
 	}
 	if !gc.activateConvo(convo, wheel) {
 		convo.Lock()
@@ -105,7 +116,11 @@ func (gc *GuiClient) NewConfig(chain []*config.SignedConfig) {
 	notify("New %s config", prev.Service)
 }
 
-func (gc *GuiClient) GlobalAnnouncement(message string) {
-	gc.WarnfSync("Global Announcement: %s\n", message)
-	notify("Global Announcement: %s", message)
+func (gc *GuiClient) GlobalAnnouncement(a *coordinator.GlobalAnnouncement) {
+	if a.Severity == coordinator.SeverityInfo {
+		gc.WarnfSync("Global Announcement: %s\n", a.Message)
+	} else {
+		gc.WarnfSync("Global Announcement (%s): %s\n", a.Severity, a.Message)
+	}
+	notify("Global Announcement: %s", a.Message)
 }
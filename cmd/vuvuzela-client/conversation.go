@@ -5,24 +5,18 @@
 package main
 
 import (
-	"bytes"
-	"crypto/hmac"
 	"crypto/rand"
-	"crypto/sha256"
-	"crypto/sha512"
-	"encoding/binary"
 	"fmt"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/jroimartin/gocui"
-	"golang.org/x/crypto/nacl/secretbox"
 
-	"vuvuzela.io/alpenhorn/errors"
 	"vuvuzela.io/alpenhorn/log"
 	"vuvuzela.io/alpenhorn/log/ansi"
 	"vuvuzela.io/vuvuzela/convo"
+	"vuvuzela.io/vuvuzela/stream"
 )
 
 type Conversation struct {
@@ -32,96 +26,74 @@ type Conversation struct {
 	gc *GuiClient
 
 	sync.RWMutex
-	rounds      map[uint32]*convoRound
 	pendingCall *keywheelStart
 
-	outQueue    []seqMsg
-	lastOut     int
-	inQueue     map[uint32][]byte // seq number -> msg
-	relativeSeq uint32
-	seqBase     uint32
-	ack         uint32
-
-	sessionKey      *[32]byte
-	sessionKeyRound uint32
-
-	lastPeerResponding bool
-	lastLatency        time.Duration
-	lastRound          uint32
-	unread             bool
-	focused            bool
-}
-
-type seqMsg struct {
-	RelativeSeq uint32
-	Msg         []byte
-}
+	// session is nil until the first call is activated.
+	session *stream.Session
 
-func (c *Conversation) Init() {
-	c.relativeSeq = 0
-	c.seqBase = 0
-	c.lastPeerResponding = false
-	c.rounds = make(map[uint32]*convoRound)
-	c.inQueue = make(map[uint32][]byte)
-}
+	// outLines has the lines that were typed but not yet written to
+	// the session. Writing can block for many rounds while the peer
+	// is offline, so the lines wait here instead of blocking the GUI.
+	outLines   [][]byte
+	linesReady chan struct{}
 
-type convoRound struct {
-	sentMessage []byte
-	roundKey    *[32]byte
-	created     time.Time
+	lastRound uint32
+	unread    bool
+	focused   bool
 }
 
 func (c *Conversation) ViewName() string {
 	return c.peerUsername
 }
 
-type ConvoMessage struct {
-	Seq      uint32
-	Ack      uint32
-	Lowest   bool
-	UserText []byte // Must be SizeUserText bytes.
+func (c *Conversation) QueueTextMessage(msg []byte) {
+	c.Lock()
+	c.outLines = append(c.outLines, msg)
+	c.Unlock()
+	select {
+	case c.linesReady <- struct{}{}:
+	default:
+	}
+
+	c.Printf("%s\n", c.formatUserMessage(true, string(msg)))
 }
 
-const SizeUserText = convo.SizeMessageBody - 4 - 4 - 1
+// writeLoop writes the queued lines to the session, one at a time.
+func (c *Conversation) writeLoop(session *stream.Session) {
+	for {
+		c.Lock()
+		if len(c.outLines) == 0 {
+			c.Unlock()
+			<-c.linesReady
+			continue
+		}
+		line := c.outLines[0]
+		c.Unlock()
 
-func (cm *ConvoMessage) Marshal() (msg [convo.SizeMessageBody]byte) {
-	binary.BigEndian.PutUint32(msg[0:4], cm.Seq)
-	binary.BigEndian.PutUint32(msg[4:8], cm.Ack)
-	if cm.Lowest {
-		msg[8] = 1
-	} else {
-		msg[8] = 0
-	}
-	copy(msg[9:], cm.UserText)
-	return
-}
+		// Each line is its own message, as in earlier clients.
+		if _, err := session.Write(line); err != nil {
+			c.WarnfSync("Error sending message: %s\n", err)
+			return
+		}
 
-func (cm *ConvoMessage) Unmarshal(msg []byte) error {
-	if len(msg) != convo.SizeMessageBody {
-		return errors.New("bad message length: want %d bytes, got %d", convo.SizeMessageBody, len(msg))
-	}
-	cm.Seq = binary.BigEndian.Uint32(msg[0:4])
-	cm.Ack = binary.BigEndian.Uint32(msg[4:8])
-	if msg[8] == 0 {
-		cm.Lowest = false
-	} else {
-		cm.Lowest = true
+		c.Lock()
+		c.outLines = c.outLines[1:]
+		c.Unlock()
 	}
-	cm.UserText = msg[9:]
-	return nil
 }
 
-func (c *Conversation) QueueTextMessage(msg []byte) {
-	c.Lock()
-	c.outQueue = append(c.outQueue, seqMsg{
-		RelativeSeq: c.relativeSeq,
-		Msg:         msg,
-	})
-	c.relativeSeq++
-	c.Unlock()
-
-	// TODO print only SizeMessage bytes, so user knows what got truncated.
-	c.Printf("%s\n", c.formatUserMessage(true, string(msg)))
+// readLoop prints the messages that the peer sends in the session,
+// one line per message.
+func (c *Conversation) readLoop(session *stream.Session) {
+	for {
+		body, err := session.ReadMessage()
+		if err != nil {
+			return
+		}
+		s := string(body)
+		c.PrintfSync("%s\n", c.formatUserMessage(false, s))
+		seldomNotify("%s says: %s", c.peerUsername, s)
+	}
 }
 
 func (c *Conversation) formatUserMessage(fromMe bool, msg string) string {
@@ -193,161 +165,31 @@ func (c *Conversation) WarnfSync(format string, args ...interface{}) {
 func (c *Conversation) NextMessage(round uint32) *convo.DeadDropMessage {
 	c.Lock()
 	c.lastRound = round
+	session := c.session
 	c.Unlock()
 	// update the round number in the status bar
 	go c.gc.redraw()
 
-	c.Lock()
-
-	// Cover traffic message by default
-	msg := &ConvoMessage{
-		Seq:      0,
-		Ack:      c.ack,
-		Lowest:   false,
-		UserText: make([]byte, SizeUserText),
-	}
-
-	if len(c.outQueue) > 0 {
-		if c.seqBase == 0 {
-			// Use the conversation round as an initial seq#
-			// to avoid seq# duplicates across client restart
-			c.seqBase = round + 1
-		}
-
-		if c.lastOut != -1 && c.lastOut+1 < len(c.outQueue) {
-			c.lastOut++
-		} else {
-			c.lastOut = 0
-			msg.Lowest = true
-		}
-
-		msg.Seq = c.outQueue[c.lastOut].RelativeSeq + c.seqBase
-		msg.UserText = c.outQueue[c.lastOut].Msg
-	}
-	c.Unlock()
-
-	msgdata := msg.Marshal()
-
-	roundKey := c.rollAndReplaceKey(round)
-	if roundKey == nil {
-		// We've rolled past this round so generate cover traffic.
+	if session == nil {
 		dummy := new(convo.DeadDropMessage)
 		rand.Read(dummy.DeadDrop[:])
 		rand.Read(dummy.EncryptedMessage[:])
 		return dummy
 	}
-	ctxt := c.Seal(msgdata[:], round, roundKey)
-
-	var encmsg [convo.SizeEncryptedMessageBody]byte
-	copy(encmsg[:], ctxt)
-
-	c.Lock()
-	c.rounds[round] = &convoRound{
-		sentMessage: encmsg[:],
-		roundKey:    roundKey,
-		created:     time.Now(),
-	}
-	c.Unlock()
-
-	return &convo.DeadDropMessage{
-		DeadDrop:         c.deadDrop(round, roundKey),
-		EncryptedMessage: encmsg,
-	}
+	return session.NextMessage(round)
 }
 
 func (c *Conversation) Reply(round uint32, encmsg []byte) {
-	rlog := log.WithFields(log.Fields{"round": round})
-
-	var responding bool
-	defer func() {
-		c.Lock()
-		c.lastPeerResponding = responding
-		c.Unlock()
-		c.gc.redraw()
-	}()
-
-	c.Lock()
-	st, ok := c.rounds[round]
-	delete(c.rounds, round)
-	// Delete old rounds to ensure forward secrecy.
-	for r := range c.rounds {
-		if r < round-10 {
-			delete(c.rounds, r)
-		}
-	}
-	c.Unlock()
-	if !ok {
-		rlog.Error("round not found")
-		return
-	}
-
-	if bytes.Compare(encmsg, st.sentMessage) == 0 && !c.Solo() {
-		return
-	}
-
-	msgdata, ok := c.Open(encmsg, round, st.roundKey)
-	if !ok {
-		rlog.Error("decrypting peer message failed")
-		return
-	}
+	defer c.gc.redraw()
 
-	msg := new(ConvoMessage)
-	if err := msg.Unmarshal(msgdata); err != nil {
-		rlog.Error("unmarshaling peer message failed")
+	c.RLock()
+	session := c.session
+	c.RUnlock()
+	if session == nil {
 		return
 	}
-
-	responding = true
-
-	c.Lock()
-	c.lastLatency = time.Now().Sub(st.created)
-
-	newOutQueue := c.outQueue[:0]
-	for _, out := range c.outQueue {
-		if c.seqBase > 0 && out.RelativeSeq+c.seqBase <= msg.Ack {
-			// We removed something from the queue, adjust lastOut.
-			if c.lastOut > 0 {
-				c.lastOut -= 1
-			}
-			continue
-		}
-		newOutQueue = append(newOutQueue, out)
-	}
-	c.outQueue = newOutQueue
-
-	var displayMsgs [][]byte
-	if msg.Seq > c.ack {
-		// This message is not cover traffic (msg.Seq != 0) and we have
-		// not processed it yet (msg.Seq > c.ack).  Queue the message.
-		c.inQueue[msg.Seq] = msg.UserText
-
-		// If this is the lowest-numbered message the peer knows about,
-		// then don't bother waiting for any lower-numbered messages.
-		if msg.Lowest {
-			c.ack = msg.Seq - 1
-		}
-
-		// Acknowledge the longest consecutive chain starting from our
-		// current ack value.
-		i := c.ack + 1
-
-		for {
-			in, ok := c.inQueue[i]
-			if !ok {
-				break
-			}
-			displayMsgs = append(displayMsgs, in)
-			delete(c.inQueue, i)
-			c.ack = i
-			i++
-		}
-	}
-	c.Unlock()
-
-	for _, body := range displayMsgs {
-		s := strings.TrimRight(string(body), "\x00")
-		c.PrintfSync("%s\n", c.formatUserMessage(false, s))
-		seldomNotify("%s says: %s", c.peerUsername, s)
+	if err := session.Reply(round, encmsg); err != nil {
+		log.WithFields(log.Fields{"round": round}).Error(err)
 	}
 }
 
@@ -362,81 +204,26 @@ type Status struct {
 func (c *Conversation) Status() *Status {
 	c.RLock()
 	status := &Status{
-		PeerResponding: c.lastPeerResponding,
-		Round:          c.lastRound,
-		Latency:        float64(c.lastLatency) / float64(time.Second),
-		Unread:         c.unread,
-		Unacked:        len(c.outQueue),
+		Round:   c.lastRound,
+		Unread:  c.unread,
+		Unacked: len(c.outLines),
 	}
+	session := c.session
 	c.RUnlock()
+	if session != nil {
+		st := session.Status()
+		status.PeerResponding = st.PeerResponding
+		status.Latency = st.Latency.Seconds()
+		status.Unacked += st.Unacked
+	}
 	return status
 }
 
-func (c *Conversation) Solo() bool {
-	return c.peerUsername == c.myUsername
-}
-
-func (c *Conversation) Seal(message []byte, round uint32, roundKey *[32]byte) []byte {
-	var nonce [24]byte
-	binary.BigEndian.PutUint32(nonce[:], round)
-	nameHash := sha256.Sum256([]byte(c.peerUsername))
-	copy(nonce[4:], nameHash[:16])
-
-	ctxt := secretbox.Seal(nil, message, &nonce, roundKey)
-	return ctxt
-}
-
-func (c *Conversation) Open(ctxt []byte, round uint32, roundKey *[32]byte) ([]byte, bool) {
-	var nonce [24]byte
-	binary.BigEndian.PutUint32(nonce[:], round)
-	nameHash := sha256.Sum256([]byte(c.myUsername))
-	copy(nonce[4:], nameHash[:16])
-
-	return secretbox.Open(nil, ctxt, &nonce, roundKey)
-}
-
 type keywheelStart struct {
 	sessionKey *[32]byte
 	convoRound uint32
 }
 
-func (c *Conversation) rollAndReplaceKey(targetRound uint32) *[32]byte {
-	c.Lock()
-	key, keyRound := c.sessionKey, c.sessionKeyRound
-	c.Unlock()
-
-	newKey := rollKey(key, keyRound, targetRound)
-	if newKey == nil {
-		return nil
-	}
-
-	c.Lock()
-	c.sessionKey, c.sessionKeyRound = newKey, targetRound
-	c.Unlock()
-
-	return newKey
-}
-
-func rollKey(currentKey *[32]byte, keyRound, targetRound uint32) *[32]byte {
-	if keyRound > targetRound {
-		return nil
-	}
-
-	newKey := new([32]byte)
-	copy(newKey[:], currentKey[:])
-
-	hash := sha512.New512_256()
-	key := newKey[:]
-	for r := keyRound; r < targetRound; r++ {
-		hash.Reset()
-		binary.Write(hash, binary.BigEndian, r)
-		hash.Write(key)
-		key = hash.Sum(key[:0])
-	}
-
-	return newKey
-}
-
 // roundSyncer is used to agree on a past convo round, which is used to
 // bootstrap a convo's keywheel for forward secrecy. The sender calls
 // outgoingCallConvoRound and the receiver calls incomingCallConvoRound.
@@ -446,35 +233,58 @@ func rollKey(currentKey *[32]byte, keyRound, targetRound uint32) *[32]byte {
 //   agreedRoundSender == agreedRoundReceiver
 type roundSyncer struct {
 	roundingIncrement uint32
+
+	// If schedule is set, epochs are epochLength of wall-clock time
+	// instead of roundingIncrement rounds, so they do not depend on
+	// how fast the coordinator runs rounds.
+	schedule    *convo.Schedule
+	epochLength time.Duration
 }
 
 var stdRoundSyncer = roundSyncer{
 	roundingIncrement: 10000,
 }
 
+const stdEpochLength = 6 * time.Hour
+
+// newRoundSyncer returns the round syncer for a convo config's
+// wall-clock schedule, which may be nil.
+func newRoundSyncer(schedule *convo.Schedule) roundSyncer {
+	if schedule == nil {
+		return stdRoundSyncer
+	}
+	return roundSyncer{
+		schedule:    schedule,
+		epochLength: stdEpochLength,
+	}
+}
+
 func (rs roundSyncer) outgoingCallConvoRound(latestRound uint32) (round uint32, intent int) {
-	return rs.epochStart(latestRound)
+	epoch := rs.epoch(latestRound)
+	return rs.epochStart(epoch), int(epoch % 2)
 }
 
 func (rs roundSyncer) incomingCallConvoRound(latestRound uint32, intent int) uint32 {
-	epochStart, currIntent := rs.epochStart(latestRound)
-	if currIntent == intent {
-		return epochStart
+	epoch := rs.epoch(latestRound)
+	if int(epoch%2) != intent {
+		epoch--
 	}
-	return epochStart - rs.roundingIncrement
+	return rs.epochStart(epoch)
 }
 
-func (rs roundSyncer) epochStart(round uint32) (uint32, int) {
-	c := rs.roundingIncrement
-	intent := int((round / c) % 2)
-	return round / c * c, intent
+func (rs roundSyncer) epoch(round uint32) uint32 {
+	if rs.schedule == nil {
+		return round / rs.roundingIncrement
+	}
+	return uint32(rs.schedule.Deadline(round).Sub(rs.schedule.Genesis) / rs.epochLength)
 }
 
-func (c *Conversation) deadDrop(round uint32, roundKey *[32]byte) (id convo.DeadDrop) {
-	h := hmac.New(sha256.New, roundKey[:])
-	h.Write([]byte("DeadDrop"))
-	binary.Write(h, binary.BigEndian, round)
-	r := h.Sum(nil)
-	copy(id[:], r)
-	return
+// epochStart returns the first round of the epoch.
+func (rs roundSyncer) epochStart(epoch uint32) uint32 {
+	if rs.schedule == nil {
+		return epoch * rs.roundingIncrement
+	}
+	start := rs.schedule.Genesis.Add(time.Duration(epoch) * rs.epochLength)
+	// The first round with a deadline at or after the start.
+	return rs.schedule.Round(start.Add(-1))
 }
@@ -5,85 +5,44 @@
 package main
 
 import (
-	"bytes"
-	"crypto/rand"
-	"reflect"
 	"testing"
+	"time"
 
-	"github.com/davidlazar/go-crypto/encoding/base32"
+	"vuvuzela.io/vuvuzela/convo"
 )
 
-func TestSoloConversation(t *testing.T) {
-	convo := &Conversation{
-		myUsername:      "alice@example.org",
-		peerUsername:    "alice@example.org",
-		sessionKey:      new([32]byte),
-		sessionKeyRound: 23,
-	}
-	rand.Read(convo.sessionKey[:])
-
-	msg := make([]byte, 256)
-	rand.Read(msg)
-
-	var round uint32 = 42
-	convo.rollAndReplaceKey(round)
-	ctxt := convo.Seal(msg, round, convo.sessionKey)
-	xmsg, ok := convo.Open(ctxt, round, convo.sessionKey)
-	if !ok {
-		t.Fatalf("failed to decrypt message")
-	}
-
-	if bytes.Compare(msg, xmsg) != 0 {
-		t.Fatalf("messages don't match")
-	}
-}
-
-func TestMarshalConvoMessage(t *testing.T) {
-	cm := &ConvoMessage{
-		Seq:      55555,
-		Ack:      22222,
-		Lowest:   true,
-		UserText: make([]byte, SizeUserText),
-	}
-	copy(cm.UserText, []byte("hello world"))
-	data := cm.Marshal()
-
-	xcm := new(ConvoMessage)
-	if err := xcm.Unmarshal(data[:]); err != nil {
-		t.Fatalf("Unmarshal error: %s", err)
-	}
-
-	if !reflect.DeepEqual(cm, xcm) {
-		t.Fatalf("%#v != %#v", cm, xcm)
-	}
-}
-
-func TestRollKey(t *testing.T) {
-	k0 := new([32]byte)
-	k1 := rollKey(k0, 0, 1)
-	k2 := rollKey(k1, 1, 2)
-
-	k2a := rollKey(k0, 0, 2)
-
-	if !bytes.Equal(k2[:], k2a[:]) {
-		t.Fatalf("%v != %v", k2, k2a)
+func TestSyncConvoRound(t *testing.T) {
+	syncer := roundSyncer{
+		roundingIncrement: 1000,
 	}
 
-	k2strExpected := "8sjdg6x3a7g78293d5n470y20myqhj723bp0687637sdt89gkrq0"
-	k2strActual := base32.EncodeToString(k2[:])
-	if k2strActual != k2strExpected {
-		t.Fatalf("got %q, want %q", k2strActual, k2strExpected)
+	for i := uint32(0); i < 10000; i++ {
+		out, intent := syncer.outgoingCallConvoRound(i)
+		for j := i; j < i+1001; j++ {
+			in := syncer.incomingCallConvoRound(j, intent)
+			if out != in {
+				t.Fatalf("i=%d -> out=%d intent=%d :: j=%d -> in=%d", i, out, intent, j, in)
+			}
+		}
 	}
 }
 
-func TestSyncConvoRound(t *testing.T) {
+func TestSyncScheduledConvoRound(t *testing.T) {
 	syncer := roundSyncer{
-		roundingIncrement: 1000,
+		schedule: &convo.Schedule{
+			Genesis: time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC),
+			Period:  7 * time.Second,
+		},
+		epochLength: time.Hour,
 	}
+	epochRounds := uint32(time.Hour / (7 * time.Second))
 
-	for i := uint32(0); i < 10000; i++ {
+	for i := uint32(0); i < 5*epochRounds; i++ {
 		out, intent := syncer.outgoingCallConvoRound(i)
-		for j := i; j < i+1001; j++ {
+		if out > i || syncer.epoch(out) != syncer.epoch(i) {
+			t.Fatalf("i=%d -> out=%d is not the start of its epoch", i, out)
+		}
+		for j := i; j < i+epochRounds; j += 13 {
 			in := syncer.incomingCallConvoRound(j, intent)
 			if out != in {
 				t.Fatalf("i=%d -> out=%d intent=%d :: j=%d -> in=%d", i, out, intent, j, in)
@@ -21,6 +21,7 @@ import (
 	"vuvuzela.io/alpenhorn/log/ansi"
 	"vuvuzela.io/vuvuzela"
 	"vuvuzela.io/vuvuzela/convo"
+	"vuvuzela.io/vuvuzela/stream"
 )
 
 const NumOutgoing = 5
@@ -90,12 +91,14 @@ func (gc *GuiClient) activateConvo(convo *Conversation, wheel *keywheelStart) bo
 	if len(gc.active) < NumOutgoing {
 		gc.active[convo] = true
 		convo.Lock()
-		convo.sessionKey = wheel.sessionKey
-		convo.sessionKeyRound = wheel.convoRound
-		convo.lastPeerResponding = false
-		convo.lastLatency = 0
+		if convo.session == nil {
+			convo.session = stream.NewSession(convo.myUsername, convo.peerUsername, wheel.sessionKey, wheel.convoRound)
+			go convo.readLoop(convo.session)
+			go convo.writeLoop(convo.session)
+		} else {
+			convo.session.Rekey(wheel.sessionKey, wheel.convoRound)
+		}
 		convo.pendingCall = nil
-		convo.lastOut = -1
 		convo.Unlock()
 		return true
 	}
@@ -130,8 +133,8 @@ func (gc *GuiClient) getOrCreateConvo(username string) *Conversation {
 		peerUsername: username,
 		myUsername:   gc.myName,
 		gc:           gc,
+		linesReady:   make(chan struct{}, 1),
 	}
-	convo.Init()
 
 	gc.conversations = append(gc.conversations, convo)
 	maxX, maxY := gc.gui.Size()
@@ -443,7 +446,13 @@ func (gc *GuiClient) layout(g *gocui.Gui) error {
 		more = "-MORE-"
 	}
 
-	fmt.Fprintf(sv, " [%s]  [%s]%s %s", gc.myName, menu, roundLatency, ansi.Colorf(more, ansi.Yellow, ansi.Bold))
+	// The connection to the coordinator, or "-" until it is measured.
+	coordinator := "-"
+	if est, ok := gc.convoClient.ClockEstimate(); ok {
+		coordinator = fmt.Sprintf("%dms, clock %+.1fs", est.RTT/time.Millisecond, est.Offset.Seconds())
+	}
+
+	fmt.Fprintf(sv, " [%s]  [%s]%s  [coordinator: %s] %s", gc.myName, menu, roundLatency, coordinator, ansi.Colorf(more, ansi.Yellow, ansi.Bold))
 
 	partner := "vuvuzela"
 	if gc.selectedConvo != nil {
@@ -13,6 +13,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/davidlazar/go-crypto/encoding/base32"
 	"golang.org/x/crypto/ed25519"
 
 	"vuvuzela.io/alpenhorn"
@@ -20,11 +21,14 @@ import (
 	"vuvuzela.io/alpenhorn/errors"
 	"vuvuzela.io/alpenhorn/log"
 	"vuvuzela.io/vuvuzela"
+	"vuvuzela.io/vuvuzela/admission"
 )
 
 var username = flag.String("username", "", "Alpenhorn username")
 var debug = flag.Bool("debug", false, "Turn on debug mode")
-var latency = flag.Duration("latency", 150*time.Millisecond, "latency to coordinator")
+var latency = flag.Duration("latency", 150*time.Millisecond, "latency to coordinator until it is measured")
+var issuerAddr = flag.String("issuer", "", "address of the admission token issuer, if the coordinator requires tokens")
+var issuerKey = flag.String("issuerKey", "", "key of the admission token issuer")
 
 func main() {
 	flag.Parse()
@@ -46,6 +50,18 @@ func main() {
 	alpenhornClient, isNewAlpClient := LoadAlpenhornState(confHome, *username)
 	vuvuzelaClient, isNewVuvuzelaClient := LoadVuvuzelaState(confHome, *username)
 	vuvuzelaClient.CoordinatorLatency = *latency
+	if *issuerAddr != "" {
+		key, err := base32.DecodeString(*issuerKey)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			fmt.Println("invalid issuer key")
+			os.Exit(1)
+		}
+		vuvuzelaClient.Tokens = &admission.Wallet{
+			IssuerAddress: *issuerAddr,
+			IssuerKey:     key,
+			Key:           alpenhornClient.LongTermPrivateKey,
+		}
+	}
 
 	gc := &GuiClient{
 		myName:          alpenhornClient.Username,
@@ -0,0 +1,78 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"vuvuzela.io/alpenhorn/edtls"
+	"vuvuzela.io/alpenhorn/log"
+	"vuvuzela.io/vuvuzela/cmd/cmdconf"
+	"vuvuzela.io/vuvuzela/convo"
+	"vuvuzela.io/vuvuzela/coordinator"
+)
+
+var severities = map[string]coordinator.Severity{
+	"info":     coordinator.SeverityInfo,
+	"warning":  coordinator.SeverityWarning,
+	"critical": coordinator.SeverityCritical,
+}
+
+// announce signs the message with the guardian key and sends it to the
+// coordinator of the current config, which relays it to the clients.
+func announce(message string) {
+	sev, ok := severities[*severity]
+	if !ok {
+		log.Fatalf("unknown severity: %q", *severity)
+	}
+
+	guardian := new(cmdconf.GuardianConfig)
+	readTOML(*guardianPath, guardian)
+
+	conf := currentConfig()
+	if conf == nil {
+		log.Fatalf("no current config in %s", *configDir)
+	}
+	coordinatorConf := conf.Inner.(*convo.ConvoConfig).Coordinator
+
+	now := time.Now()
+	a := &coordinator.GlobalAnnouncement{
+		Message:  message,
+		Severity: sev,
+		Created:  now,
+		Expires:  now.Add(*lifetime),
+	}
+	a.Sign(guardian.PrivateKey)
+	if err := a.Verify(conf.Guardians, now); err != nil {
+		log.Fatalf("invalid announcement: %s", err)
+	}
+
+	data, err := json.Marshal(a)
+	if err != nil {
+		log.Fatal(err)
+	}
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: edtls.NewTLSClientConfig(guardian.PrivateKey, coordinatorConf.Key),
+		},
+		Timeout: 30 * time.Second,
+	}
+	url := fmt.Sprintf("https://%s/convo/sendannouncement", coordinatorConf.Address)
+	resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		log.Fatalf("error sending announcement: %s", err)
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		log.Fatalf("coordinator rejected the announcement: %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	fmt.Printf("! Sent %s announcement, shown until %s\n", sev, a.Expires.Format(time.RFC1123))
+}
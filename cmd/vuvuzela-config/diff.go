@@ -0,0 +1,122 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/davidlazar/go-crypto/encoding/base32"
+
+	"vuvuzela.io/alpenhorn/config"
+	"vuvuzela.io/vuvuzela/convo"
+	"vuvuzela.io/vuvuzela/mixnet"
+)
+
+// diff describes the changes from prev to next for the guardians who
+// review a new config.
+func diff(prev, next *config.SignedConfig) []string {
+	var changes []string
+	add := func(format string, args ...interface{}) {
+		changes = append(changes, fmt.Sprintf(format, args...))
+	}
+
+	if !prev.Expires.Equal(next.Expires) {
+		add("expires %s (was %s)", next.Expires.Format("2006-01-02 15:04"), prev.Expires.Format("2006-01-02 15:04"))
+	}
+
+	prevGuardians := make(map[string]string)
+	for _, g := range prev.Guardians {
+		prevGuardians[base32.EncodeToString(g.Key)] = g.Username
+	}
+	for _, g := range next.Guardians {
+		key := base32.EncodeToString(g.Key)
+		if _, ok := prevGuardians[key]; !ok {
+			add("new guardian %s (%s)", g.Username, key)
+		}
+		delete(prevGuardians, key)
+	}
+	for key, username := range prevGuardians {
+		add("removed guardian %s (%s)", username, key)
+	}
+
+	p := prev.Inner.(*convo.ConvoConfig)
+	n := next.Inner.(*convo.ConvoConfig)
+
+	if p.Version != n.Version {
+		add("convo config version %d (was %d)", n.Version, p.Version)
+	}
+	if !bytes.Equal(p.Coordinator.Key, n.Coordinator.Key) {
+		add("new coordinator key %s", base32.EncodeToString(n.Coordinator.Key))
+	}
+	if p.Coordinator.Address != n.Coordinator.Address {
+		add("coordinator address %s (was %s)", n.Coordinator.Address, p.Coordinator.Address)
+	}
+
+	prevMixers := make(map[string]mixnet.PublicServerConfig)
+	for _, m := range mixers(p) {
+		prevMixers[base32.EncodeToString(m.Key)] = m
+	}
+	for _, m := range mixers(n) {
+		key := base32.EncodeToString(m.Key)
+		old, ok := prevMixers[key]
+		if !ok {
+			add("new mixer %s at %s", key, m.Address)
+		} else if old.Address != m.Address {
+			add("mixer %s moved to %s (was %s)", key, m.Address, old.Address)
+		}
+		delete(prevMixers, key)
+	}
+	for key, m := range prevMixers {
+		add("removed mixer %s at %s", key, m.Address)
+	}
+
+	if layout(p) != layout(n) {
+		add("%s (was %s)", layout(n), layout(p))
+	}
+	if schedule(p) != schedule(n) {
+		add("%s (was %s)", schedule(n), schedule(p))
+	}
+
+	return changes
+}
+
+// mixers returns every mixer in the config once.
+func mixers(c *convo.ConvoConfig) []mixnet.PublicServerConfig {
+	if c.MixPool != nil {
+		return c.MixPool.Mixers
+	}
+	var all []mixnet.PublicServerConfig
+	seen := make(map[string]bool)
+	for _, chain := range c.Chains(0) {
+		for _, m := range chain {
+			key := base32.EncodeToString(m.Key)
+			if !seen[key] {
+				seen[key] = true
+				all = append(all, m)
+			}
+		}
+	}
+	return all
+}
+
+func layout(c *convo.ConvoConfig) string {
+	if pool := c.MixPool; pool != nil {
+		return fmt.Sprintf("%d chains of %d mixers from a pool with seed %s",
+			pool.NumChains, pool.ChainLength, base32.EncodeToString(pool.Seed))
+	}
+	chains := c.Chains(0)
+	if len(chains) == 1 {
+		return fmt.Sprintf("a single chain of %d mixers", len(chains[0]))
+	}
+	return fmt.Sprintf("%d fixed chains", len(chains))
+}
+
+func schedule(c *convo.ConvoConfig) string {
+	if s := c.Schedule; s != nil {
+		return fmt.Sprintf("rounds every %s from %s", s.Period, s.Genesis.UTC().Format("2006-01-02 15:04:05"))
+	}
+	return "rounds scheduled by the coordinator"
+}
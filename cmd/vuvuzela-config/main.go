@@ -0,0 +1,351 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+// Command vuvuzela-config builds and signs convo configs. A config is
+// built from a description of the deployment (see cmdconf.WorldConfig)
+// as a draft, signed by each of the current guardians, and committed
+// to a config directory that the servers load it from (see
+// configsource.Dir):
+//
+//	vuvuzela-config -build
+//	vuvuzela-config -sign -guardian alice.conf
+//	vuvuzela-config -sign -guardian bob.conf
+//	vuvuzela-config -commit
+//
+// Guardians also use it to send global announcements to clients:
+//
+//	vuvuzela-config -announce "Upgrade to the latest client" -guardian alice.conf
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/davidlazar/go-crypto/encoding/base32"
+	"golang.org/x/crypto/ed25519"
+
+	"vuvuzela.io/alpenhorn/cmd/cmdutil"
+	"vuvuzela.io/alpenhorn/config"
+	"vuvuzela.io/alpenhorn/encoding/toml"
+	"vuvuzela.io/alpenhorn/errors"
+	"vuvuzela.io/alpenhorn/log"
+	"vuvuzela.io/crypto/rand"
+	"vuvuzela.io/internal/ioutil2"
+	"vuvuzela.io/vuvuzela/cmd/cmdconf"
+	"vuvuzela.io/vuvuzela/configsource"
+	"vuvuzela.io/vuvuzela/convo"
+	"vuvuzela.io/vuvuzela/mixnet"
+)
+
+var (
+	doInit     = flag.Bool("init", false, "create a config description")
+	doGuardian = flag.String("init-guardian", "", "create a guardian key for the given username")
+	doBuild    = flag.Bool("build", false, "build a draft config from the description")
+	doSign     = flag.Bool("sign", false, "sign the draft config with the guardian key")
+	doCommit   = flag.Bool("commit", false, "make the signed draft the current config")
+	doAnnounce = flag.String("announce", "", "send a global announcement signed with the guardian key")
+
+	configDir    = flag.String("dir", ".", "config directory")
+	worldPath    = flag.String("world", "world.toml", "config description")
+	guardianPath = flag.String("guardian", "guardian.conf", "guardian key")
+
+	severity = flag.String("severity", "info", "announcement severity: info, warning, or critical")
+	lifetime = flag.Duration("lifetime", 24*time.Hour, "how long clients show the announcement")
+)
+
+const service = "Convo"
+
+func draftPath() string {
+	return filepath.Join(*configDir, "draft.json")
+}
+
+func main() {
+	flag.Parse()
+
+	switch {
+	case *doInit:
+		writeTOML(*worldPath, cmdconf.NewWorldConfig().TOML())
+	case *doGuardian != "":
+		conf := cmdconf.NewGuardianConfig(*doGuardian)
+		writeTOML(*guardianPath, conf.TOML())
+		fmt.Printf("Guardian key for %s: %s\n", conf.Username, toml.EncodeBytes(conf.PublicKey))
+	case *doBuild:
+		buildDraft()
+	case *doSign:
+		signDraft()
+	case *doCommit:
+		commitDraft()
+	case *doAnnounce != "":
+		announce(*doAnnounce)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func writeTOML(path string, data []byte) {
+	if !cmdutil.Overwrite(path) {
+		fmt.Println("Nothing to do.")
+		return
+	}
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("! Wrote %s\n", path)
+}
+
+// currentConfig returns the current config in the config directory,
+// or nil if there is none yet.
+func currentConfig() *config.SignedConfig {
+	conf, err := configsource.Dir(*configDir).CurrentConfig(service)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		log.Fatalf("error reading current config: %s", err)
+	}
+	return conf
+}
+
+func buildDraft() {
+	world := new(cmdconf.WorldConfig)
+	readTOML(*worldPath, world)
+
+	prev := currentConfig()
+	next, err := build(world, prev)
+	if err != nil {
+		log.Fatalf("error building config: %s", err)
+	}
+	if err := next.Validate(); err != nil {
+		log.Fatalf("invalid config: %s", err)
+	}
+
+	if prev == nil {
+		fmt.Printf("First config in %s\n", *configDir)
+	} else {
+		fmt.Printf("Changes from the current config %s:\n", prev.Hash())
+		changes := diff(prev, next)
+		if len(changes) == 0 {
+			fmt.Println("  none")
+		}
+		for _, c := range changes {
+			fmt.Printf("  %s\n", c)
+		}
+	}
+
+	writeConfig(draftPath(), next)
+	fmt.Printf("! Wrote draft config %s to %s\n", next.Hash(), draftPath())
+	fmt.Printf("--> It needs the signatures of: %s\n", usernames(signers(prev, next)))
+}
+
+func build(world *cmdconf.WorldConfig, prev *config.SignedConfig) (*config.SignedConfig, error) {
+	coordinatorConf := new(cmdconf.CoordinatorConfig)
+	readTOML(world.Coordinator, coordinatorConf)
+	coordinatorAddr, err := publicAddress(world.CoordinatorAddress, coordinatorConf.ListenAddr)
+	if err != nil {
+		return nil, errors.Wrap(err, "coordinator")
+	}
+
+	mixers := make([]mixnet.PublicServerConfig, len(world.Mixers))
+	for i, m := range world.Mixers {
+		mixerConf := new(cmdconf.MixerConfig)
+		readTOML(m.Config, mixerConf)
+		addr, err := publicAddress(m.Address, mixerConf.ListenAddr)
+		if err != nil {
+			return nil, errors.Wrap(err, "mixer %s", m.Config)
+		}
+		mixers[i] = mixnet.PublicServerConfig{
+			Key:     mixerConf.PublicKey,
+			Address: addr,
+		}
+	}
+
+	inner := &convo.ConvoConfig{
+		Version: convo.ConvoConfigVersion,
+		Coordinator: convo.CoordinatorConfig{
+			Key:     coordinatorConf.PublicKey,
+			Address: coordinatorAddr,
+		},
+	}
+	if world.NumChains > 0 {
+		// Keep the seed of the previous pool, so that a new config
+		// does not change every round's chains.
+		var seed []byte
+		if prev != nil {
+			if pool := prev.Inner.(*convo.ConvoConfig).MixPool; pool != nil {
+				seed = pool.Seed
+			}
+		}
+		if seed == nil {
+			seed = make([]byte, 32)
+			rand.Read(seed)
+		}
+		inner.MixPool = &mixnet.MixPool{
+			Mixers:      mixers,
+			Seed:        seed,
+			NumChains:   world.NumChains,
+			ChainLength: world.ChainLength,
+		}
+	} else {
+		inner.MixServers = mixers
+	}
+	if world.Period > 0 {
+		inner.Schedule = &convo.Schedule{
+			Genesis: world.Genesis,
+			Period:  world.Period,
+		}
+	}
+
+	guardians := make([]config.Guardian, len(world.Guardians))
+	for i, g := range world.Guardians {
+		guardians[i] = config.Guardian{
+			Username: g.Username,
+			Key:      g.Key,
+		}
+	}
+
+	// Round the times, or they include a monotonic clock value.
+	now := time.Now().Round(0)
+	next := &config.SignedConfig{
+		Version: config.SignedConfigVersion,
+		Created: now,
+		Expires: now.Add(world.Lifetime),
+
+		Service:   service,
+		Inner:     inner,
+		Guardians: guardians,
+
+		Signatures: make(map[string][]byte),
+	}
+	if prev != nil {
+		next.PrevConfigHash = prev.Hash()
+	}
+	return next, nil
+}
+
+// publicAddress returns addr, or listenAddr if addr is empty and
+// listenAddr is a specific host.
+func publicAddress(addr, listenAddr string) (string, error) {
+	if addr != "" {
+		return addr, nil
+	}
+	host, _, err := net.SplitHostPort(listenAddr)
+	if err != nil {
+		return "", err
+	}
+	if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+		return "", errors.New("listen address %q is not a public address; set the address in %s", listenAddr, *worldPath)
+	}
+	return listenAddr, nil
+}
+
+// signers returns the guardians that must sign next: the guardians of
+// prev, or of next itself if it is the first config.
+func signers(prev, next *config.SignedConfig) []config.Guardian {
+	if prev == nil {
+		return next.Guardians
+	}
+	return prev.Guardians
+}
+
+func usernames(guardians []config.Guardian) string {
+	names := make([]string, len(guardians))
+	for i, g := range guardians {
+		names[i] = g.Username
+	}
+	return strings.Join(names, ", ")
+}
+
+func signDraft() {
+	guardian := new(cmdconf.GuardianConfig)
+	readTOML(*guardianPath, guardian)
+
+	draft := readConfig(draftPath())
+	prev := currentConfig()
+	if prev != nil && draft.PrevConfigHash != prev.Hash() {
+		log.Fatalf("draft config does not follow the current config %s", prev.Hash())
+	}
+
+	isSigner := false
+	for _, g := range signers(prev, draft) {
+		if bytes.Equal(g.Key, guardian.PublicKey) {
+			isSigner = true
+		}
+	}
+	if !isSigner {
+		log.Fatalf("%s is not a guardian of the current config", guardian.Username)
+	}
+
+	if draft.Signatures == nil {
+		draft.Signatures = make(map[string][]byte)
+	}
+	draft.Signatures[base32.EncodeToString(guardian.PublicKey)] = ed25519.Sign(guardian.PrivateKey, draft.SigningMessage())
+	writeConfig(draftPath(), draft)
+	fmt.Printf("! Signed draft config %s as %s\n", draft.Hash(), guardian.Username)
+}
+
+func commitDraft() {
+	draft := readConfig(draftPath())
+	prev := currentConfig()
+
+	var err error
+	if prev == nil {
+		err = draft.Verify()
+	} else {
+		err = config.VerifyConfigChain(draft, prev)
+	}
+	if err != nil {
+		log.Fatalf("draft config is not ready: %s", err)
+	}
+
+	// Keep the previous config so that servers can verify the chain.
+	if prev != nil {
+		writeConfig(filepath.Join(*configDir, configsource.ChainFile(prev)), prev)
+	}
+	writeConfig(filepath.Join(*configDir, configsource.WorldFile(service)), draft)
+	if err := os.Remove(draftPath()); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("! Config %s is now the current config in %s\n", draft.Hash(), *configDir)
+}
+
+func readTOML(path string, v interface{}) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := toml.Unmarshal(data, v); err != nil {
+		log.Fatalf("error parsing %s: %s", path, err)
+	}
+}
+
+func readConfig(path string) *config.SignedConfig {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	conf := new(config.SignedConfig)
+	if err := json.Unmarshal(data, conf); err != nil {
+		log.Fatalf("error parsing config %s: %s", path, err)
+	}
+	return conf
+}
+
+func writeConfig(path string, conf *config.SignedConfig) {
+	data, err := json.MarshalIndent(conf, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil2.WriteFileAtomic(path, data, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
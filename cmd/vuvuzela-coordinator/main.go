@@ -13,24 +13,42 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
 	"github.com/numbleroot/vuvuzela/tools/vzlog"
 	"vuvuzela.io/alpenhorn/cmd/cmdutil"
-	"vuvuzela.io/alpenhorn/config"
 	"vuvuzela.io/alpenhorn/edtls"
 	"vuvuzela.io/alpenhorn/encoding/toml"
 	"vuvuzela.io/alpenhorn/log"
+	"vuvuzela.io/vuvuzela/admission"
 	"vuvuzela.io/vuvuzela/cmd/cmdconf"
+	"vuvuzela.io/vuvuzela/configsource"
+	"vuvuzela.io/vuvuzela/convo"
 	"vuvuzela.io/vuvuzela/coordinator"
+	"vuvuzela.io/vuvuzela/mixnet"
 )
 
 var (
 	doInit      = flag.Bool("init", false, "initialize a coordinator for the first time")
+	doCheck     = flag.Bool("check", false, "check that every mixer in the convo config is reachable and exit")
 	persistPath = flag.String("persist", "persist", "persistent data directory")
+	configsPath = flag.String("configs", ".", "config file or directory, or \"server\" for the config server")
 )
 
+// mixServices has the mix services that the coordinator can run. A new
+// service also needs a mixer that runs it and checks its chains
+// against its config; vuvuzela-mixer only runs Convo.
+var mixServices = map[string]mixnet.MixService{
+	"Convo": new(convo.ConvoService),
+}
+
+func servicePersistPath(service string) string {
+	return filepath.Join(*persistPath, strings.ToLower(service)+"-coordinator-state")
+}
+
 func initService(service string) {
 	fmt.Printf("--> Initializing %q service.\n", service)
-	servicePersistPath := filepath.Join(*persistPath, strings.ToLower(service)+"-coordinator-state")
+	servicePersistPath := servicePersistPath(service)
 
 	doCoordinator := cmdutil.Overwrite(servicePersistPath)
 
@@ -63,7 +81,39 @@ func initCoordinator(confPath string) {
 		fmt.Printf("--> Please edit the config file before running the server.\n")
 	}
 
-	initService("Convo")
+	conf := readConfig(confPath)
+	for _, s := range conf.ServiceConfigs() {
+		initService(s.Name)
+	}
+}
+
+func readConfig(confPath string) *cmdconf.CoordinatorConfig {
+	data, err := ioutil.ReadFile(confPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	conf := new(cmdconf.CoordinatorConfig)
+	err = toml.Unmarshal(data, conf)
+	if err != nil {
+		log.Fatalf("error parsing config %s: %s", confPath, err)
+	}
+	return conf
+}
+
+// checkMixers checks the mixers in the current convo config and
+// exits with a non-zero status if any of them fail.
+func checkMixers(conf *cmdconf.CoordinatorConfig, configSource configsource.Source) {
+	signedConfig, err := configSource.CurrentConfig("Convo")
+	if err != nil {
+		log.Fatal(err)
+	}
+	convoConfig := signedConfig.Inner.(*convo.ConvoConfig)
+
+	err = cmdconf.CheckMixers(os.Stdout, conf.PrivateKey, convoConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "check failed: %s\n", err)
+		os.Exit(1)
+	}
 }
 
 func main() {
@@ -80,14 +130,16 @@ func main() {
 		return
 	}
 
-	data, err := ioutil.ReadFile(confPath)
+	conf := readConfig(confPath)
+
+	configSource, err := configsource.Parse(*configsPath)
 	if err != nil {
-		log.Fatal(err)
+		log.Fatalf("invalid config source: %s", err)
 	}
-	conf := new(cmdconf.CoordinatorConfig)
-	err = toml.Unmarshal(data, conf)
-	if err != nil {
-		log.Fatalf("error parsing config %s: %s", confPath, err)
+
+	if *doCheck {
+		checkMixers(conf, configSource)
+		return
 	}
 
 	logsDir := filepath.Join(*persistPath, "logs")
@@ -96,23 +148,81 @@ func main() {
 		log.Fatal(err)
 	}
 
-	convoPresistPath := filepath.Join(*persistPath, "convo-coordinator-state")
-	convoServer := &coordinator.Server{
-		Service:    "Convo",
-		PrivateKey: conf.PrivateKey,
-
-		ConfigClient: config.StdClient,
+	if conf.AuditDir != "" {
+		if err := os.MkdirAll(conf.AuditDir, 0700); err != nil {
+			log.Fatal(err)
+		}
+	}
 
-		RoundDelay: conf.RoundDelay,
+	var verifier *admission.Verifier
+	if len(conf.TokenIssuerKey) > 0 {
+		key, err := admission.UnmarshalPublicKey(conf.TokenIssuerKey)
+		if err != nil {
+			log.Fatalf("invalid token issuer key: %s", err)
+		}
+		if conf.TokenEpoch <= 0 {
+			log.Fatalf("invalid token epoch: %s", conf.TokenEpoch)
+		}
+		verifier = &admission.Verifier{
+			Key:           key,
+			EpochDuration: conf.TokenEpoch,
+		}
+	}
 
-		PersistPath: convoPresistPath,
+	var pow *coordinator.PoWSettings
+	if conf.ProofOfWork {
+		pow = &conf.PoW
 	}
-	err = convoServer.LoadPersistedState()
-	if err != nil {
-		log.Fatalf("error loading persisted state: %s", err)
+
+	servers := make([]*coordinator.Server, 0, len(conf.Services))
+	for _, s := range conf.ServiceConfigs() {
+		mixService, ok := mixServices[s.Name]
+		if !ok {
+			log.Fatalf("unknown mix service: %q", s.Name)
+		}
+		numTraps := 0
+		if s.Name == "Convo" {
+			numTraps = conf.NumTraps
+		}
+		server := &coordinator.Server{
+			Service:    s.Name,
+			PrivateKey: conf.PrivateKey,
+			MixService: mixService,
+
+			ConfigSource:  configSource,
+			ConfigService: s.ConfigService,
+
+			RoundDelay: s.RoundDelay,
+			Schedule:   conf.Schedule,
+			NumTraps:   numTraps,
+
+			AuditShuffles: conf.AuditShuffles,
+			AuditDir:      conf.AuditDir,
+
+			Limits:    conf.Limits,
+			Admission: verifier,
+
+			ProofOfWork: pow,
+
+			PersistPath: servicePersistPath(s.Name),
+		}
+		err = server.LoadPersistedState()
+		if err != nil {
+			log.Fatalf("error loading persisted state for service %q: %s", s.Name, err)
+		}
+
+		prefix := "/" + strings.ToLower(s.Name)
+		http.Handle(prefix+"/", http.StripPrefix(prefix, server))
+		servers = append(servers, server)
 	}
 
-	http.Handle("/convo/", http.StripPrefix("/convo", convoServer))
+	if conf.MetricsAddr != "" {
+		go func() {
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", promhttp.Handler())
+			log.Fatal(http.ListenAndServe(conf.MetricsAddr, mux))
+		}()
+	}
 
 	listener, err := edtls.Listen("tcp", conf.ListenAddr, conf.PrivateKey)
 	if err != nil {
@@ -123,9 +233,11 @@ func main() {
 	log.StdLogger.EntryHandler = logHandler
 	log.Infof("Listening on %q", conf.ListenAddr)
 
-	err = convoServer.Run()
-	if err != nil {
-		log.Fatalf("error starting convo loop: %s", err)
+	for _, server := range servers {
+		err = server.Run()
+		if err != nil {
+			log.Fatalf("error starting %s loop: %s", server.Service, err)
+		}
 	}
 	err = http.Serve(listener, nil)
 	if err != nil {
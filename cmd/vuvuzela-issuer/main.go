@@ -0,0 +1,96 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+// Command vuvuzela-issuer hands out anonymous admission tokens for the
+// coordinator. It is meant to run next to the coordinator.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"vuvuzela.io/alpenhorn/cmd/cmdutil"
+	"vuvuzela.io/alpenhorn/edtls"
+	"vuvuzela.io/alpenhorn/encoding/toml"
+	"vuvuzela.io/alpenhorn/log"
+	"vuvuzela.io/vuvuzela/admission"
+	"vuvuzela.io/vuvuzela/cmd/cmdconf"
+)
+
+var (
+	doInit      = flag.Bool("init", false, "create config file")
+	persistPath = flag.String("persist", "persist_issuer", "persistent data directory")
+)
+
+func printKeys(conf *cmdconf.IssuerConfig) {
+	tokenKey, err := admission.UnmarshalPrivateKey(conf.TokenKey)
+	if err != nil {
+		log.Fatalf("invalid token key: %s", err)
+	}
+	fmt.Printf("Issuer key (for clients):               %s\n", toml.EncodeBytes(conf.PublicKey))
+	fmt.Printf("Token issuer key (for the coordinator): %s\n", toml.EncodeBytes(tokenKey.PublicKey.Bytes()))
+}
+
+func main() {
+	flag.Parse()
+
+	if err := os.MkdirAll(*persistPath, 0700); err != nil {
+		log.Fatal(err)
+	}
+	confPath := filepath.Join(*persistPath, "issuer.conf")
+
+	if *doInit {
+		if cmdutil.Overwrite(confPath) {
+			conf := cmdconf.NewIssuerConfig()
+			if err := ioutil.WriteFile(confPath, conf.TOML(), 0600); err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("! Wrote new config file: %s\n", confPath)
+			printKeys(conf)
+		}
+		return
+	}
+
+	data, err := ioutil.ReadFile(confPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	conf := new(cmdconf.IssuerConfig)
+	err = toml.Unmarshal(data, conf)
+	if err != nil {
+		log.Fatalf("error parsing config %s: %s", confPath, err)
+	}
+
+	tokenKey, err := admission.UnmarshalPrivateKey(conf.TokenKey)
+	if err != nil {
+		log.Fatalf("invalid token key: %s", err)
+	}
+	if conf.EpochDuration <= 0 {
+		log.Fatalf("invalid epoch duration: %s", conf.EpochDuration)
+	}
+	if len(conf.Users) == 0 && !conf.OpenIssuance {
+		log.Fatalf("no users in config: add users or set openIssuance = true")
+	}
+
+	issuer := &admission.Issuer{
+		Key:            tokenKey,
+		EpochDuration:  conf.EpochDuration,
+		TokensPerEpoch: conf.TokensPerEpoch,
+		Users:          conf.Users,
+		OpenIssuance:   conf.OpenIssuance,
+	}
+
+	listener, err := edtls.Listen("tcp", conf.ListenAddr, conf.PrivateKey)
+	if err != nil {
+		log.Fatalf("edtls listen: %s", err)
+	}
+
+	log.Infof("Listening on %q", conf.ListenAddr)
+	err = http.Serve(listener, issuer)
+	log.Fatal(err)
+}
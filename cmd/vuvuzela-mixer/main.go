@@ -12,19 +12,25 @@ import (
 	"net/http"
 	_ "net/http/pprof"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"runtime"
+	"syscall"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 
-	"github.com/numbleroot/vuvuzela/eval"
 	"github.com/numbleroot/vuvuzela/tools/vzlog"
 	"vuvuzela.io/alpenhorn/cmd/cmdutil"
+	"vuvuzela.io/alpenhorn/config"
 	"vuvuzela.io/alpenhorn/edtls"
 	"vuvuzela.io/alpenhorn/encoding/toml"
 	"vuvuzela.io/alpenhorn/log"
 	"vuvuzela.io/vuvuzela/cmd/cmdconf"
+	"vuvuzela.io/vuvuzela/configsource"
 	"vuvuzela.io/vuvuzela/convo"
 	"vuvuzela.io/vuvuzela/mixnet"
 	pb "vuvuzela.io/vuvuzela/mixnet/convopb"
@@ -32,9 +38,17 @@ import (
 
 var (
 	doinit      = flag.Bool("init", false, "create config file")
+	doCheck     = flag.Bool("check", false, "check that every mixer in the convo config is reachable and exit")
 	persistPath = flag.String("persist", "persist_vzmix", "persistent data directory")
+	configsPath = flag.String("configs", ".", "config file or directory, or \"server\" for the config server")
 )
 
+// replayPersistInterval is how often the replay filter is saved.
+const replayPersistInterval = 1 * time.Minute
+
+// defaultDrainTimeout is used for configs that do not set DrainTimeout.
+const defaultDrainTimeout = 1 * time.Minute
+
 func writeNewConfig(path string) {
 	data := cmdconf.NewMixerConfig().TOML()
 	err := ioutil.WriteFile(path, data, 0600)
@@ -44,6 +58,74 @@ func writeNewConfig(path string) {
 	fmt.Printf("wrote %s\n", path)
 }
 
+// updateConfigLoop keeps the chain checker up to date with the latest
+// convo config, like the coordinator's updateConfigLoop.
+func updateConfigLoop(configSource configsource.Source, current *config.SignedConfig, chainChecker *convo.ChainChecker) {
+	for {
+		time.Sleep(configsource.Interval(configSource))
+		log.Infof("Fetching latest config")
+
+		next, err := configSource.CurrentConfig("Convo")
+		if err != nil {
+			log.Errorf("failed to fetch current config: %s", err)
+			continue
+		}
+
+		chain, err := configsource.Verify(configSource, current, next)
+		if err != nil {
+			log.Errorf("ignoring new config %s: %s", next.Hash(), err)
+			continue
+		}
+		if len(chain) == 1 {
+			continue
+		}
+
+		if err := chainChecker.SetConfig(next); err != nil {
+			log.Errorf("ignoring invalid config: %s", err)
+			continue
+		}
+		log.WithFields(log.Fields{"prev": current.Hash(), "next": next.Hash()}).Info("Loaded new config")
+		current = next
+	}
+}
+
+// handleSignals drains the mixer on SIGUSR1, and shuts it down on
+// SIGTERM or SIGINT: open rounds get until the drain timeout to finish,
+// then their keys are erased and the server stops. A second SIGTERM or
+// SIGINT exits immediately.
+func handleSignals(mixServer *mixnet.Server, grpcServer *grpc.Server, replayFilter *mixnet.ReplayFilter, drainTimeout time.Duration, done chan<- struct{}) {
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, syscall.SIGTERM, syscall.SIGINT, syscall.SIGUSR1)
+
+	for sig := range sigs {
+		if sig == syscall.SIGUSR1 {
+			log.Infof("Received %s; no longer accepting new rounds", sig)
+			mixServer.Drain()
+			continue
+		}
+		break
+	}
+
+	log.Infof("Shutting down; waiting up to %s for open rounds", drainTimeout)
+	go func() {
+		sig := <-sigs
+		log.Fatalf("Received %s during shutdown; exiting now", sig)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	err := mixServer.Shutdown(ctx)
+	cancel()
+	if err != nil {
+		log.Warnf("Stopped waiting for open rounds: %s", err)
+	}
+	grpcServer.Stop()
+
+	if err := replayFilter.Persist(); err != nil {
+		log.Errorf("error persisting replay filter: %s", err)
+	}
+	close(done)
+}
+
 func main() {
 	flag.Parse()
 
@@ -75,15 +157,47 @@ func main() {
 		log.Fatal(err)
 	}
 
-	// For evaluation purposes, we swap the Alpenhorn
-	// config file retrieval with a static one.
-	// signedConfig, err := config.StdClient.CurrentConfig("Convo")
-	signedConfig, err := eval.StaticConfig()
+	configSource, err := configsource.Parse(*configsPath)
+	if err != nil {
+		log.Fatalf("invalid config source: %s", err)
+	}
+	signedConfig, err := configSource.CurrentConfig("Convo")
 	if err != nil {
 		log.Fatal(err)
 	}
 	convoConfig := signedConfig.Inner.(*convo.ConvoConfig)
 
+	if *doCheck {
+		if err := cmdconf.CheckMixers(os.Stdout, conf.PrivateKey, convoConfig); err != nil {
+			fmt.Fprintf(os.Stderr, "check failed: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	chainChecker := new(convo.ChainChecker)
+	if err := chainChecker.SetConfig(signedConfig); err != nil {
+		log.Fatalf("invalid convo config: %s", err)
+	}
+	go updateConfigLoop(configSource, signedConfig, chainChecker)
+
+	replayFilter := &mixnet.ReplayFilter{
+		Window:      conf.ReplayWindow,
+		PersistPath: filepath.Join(*persistPath, "replay-filter"),
+	}
+	err = replayFilter.LoadPersistedState()
+	if err != nil && !os.IsNotExist(err) {
+		log.Fatalf("error loading replay filter: %s", err)
+	}
+	go func() {
+		for {
+			time.Sleep(replayPersistInterval)
+			if err := replayFilter.Persist(); err != nil {
+				log.Errorf("error persisting replay filter: %s", err)
+			}
+		}
+	}()
+
 	mixServer := &mixnet.Server{
 		SigningKey:     conf.PrivateKey,
 		CoordinatorKey: convoConfig.Coordinator.Key,
@@ -94,6 +208,11 @@ func main() {
 				AccessCounts: make(chan convo.AccessCount, 64),
 			},
 		},
+
+		AuditShuffles: conf.AuditShuffles,
+		RoundTTLs:     conf.RoundTTLs,
+		ReplayFilter:  replayFilter,
+		ChainChecker:  chainChecker,
 	}
 
 	if conf.DebugAddr != "" {
@@ -104,6 +223,14 @@ func main() {
 		runtime.SetMutexProfileFraction(1)
 	}
 
+	if conf.MetricsAddr != "" {
+		go func() {
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", promhttp.Handler())
+			log.Fatal(http.ListenAndServe(conf.MetricsAddr, mux))
+		}()
+	}
+
 	creds := credentials.NewTLS(edtls.NewTLSServerConfig(conf.PrivateKey))
 	grpcServer := grpc.NewServer(grpc.Creds(creds))
 
@@ -118,6 +245,17 @@ func main() {
 		log.Fatalf("net.Listen: %s", err)
 	}
 
+	drainTimeout := conf.DrainTimeout
+	if drainTimeout == 0 {
+		drainTimeout = defaultDrainTimeout
+	}
+	shutdownDone := make(chan struct{})
+	go handleSignals(mixServer, grpcServer, replayFilter, drainTimeout, shutdownDone)
+
 	err = grpcServer.Serve(listener)
-	log.Fatal(err)
+	if err != nil {
+		log.Fatal(err)
+	}
+	<-shutdownDone
+	log.Info("Shutdown complete")
 }
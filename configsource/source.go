@@ -0,0 +1,188 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+// Package configsource provides the signed configs that the
+// coordinator and the mixers run with.
+package configsource
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"vuvuzela.io/alpenhorn/config"
+	"vuvuzela.io/alpenhorn/errors"
+)
+
+// A Source provides the current configs of services. The alpenhorn
+// config client, *config.Client, is a Source.
+type Source interface {
+	CurrentConfig(service string) (*config.SignedConfig, error)
+
+	// FetchAndVerifyChain returns the configs from have to the config
+	// with hash wantHash, newest first. The chain is verified with
+	// config.VerifyConfigChain, so every config is signed by the
+	// guardians of the config before it.
+	FetchAndVerifyChain(have *config.SignedConfig, wantHash string) ([]*config.SignedConfig, error)
+}
+
+// Parse returns the source described by s, which is "server" for the
+// alpenhorn config server, or the path of a config file or directory.
+func Parse(s string) (Source, error) {
+	if s == "server" {
+		return config.StdClient, nil
+	}
+	info, err := os.Stat(s)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return Dir(s), nil
+	}
+	return File(s), nil
+}
+
+// Interval returns how often to check the source for a new config.
+// Local files are checked more often than the config server.
+func Interval(src Source) time.Duration {
+	switch src.(type) {
+	case Dir, File:
+		return 10 * time.Second
+	default:
+		return 1 * time.Minute
+	}
+}
+
+// Verify checks that next may replace prev: next must be prev, or
+// the guardians of prev must have signed next, directly or through a
+// chain of configs. It returns the chain from prev to next, newest
+// first. If prev is nil, there is nothing to check next against, and
+// Verify only validates it.
+func Verify(src Source, prev, next *config.SignedConfig) ([]*config.SignedConfig, error) {
+	if prev == nil {
+		if err := next.Validate(); err != nil {
+			return nil, err
+		}
+		return []*config.SignedConfig{next}, nil
+	}
+	if prev.Service != next.Service {
+		return nil, errors.New("config service changed from %q to %q", prev.Service, next.Service)
+	}
+	if prev.Hash() == next.Hash() {
+		return []*config.SignedConfig{next}, nil
+	}
+	chain, err := src.FetchAndVerifyChain(prev, next.Hash())
+	if err != nil {
+		return nil, err
+	}
+	if chain[0].Hash() != next.Hash() {
+		return nil, errors.New("config chain ends at %s, want %s", chain[0].Hash(), next.Hash())
+	}
+	return chain, nil
+}
+
+// Dir is a directory of configs, such as one written by vuvuzela-config.
+// The current config of a service is in world.json for Convo and in
+// <service>-world.json for other services, with the service in lower
+// case. Older configs are in <hash>.json, so that a new config can be
+// verified against any config before it.
+type Dir string
+
+func (d Dir) CurrentConfig(service string) (*config.SignedConfig, error) {
+	return readConfig(filepath.Join(string(d), WorldFile(service)), service)
+}
+
+func (d Dir) FetchAndVerifyChain(have *config.SignedConfig, wantHash string) ([]*config.SignedConfig, error) {
+	next, err := d.CurrentConfig(have.Service)
+	if err != nil {
+		return nil, err
+	}
+	return fetchChain(string(d), have, next, wantHash)
+}
+
+// File is a config file. Older configs are in the file's directory,
+// named as in Dir.
+type File string
+
+func (f File) CurrentConfig(service string) (*config.SignedConfig, error) {
+	return readConfig(string(f), service)
+}
+
+func (f File) FetchAndVerifyChain(have *config.SignedConfig, wantHash string) ([]*config.SignedConfig, error) {
+	next, err := f.CurrentConfig(have.Service)
+	if err != nil {
+		return nil, err
+	}
+	return fetchChain(filepath.Dir(string(f)), have, next, wantHash)
+}
+
+// WorldFile is the name of the file with the current config of the
+// service in a Dir.
+func WorldFile(service string) string {
+	if service == "Convo" {
+		return "world.json"
+	}
+	return strings.ToLower(service) + "-world.json"
+}
+
+// ChainFile is the name of the file that has conf in a Dir once it
+// is no longer the current config.
+func ChainFile(conf *config.SignedConfig) string {
+	return conf.Hash() + ".json"
+}
+
+// maxChainLength bounds how many older configs fetchChain reads.
+const maxChainLength = 100
+
+// fetchChain follows the PrevConfigHash of next through the configs
+// in dir until it reaches have.
+func fetchChain(dir string, have, next *config.SignedConfig, wantHash string) ([]*config.SignedConfig, error) {
+	if next.Hash() != wantHash {
+		return nil, errors.New("current config is %s, want %s", next.Hash(), wantHash)
+	}
+
+	haveHash := have.Hash()
+	chain := []*config.SignedConfig{next}
+	for next.PrevConfigHash != haveHash {
+		if next.PrevConfigHash == "" {
+			return nil, errors.New("config %s does not follow config %s", wantHash, haveHash)
+		}
+		if len(chain) >= maxChainLength {
+			return nil, errors.New("config chain from %s to %s is too long", haveHash, wantHash)
+		}
+		prev, err := readConfig(filepath.Join(dir, next.PrevConfigHash+".json"), have.Service)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading config chain")
+		}
+		if prev.Hash() != next.PrevConfigHash {
+			return nil, errors.New("config %s has hash %s", next.PrevConfigHash, prev.Hash())
+		}
+		chain = append(chain, prev)
+		next = prev
+	}
+	chain = append(chain, have)
+
+	if err := config.VerifyConfigChain(chain...); err != nil {
+		return nil, err
+	}
+	return chain, nil
+}
+
+func readConfig(path string, service string) (*config.SignedConfig, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	conf := new(config.SignedConfig)
+	if err := json.Unmarshal(data, conf); err != nil {
+		return nil, errors.Wrap(err, "parsing config %s", path)
+	}
+	if conf.Service != service {
+		return nil, errors.New("%s: expected config for service %q, got %q", path, service, conf.Service)
+	}
+	return conf, nil
+}
@@ -0,0 +1,136 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package configsource
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/davidlazar/go-crypto/encoding/base32"
+	"golang.org/x/crypto/ed25519"
+
+	"vuvuzela.io/alpenhorn/config"
+	"vuvuzela.io/vuvuzela/convo"
+	"vuvuzela.io/vuvuzela/mixnet"
+)
+
+type guardian struct {
+	pub  ed25519.PublicKey
+	priv ed25519.PrivateKey
+}
+
+func newGuardian() guardian {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	return guardian{pub, priv}
+}
+
+// newConfig returns a config that follows prev, signed by the given
+// guardians and guarded by next.
+func newConfig(prev *config.SignedConfig, address string, signers []guardian, next guardian) *config.SignedConfig {
+	conf := &config.SignedConfig{
+		Version: config.SignedConfigVersion,
+		Created: time.Now().Round(0),
+		Expires: time.Now().Add(24 * time.Hour).Round(0),
+
+		Guardians: []config.Guardian{{Username: "guardian", Key: next.pub}},
+
+		Service: "Convo",
+		Inner: &convo.ConvoConfig{
+			Version: convo.ConvoConfigVersion,
+			Coordinator: convo.CoordinatorConfig{
+				Key:     next.pub,
+				Address: "localhost:8080",
+			},
+			MixServers: []mixnet.PublicServerConfig{
+				{Key: next.pub, Address: address},
+			},
+		},
+	}
+	if prev != nil {
+		conf.PrevConfigHash = prev.Hash()
+	}
+	msg := conf.SigningMessage()
+	conf.Signatures = make(map[string][]byte)
+	for _, g := range signers {
+		conf.Signatures[base32.EncodeToString(g.pub)] = ed25519.Sign(g.priv, msg)
+	}
+	return conf
+}
+
+func writeConfig(t *testing.T, path string, conf *config.SignedConfig) {
+	data, err := json.Marshal(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDirChain(t *testing.T) {
+	dir, err := ioutil.TempDir("", "configsource_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	src := Dir(dir)
+
+	g1, g2 := newGuardian(), newGuardian()
+	conf1 := newConfig(nil, "localhost:1", []guardian{g1}, g1)
+	conf2 := newConfig(conf1, "localhost:2", []guardian{g1, g2}, g2)
+	conf3 := newConfig(conf2, "localhost:3", []guardian{g2}, g2)
+
+	writeConfig(t, filepath.Join(dir, WorldFile("Convo")), conf1)
+	have, err := src.CurrentConfig("Convo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(src, nil, have); err != nil {
+		t.Fatal(err)
+	}
+
+	writeConfig(t, filepath.Join(dir, ChainFile(conf1)), conf1)
+	writeConfig(t, filepath.Join(dir, ChainFile(conf2)), conf2)
+	writeConfig(t, filepath.Join(dir, WorldFile("Convo")), conf3)
+	next, err := src.CurrentConfig("Convo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain, err := Verify(src, have, next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chain) != 3 || chain[0].Hash() != conf3.Hash() || chain[1].Hash() != conf2.Hash() || chain[2].Hash() != conf1.Hash() {
+		t.Fatalf("unexpected chain: %v", chain)
+	}
+
+	chain, err = Verify(src, next, next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chain) != 1 {
+		t.Fatalf("expected no new configs, got %d", len(chain)-1)
+	}
+
+	// A config that the old guardians did not sign is rejected.
+	g3 := newGuardian()
+	bad := newConfig(conf3, "localhost:4", []guardian{g3}, g3)
+	writeConfig(t, filepath.Join(dir, WorldFile("Convo")), bad)
+	if _, err := Verify(src, next, bad); err == nil {
+		t.Fatal("expected error for config without guardian signatures")
+	}
+
+	// So is a config that does not follow the current one.
+	fork := newConfig(conf1, "localhost:5", []guardian{g1}, g1)
+	writeConfig(t, filepath.Join(dir, WorldFile("Convo")), fork)
+	if _, err := Verify(src, next, fork); err == nil {
+		t.Fatal("expected error for config that does not follow the current config")
+	}
+}
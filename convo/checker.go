@@ -0,0 +1,96 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package convo
+
+import (
+	"sync"
+
+	"vuvuzela.io/alpenhorn/config"
+	"vuvuzela.io/alpenhorn/errors"
+	"vuvuzela.io/vuvuzela/mixnet"
+)
+
+// ChainChecker lets a mixer check the chains that the coordinator
+// sends in NewRound against the signed config of the round's service,
+// so that a coordinator can not surround an honest mixer with servers
+// of its own choosing. It implements mixnet.ChainChecker.
+//
+// The checker keeps the configs of every service that it was given a
+// config for, by the config's service name, and rejects the chains of
+// other services. The coordinator and the mixers fetch new configs
+// independently, so the checker accepts chains from a service's current
+// config and from the one before it.
+type ChainChecker struct {
+	mu       sync.Mutex
+	services map[string]*checkerConfigs
+}
+
+type checkerConfigs struct {
+	current  *config.SignedConfig
+	previous *config.SignedConfig
+}
+
+// chainConfig is an inner config that assigns mix chains to rounds,
+// such as ConvoConfig.
+type chainConfig interface {
+	Validate() error
+	Chains(round uint32) [][]mixnet.PublicServerConfig
+}
+
+// SetConfig makes conf the current config of its service. It does
+// nothing if conf is already the current config.
+func (c *ChainChecker) SetConfig(conf *config.SignedConfig) error {
+	inner, ok := conf.Inner.(chainConfig)
+	if !ok {
+		return errors.New("%s config does not have mix chains: %T", conf.Service, conf.Inner)
+	}
+	if err := inner.Validate(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.services == nil {
+		c.services = make(map[string]*checkerConfigs)
+	}
+	configs, ok := c.services[conf.Service]
+	if !ok {
+		configs = new(checkerConfigs)
+		c.services[conf.Service] = configs
+	}
+	if configs.current != nil && configs.current.Hash() == conf.Hash() {
+		return nil
+	}
+	configs.previous = configs.current
+	configs.current = conf
+	return nil
+}
+
+func (c *ChainChecker) CheckChain(service string, round uint32, chain []mixnet.PublicServerConfig) error {
+	c.mu.Lock()
+	configs, ok := c.services[service]
+	var confs []*config.SignedConfig
+	if ok {
+		confs = []*config.SignedConfig{configs.current, configs.previous}
+	}
+	c.mu.Unlock()
+	if !ok {
+		return errors.New("no config for service %q", service)
+	}
+
+	for _, conf := range confs {
+		if conf == nil {
+			continue
+		}
+		for _, configChain := range conf.Inner.(chainConfig).Chains(round) {
+			if mixnet.EqualChains(configChain, chain) {
+				return nil
+			}
+		}
+	}
+
+	return errors.New("%s round %d: chain does not match the config", service, round)
+}
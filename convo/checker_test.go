@@ -0,0 +1,99 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package convo
+
+import (
+	"crypto/rand"
+	"fmt"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ed25519"
+
+	"vuvuzela.io/alpenhorn/config"
+	"vuvuzela.io/vuvuzela/mixnet"
+)
+
+func testConfig(service string, chain []mixnet.PublicServerConfig) *config.SignedConfig {
+	coordinatorKey, _, _ := ed25519.GenerateKey(rand.Reader)
+	return &config.SignedConfig{
+		Version: config.SignedConfigVersion,
+		Created: time.Now().Round(0),
+		Expires: time.Now().Round(0).Add(24 * time.Hour),
+		Service: service,
+		Inner: &ConvoConfig{
+			Version: ConvoConfigVersion,
+			Coordinator: CoordinatorConfig{
+				Key:     coordinatorKey,
+				Address: "localhost:8080",
+			},
+			MixServers: chain,
+		},
+	}
+}
+
+func TestChainChecker(t *testing.T) {
+	mixers := make([]mixnet.PublicServerConfig, 4)
+	for i := range mixers {
+		key, _, _ := ed25519.GenerateKey(rand.Reader)
+		mixers[i] = mixnet.PublicServerConfig{
+			Key:     key,
+			Address: fmt.Sprintf("localhost:%d", 2000+i),
+		}
+	}
+	chain1 := mixers[0:3]
+	chain2 := mixers[1:4]
+	chain3 := []mixnet.PublicServerConfig{mixers[3], mixers[1], mixers[2]}
+
+	checker := new(ChainChecker)
+	if err := checker.CheckChain("Convo", 1, chain1); err == nil {
+		t.Fatalf("checker without config accepted a chain")
+	}
+
+	if err := checker.SetConfig(testConfig("Convo", chain1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := checker.CheckChain("Convo", 1, chain1); err != nil {
+		t.Fatalf("chain from config rejected: %s", err)
+	}
+	if err := checker.CheckChain("Convo", 1, chain2); err == nil {
+		t.Fatalf("chain not in config accepted")
+	}
+	if err := checker.CheckChain("AddFriend", 1, chain1); err == nil {
+		t.Fatalf("chain for another service accepted")
+	}
+
+	// Chains from the previous config are still accepted.
+	if err := checker.SetConfig(testConfig("Convo", chain2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := checker.CheckChain("Convo", 2, chain1); err != nil {
+		t.Fatalf("chain from previous config rejected: %s", err)
+	}
+	if err := checker.CheckChain("Convo", 2, chain2); err != nil {
+		t.Fatalf("chain from current config rejected: %s", err)
+	}
+
+	if err := checker.SetConfig(testConfig("Convo", chain3)); err != nil {
+		t.Fatal(err)
+	}
+	if err := checker.CheckChain("Convo", 3, chain1); err == nil {
+		t.Fatalf("chain from an old config accepted")
+	}
+
+	// Every service is checked against its own config.
+	if err := checker.SetConfig(testConfig("Test", chain1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := checker.CheckChain("Test", 4, chain1); err != nil {
+		t.Fatalf("chain from the Test config rejected: %s", err)
+	}
+	if err := checker.CheckChain("Test", 4, chain3); err == nil {
+		t.Fatalf("chain from the Convo config accepted for the Test service")
+	}
+	if err := checker.CheckChain("Convo", 4, chain3); err != nil {
+		t.Fatalf("chain from current config rejected: %s", err)
+	}
+}
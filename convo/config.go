@@ -6,6 +6,7 @@ package convo
 
 import (
 	"encoding/json"
+	"time"
 
 	"golang.org/x/crypto/ed25519"
 
@@ -21,12 +22,50 @@ func init() {
 	config.RegisterService("Convo", &ConvoConfig{})
 }
 
-const ConvoConfigVersion = 1
+const ConvoConfigVersion = 2
 
 type ConvoConfig struct {
 	Version     int
 	Coordinator CoordinatorConfig
-	MixServers  []mixnet.PublicServerConfig
+
+	// MixServers is the mix chain when the config has a single chain.
+	MixServers []mixnet.PublicServerConfig
+
+	// MixChains lists independent mix chains that run in parallel when
+	// the config has more than one chain. It is only supported by
+	// version 2 configs, and MixServers must be empty if it is set.
+	MixChains [][]mixnet.PublicServerConfig
+
+	// MixPool, if set, picks every round's chains from a pool of
+	// mixers. It is only supported by version 2 configs, and MixServers
+	// and MixChains must be empty if it is set. It needs a Schedule, so
+	// that the coordinator can not choose round numbers.
+	MixPool *mixnet.MixPool
+
+	// Schedule, if set, fixes the deadline of every round to the
+	// wall clock. It is only supported by version 2 configs.
+	Schedule *Schedule
+}
+
+// Chains returns the mix chains for the given round.
+func (c *ConvoConfig) Chains(round uint32) [][]mixnet.PublicServerConfig {
+	if c.MixPool != nil {
+		return c.MixPool.Chains("Convo", round)
+	}
+	return c.fixedChains()
+}
+
+// WallClock returns the config's round schedule, or nil if the
+// coordinator decides the rounds.
+func (c *ConvoConfig) WallClock() *Schedule {
+	return c.Schedule
+}
+
+func (c *ConvoConfig) fixedChains() [][]mixnet.PublicServerConfig {
+	if len(c.MixChains) > 0 {
+		return c.MixChains
+	}
+	return [][]mixnet.PublicServerConfig{c.MixServers}
 }
 
 func (c *ConvoConfig) UseLatestVersion() {
@@ -52,7 +91,39 @@ type keyAddr struct {
 	Address string
 }
 
+//easyjson:readable
+type convoV2 struct {
+	Version     int
+	Coordinator keyAddr
+	MixChains   [][]keyAddr
+	MixPool     *mixPoolV2
+	Schedule    *scheduleV2
+}
+
+//easyjson:readable
+type mixPoolV2 struct {
+	Mixers      []keyAddr
+	Seed        []byte
+	NumChains   int
+	ChainLength int
+}
+
+//easyjson:readable
+type scheduleV2 struct {
+	Genesis time.Time
+	Period  time.Duration
+}
+
 func (c *ConvoConfig) v1() (*convoV1, error) {
+	if len(c.MixChains) > 0 {
+		return nil, errors.New("version 1 configs do not support multiple mix chains")
+	}
+	if c.MixPool != nil {
+		return nil, errors.New("version 1 configs do not support mix pools")
+	}
+	if c.Schedule != nil {
+		return nil, errors.New("version 1 configs do not support schedules")
+	}
 	c1 := &convoV1{
 		Version:     1,
 		Coordinator: keyAddr{c.Coordinator.Key, c.Coordinator.Address},
@@ -74,6 +145,99 @@ func (c *ConvoConfig) fromV1(c1 *convoV1) error {
 	return nil
 }
 
+func (c *ConvoConfig) v2() (*convoV2, error) {
+	c2 := &convoV2{
+		Version:     2,
+		Coordinator: keyAddr{c.Coordinator.Key, c.Coordinator.Address},
+	}
+	if c.MixPool == nil {
+		c2.MixChains = toKeyAddrChains(c.fixedChains())
+	} else {
+		c2.MixPool = &mixPoolV2{
+			Mixers:      toKeyAddrs(c.MixPool.Mixers),
+			Seed:        c.MixPool.Seed,
+			NumChains:   c.MixPool.NumChains,
+			ChainLength: c.MixPool.ChainLength,
+		}
+	}
+	if c.Schedule != nil {
+		c2.Schedule = &scheduleV2{
+			Genesis: c.Schedule.Genesis,
+			Period:  c.Schedule.Period,
+		}
+	}
+	return c2, nil
+}
+
+func (c *ConvoConfig) fromV2(c2 *convoV2) error {
+	c.Version = 2
+	c.Coordinator = CoordinatorConfig{c2.Coordinator.Key, c2.Coordinator.Address}
+	if c2.MixPool == nil {
+		c.setFixedChains(c2.MixChains)
+		c.MixPool = nil
+	} else {
+		if len(c2.MixChains) > 0 {
+			return errors.New("config has both MixChains and MixPool")
+		}
+		c.MixServers = nil
+		c.MixChains = nil
+		c.MixPool = &mixnet.MixPool{
+			Mixers:      fromKeyAddrs(c2.MixPool.Mixers),
+			Seed:        c2.MixPool.Seed,
+			NumChains:   c2.MixPool.NumChains,
+			ChainLength: c2.MixPool.ChainLength,
+		}
+	}
+	c.Schedule = nil
+	if c2.Schedule != nil {
+		c.Schedule = &Schedule{
+			Genesis: c2.Schedule.Genesis,
+			Period:  c2.Schedule.Period,
+		}
+	}
+	return nil
+}
+
+func (c *ConvoConfig) setFixedChains(keyAddrChains [][]keyAddr) {
+	chains := make([][]mixnet.PublicServerConfig, len(keyAddrChains))
+	for i, chain := range keyAddrChains {
+		chains[i] = fromKeyAddrs(chain)
+	}
+	// A single chain is kept in MixServers so that the config
+	// looks the same as its version 1 equivalent.
+	if len(chains) == 1 {
+		c.MixServers = chains[0]
+		c.MixChains = nil
+	} else {
+		c.MixServers = nil
+		c.MixChains = chains
+	}
+}
+
+func toKeyAddrs(servers []mixnet.PublicServerConfig) []keyAddr {
+	kas := make([]keyAddr, len(servers))
+	for i, srv := range servers {
+		kas[i] = keyAddr{srv.Key, srv.Address}
+	}
+	return kas
+}
+
+func toKeyAddrChains(chains [][]mixnet.PublicServerConfig) [][]keyAddr {
+	kas := make([][]keyAddr, len(chains))
+	for i, chain := range chains {
+		kas[i] = toKeyAddrs(chain)
+	}
+	return kas
+}
+
+func fromKeyAddrs(kas []keyAddr) []mixnet.PublicServerConfig {
+	servers := make([]mixnet.PublicServerConfig, len(kas))
+	for i, ka := range kas {
+		servers[i] = mixnet.PublicServerConfig{Key: ka.Key, Address: ka.Address}
+	}
+	return servers
+}
+
 func (c *ConvoConfig) MarshalJSON() ([]byte, error) {
 	switch c.Version {
 	case 1:
@@ -82,6 +246,12 @@ func (c *ConvoConfig) MarshalJSON() ([]byte, error) {
 			return nil, err
 		}
 		return json.Marshal(c1)
+	case 2:
+		c2, err := c.v2()
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(c2)
 	default:
 		return nil, errors.New("unknown ConvoConfig version: %d", c.Version)
 	}
@@ -100,6 +270,13 @@ func (c *ConvoConfig) UnmarshalJSON(data []byte) error {
 			return err
 		}
 		return c.fromV1(c1)
+	case 2:
+		c2 := new(convoV2)
+		err := json.Unmarshal(data, c2)
+		if err != nil {
+			return err
+		}
+		return c.fromV2(c2)
 	default:
 		return errors.New("unknown ConvoConfig version: %d", c.Version)
 	}
@@ -113,16 +290,44 @@ func (c *ConvoConfig) Validate() error {
 		return errors.New("empty coordinator address")
 	}
 
-	if len(c.MixServers) == 0 {
-		return errors.New("no mix servers defined for convo protocol")
+	if len(c.MixServers) > 0 && len(c.MixChains) > 0 {
+		return errors.New("both MixServers and MixChains are set")
+	}
+
+	if c.Schedule != nil {
+		if err := c.Schedule.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.MixPool != nil {
+		if len(c.MixServers) > 0 || len(c.MixChains) > 0 {
+			return errors.New("mix pool and fixed mix chains are both set")
+		}
+		if c.Schedule == nil {
+			return errors.New("mix pool needs a schedule")
+		}
+		return c.MixPool.Validate()
 	}
 
-	for i, mix := range c.MixServers {
-		if len(mix.Key) != ed25519.PublicKeySize {
-			return errors.New("invalid key for mixer %d: %s", i, mix.Key)
+	// Mixers keep their state per round, so a mixer can
+	// only be on one chain.
+	seen := make(map[string]bool)
+	for i, chain := range c.fixedChains() {
+		if len(chain) == 0 {
+			return errors.New("no mix servers defined for chain %d of convo protocol", i)
 		}
-		if mix.Address == "" {
-			return errors.New("empty address for mix server %d", i)
+		for j, mix := range chain {
+			if len(mix.Key) != ed25519.PublicKeySize {
+				return errors.New("invalid key for mixer %d of chain %d: %s", j, i, mix.Key)
+			}
+			if mix.Address == "" {
+				return errors.New("empty address for mix server %d of chain %d", j, i)
+			}
+			if seen[string(mix.Key)] {
+				return errors.New("mixer %d of chain %d is on more than one chain", j, i)
+			}
+			seen[string(mix.Key)] = true
 		}
 	}
 
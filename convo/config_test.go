@@ -77,3 +77,162 @@ func TestMarshalConvoConfig(t *testing.T) {
 		t.Fatalf("round-trip failed:\nbefore=%#v\nafter=%#v\n", *conf, *conf2)
 	}
 }
+
+func TestMarshalMultiChainConfig(t *testing.T) {
+	keys := make([]ed25519.PublicKey, 4)
+	for i := range keys {
+		keys[i], _, _ = ed25519.GenerateKey(rand.Reader)
+	}
+
+	conf := &ConvoConfig{
+		Version: ConvoConfigVersion,
+		Coordinator: CoordinatorConfig{
+			Key:     keys[0],
+			Address: "localhost:8080",
+		},
+		MixChains: [][]mixnet.PublicServerConfig{
+			{{Key: keys[0], Address: "localhost:1234"}, {Key: keys[1], Address: "localhost:1235"}},
+			{{Key: keys[2], Address: "localhost:1236"}, {Key: keys[3], Address: "localhost:1237"}},
+		},
+	}
+	if err := conf.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf2 := new(ConvoConfig)
+	if err := json.Unmarshal(data, conf2); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(conf, conf2) {
+		t.Fatalf("round-trip failed:\nbefore=%#v\nafter=%#v\n", *conf, *conf2)
+	}
+
+	conf.Version = 1
+	if _, err := json.Marshal(conf); err == nil {
+		t.Fatalf("expected error marshaling multiple chains as version 1")
+	}
+
+	conf.MixChains[1][0].Key = keys[1]
+	if err := conf.Validate(); err == nil {
+		t.Fatalf("expected error for mixer on two chains")
+	}
+}
+
+func TestMarshalMixPoolConfig(t *testing.T) {
+	pool := &mixnet.MixPool{
+		Mixers:      make([]mixnet.PublicServerConfig, 5),
+		Seed:        make([]byte, 32),
+		NumChains:   1,
+		ChainLength: 3,
+	}
+	rand.Read(pool.Seed)
+	for i := range pool.Mixers {
+		key, _, _ := ed25519.GenerateKey(rand.Reader)
+		pool.Mixers[i] = mixnet.PublicServerConfig{Key: key, Address: "localhost:1234"}
+	}
+	conf := &ConvoConfig{
+		Version: ConvoConfigVersion,
+		Coordinator: CoordinatorConfig{
+			Key:     pool.Mixers[0].Key,
+			Address: "localhost:8080",
+		},
+		MixPool: pool,
+	}
+	if err := conf.Validate(); err == nil {
+		t.Fatalf("expected error for mix pool without a schedule")
+	}
+	conf.Schedule = &Schedule{
+		Genesis: time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC),
+		Period:  10 * time.Second,
+	}
+	if err := conf.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf2 := new(ConvoConfig)
+	if err := json.Unmarshal(data, conf2); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(conf, conf2) {
+		t.Fatalf("round-trip failed:\nbefore=%#v\nafter=%#v\n", *conf, *conf2)
+	}
+	if !reflect.DeepEqual(conf.Chains(7), conf2.Chains(7)) {
+		t.Fatalf("configs pick different chains")
+	}
+
+	conf.Version = 1
+	if _, err := json.Marshal(conf); err == nil {
+		t.Fatalf("expected error marshaling mix pool as version 1")
+	}
+}
+
+func TestMarshalScheduleConfig(t *testing.T) {
+	key, _, _ := ed25519.GenerateKey(rand.Reader)
+	conf := &ConvoConfig{
+		Version: ConvoConfigVersion,
+		Coordinator: CoordinatorConfig{
+			Key:     key,
+			Address: "localhost:8080",
+		},
+		MixServers: []mixnet.PublicServerConfig{
+			{Key: key, Address: "localhost:1234"},
+		},
+		Schedule: &Schedule{
+			Genesis: time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC),
+			Period:  10 * time.Second,
+		},
+	}
+	if err := conf.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf2 := new(ConvoConfig)
+	if err := json.Unmarshal(data, conf2); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(conf, conf2) {
+		t.Fatalf("round-trip failed:\nbefore=%#v\nafter=%#v\n", *conf, *conf2)
+	}
+
+	conf.Version = 1
+	if _, err := json.Marshal(conf); err == nil {
+		t.Fatalf("expected error marshaling schedule as version 1")
+	}
+
+	conf.Version = ConvoConfigVersion
+	conf.Schedule.Period = 0
+	if err := conf.Validate(); err == nil {
+		t.Fatalf("expected error for zero period")
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	s := &Schedule{
+		Genesis: time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC),
+		Period:  10 * time.Second,
+	}
+	for _, round := range []uint32{0, 1, 2, 1000, 123456} {
+		deadline := s.Deadline(round)
+		if r := s.Round(deadline.Add(-1)); r != round {
+			t.Fatalf("Round(deadline(%d) - 1ns) = %d", round, r)
+		}
+		if r := s.Round(deadline); r != round+1 {
+			t.Fatalf("Round(deadline(%d)) = %d", round, r)
+		}
+	}
+	if r := s.Round(s.Genesis.Add(-time.Hour)); r != 0 {
+		t.Fatalf("Round before genesis = %d", r)
+	}
+}
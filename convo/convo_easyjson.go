@@ -7,6 +7,7 @@ import (
 	easyjson "github.com/davidlazar/easyjson"
 	jlexer "github.com/davidlazar/easyjson/jlexer"
 	jwriter "github.com/davidlazar/easyjson/jwriter"
+	time "time"
 )
 
 // suppress unused package warning
@@ -212,6 +213,398 @@ func (v *convoV1) UnmarshalJSON(data []byte) error {
 func (v *convoV1) UnmarshalEasyJSON(l *jlexer.Lexer) {
 	easyjsonDecodeConvoV11b32f41b(l, v)
 }
+func easyjsonDecodeConvoV21b32f41b(in *jlexer.Lexer, out *convoV2) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "Version":
+			out.Version = int(in.Int())
+		case "Coordinator":
+			(out.Coordinator).UnmarshalEasyJSON(in)
+		case "MixChains":
+			if in.IsNull() {
+				in.Skip()
+				out.MixChains = nil
+			} else {
+				in.Delim('[')
+				if out.MixChains == nil {
+					if !in.IsDelim(']') {
+						out.MixChains = make([][]keyAddr, 0, 2)
+					} else {
+						out.MixChains = [][]keyAddr{}
+					}
+				} else {
+					out.MixChains = (out.MixChains)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v13 []keyAddr
+					if in.IsNull() {
+						in.Skip()
+						v13 = nil
+					} else {
+						in.Delim('[')
+						if v13 == nil {
+							if !in.IsDelim(']') {
+								v13 = make([]keyAddr, 0, 1)
+							} else {
+								v13 = []keyAddr{}
+							}
+						} else {
+							v13 = (v13)[:0]
+						}
+						for !in.IsDelim(']') {
+							var v14 keyAddr
+							(v14).UnmarshalEasyJSON(in)
+							v13 = append(v13, v14)
+							in.WantComma()
+						}
+						in.Delim(']')
+					}
+					out.MixChains = append(out.MixChains, v13)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "MixPool":
+			if in.IsNull() {
+				in.Skip()
+				out.MixPool = nil
+			} else {
+				if out.MixPool == nil {
+					out.MixPool = new(mixPoolV2)
+				}
+				(*out.MixPool).UnmarshalEasyJSON(in)
+			}
+		case "Schedule":
+			if in.IsNull() {
+				in.Skip()
+				out.Schedule = nil
+			} else {
+				if out.Schedule == nil {
+					out.Schedule = new(scheduleV2)
+				}
+				(*out.Schedule).UnmarshalEasyJSON(in)
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonEncodeConvoV21b32f41b(out *jwriter.Writer, in convoV2) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	if !first {
+		out.RawByte(',')
+	}
+	first = false
+	out.RawString("\"Version\":")
+	out.Int(int(in.Version))
+	if !first {
+		out.RawByte(',')
+	}
+	first = false
+	out.RawString("\"Coordinator\":")
+	(in.Coordinator).MarshalEasyJSON(out)
+	if !first {
+		out.RawByte(',')
+	}
+	first = false
+	out.RawString("\"MixChains\":")
+	if in.MixChains == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+		out.RawString("null")
+	} else {
+		out.RawByte('[')
+		for v15, v16 := range in.MixChains {
+			if v15 > 0 {
+				out.RawByte(',')
+			}
+			if v16 == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+				out.RawString("null")
+			} else {
+				out.RawByte('[')
+				for v17, v18 := range v16 {
+					if v17 > 0 {
+						out.RawByte(',')
+					}
+					(v18).MarshalEasyJSON(out)
+				}
+				out.RawByte(']')
+			}
+		}
+		out.RawByte(']')
+	}
+	if !first {
+		out.RawByte(',')
+	}
+	first = false
+	out.RawString("\"MixPool\":")
+	if in.MixPool == nil {
+		out.RawString("null")
+	} else {
+		(*in.MixPool).MarshalEasyJSON(out)
+	}
+	if !first {
+		out.RawByte(',')
+	}
+	first = false
+	out.RawString("\"Schedule\":")
+	if in.Schedule == nil {
+		out.RawString("null")
+	} else {
+		(*in.Schedule).MarshalEasyJSON(out)
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v convoV2) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonEncodeConvoV21b32f41b(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v convoV2) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonEncodeConvoV21b32f41b(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *convoV2) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonDecodeConvoV21b32f41b(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *convoV2) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonDecodeConvoV21b32f41b(l, v)
+}
+func easyjsonDecodeScheduleV21b32f41b(in *jlexer.Lexer, out *scheduleV2) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "Genesis":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.Genesis).UnmarshalJSON(data))
+			}
+		case "Period":
+			out.Period = time.Duration(in.Int64())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonEncodeScheduleV21b32f41b(out *jwriter.Writer, in scheduleV2) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	if !first {
+		out.RawByte(',')
+	}
+	first = false
+	out.RawString("\"Genesis\":")
+	out.Raw((in.Genesis).MarshalJSON())
+	if !first {
+		out.RawByte(',')
+	}
+	first = false
+	out.RawString("\"Period\":")
+	out.Int64(int64(in.Period))
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v scheduleV2) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonEncodeScheduleV21b32f41b(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v scheduleV2) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonEncodeScheduleV21b32f41b(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *scheduleV2) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonDecodeScheduleV21b32f41b(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *scheduleV2) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonDecodeScheduleV21b32f41b(l, v)
+}
+func easyjsonDecodeMixPoolV21b32f41b(in *jlexer.Lexer, out *mixPoolV2) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "Mixers":
+			if in.IsNull() {
+				in.Skip()
+				out.Mixers = nil
+			} else {
+				in.Delim('[')
+				if out.Mixers == nil {
+					if !in.IsDelim(']') {
+						out.Mixers = make([]keyAddr, 0, 1)
+					} else {
+						out.Mixers = []keyAddr{}
+					}
+				} else {
+					out.Mixers = (out.Mixers)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v19 keyAddr
+					(v19).UnmarshalEasyJSON(in)
+					out.Mixers = append(out.Mixers, v19)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "Seed":
+			if in.IsNull() {
+				in.Skip()
+				out.Seed = nil
+			} else {
+				out.Seed = in.BytesReadable()
+			}
+		case "NumChains":
+			out.NumChains = int(in.Int())
+		case "ChainLength":
+			out.ChainLength = int(in.Int())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonEncodeMixPoolV21b32f41b(out *jwriter.Writer, in mixPoolV2) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	if !first {
+		out.RawByte(',')
+	}
+	first = false
+	out.RawString("\"Mixers\":")
+	if in.Mixers == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+		out.RawString("null")
+	} else {
+		out.RawByte('[')
+		for v20, v21 := range in.Mixers {
+			if v20 > 0 {
+				out.RawByte(',')
+			}
+			(v21).MarshalEasyJSON(out)
+		}
+		out.RawByte(']')
+	}
+	if !first {
+		out.RawByte(',')
+	}
+	first = false
+	out.RawString("\"Seed\":")
+	out.Base32Bytes(in.Seed)
+	if !first {
+		out.RawByte(',')
+	}
+	first = false
+	out.RawString("\"NumChains\":")
+	out.Int(int(in.NumChains))
+	if !first {
+		out.RawByte(',')
+	}
+	first = false
+	out.RawString("\"ChainLength\":")
+	out.Int(int(in.ChainLength))
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v mixPoolV2) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonEncodeMixPoolV21b32f41b(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v mixPoolV2) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonEncodeMixPoolV21b32f41b(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *mixPoolV2) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonDecodeMixPoolV21b32f41b(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *mixPoolV2) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonDecodeMixPoolV21b32f41b(l, v)
+}
 func easyjsonDecodeCoordinatorConfig1b32f41b(in *jlexer.Lexer, out *CoordinatorConfig) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
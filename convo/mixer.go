@@ -5,6 +5,7 @@
 package convo
 
 import (
+	"encoding/binary"
 	"fmt"
 	"unsafe"
 
@@ -23,6 +24,17 @@ const (
 
 type DeadDrop [16]byte
 
+// Chain returns the index of the mix chain that carries messages for
+// the dead drop when the config has numChains chains. Both sides of a
+// conversation derive the same dead drop, so their messages are mixed
+// by the same chain and meet at its last server.
+func (d DeadDrop) Chain(numChains int) int {
+	if numChains <= 1 {
+		return 0
+	}
+	return int(binary.BigEndian.Uint64(d[:8]) % uint64(numChains))
+}
+
 type DeadDropMessage struct {
 	DeadDrop         DeadDrop
 	EncryptedMessage [SizeEncryptedMessageBody]byte
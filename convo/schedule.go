@@ -0,0 +1,43 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package convo
+
+import (
+	"time"
+
+	"vuvuzela.io/alpenhorn/errors"
+)
+
+// A Schedule fixes round deadlines to the wall clock: the deadline of
+// round N is Genesis + N*Period. Since the schedule is in the signed
+// config, clients can compute it themselves, find the rounds they
+// missed, and notice when the coordinator strays from it.
+type Schedule struct {
+	Genesis time.Time
+	Period  time.Duration
+}
+
+// Deadline returns the deadline of the round.
+func (s *Schedule) Deadline(round uint32) time.Time {
+	return s.Genesis.Add(time.Duration(round) * s.Period)
+}
+
+// Round returns the first round with a deadline after t.
+func (s *Schedule) Round(t time.Time) uint32 {
+	if t.Before(s.Genesis) {
+		return 0
+	}
+	return uint32(t.Sub(s.Genesis)/s.Period) + 1
+}
+
+func (s *Schedule) Validate() error {
+	if s.Genesis.IsZero() {
+		return errors.New("schedule has no genesis time")
+	}
+	if s.Period <= 0 {
+		return errors.New("invalid schedule period: %s", s.Period)
+	}
+	return nil
+}
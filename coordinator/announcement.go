@@ -0,0 +1,138 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package coordinator
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ed25519"
+
+	"vuvuzela.io/alpenhorn/config"
+	"vuvuzela.io/alpenhorn/errors"
+)
+
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityCritical
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityCritical:
+		return "critical"
+	default:
+		return "unknown"
+	}
+}
+
+// GlobalAnnouncement is a message from a guardian to every client.
+// Guardians sign their announcements, so clients do not have to trust
+// the coordinator that relays them.
+type GlobalAnnouncement struct {
+	Message  string
+	Severity Severity
+
+	// The announcement is shown from Created until Expires.
+	Created time.Time
+	Expires time.Time
+
+	// Guardian is the key of the guardian who signed the announcement.
+	Guardian ed25519.PublicKey
+
+	// Signature is the guardian's signature of the signing message;
+	// it is not included in the signing message.
+	Signature []byte
+}
+
+// maxAnnouncementSkew is how far in the future an announcement's
+// Created time can be, to allow for clock differences.
+const maxAnnouncementSkew = 5 * time.Minute
+
+func (a GlobalAnnouncement) SigningMessage() []byte {
+	a.Signature = nil
+	buf := new(bytes.Buffer)
+	buf.WriteString("GlobalAnnouncement")
+	json.NewEncoder(buf).Encode(a)
+	return buf.Bytes()
+}
+
+// Sign sets the announcement's guardian and signs it with key.
+func (a *GlobalAnnouncement) Sign(key ed25519.PrivateKey) {
+	a.Guardian = key.Public().(ed25519.PublicKey)
+	a.Signature = ed25519.Sign(key, a.SigningMessage())
+}
+
+// Verify checks that one of the guardians signed the announcement and
+// that it is current at time now.
+func (a *GlobalAnnouncement) Verify(guardians []config.Guardian, now time.Time) error {
+	var guardian *config.Guardian
+	for i := range guardians {
+		if bytes.Equal(guardians[i].Key, a.Guardian) {
+			guardian = &guardians[i]
+			break
+		}
+	}
+	if guardian == nil {
+		return errors.New("announcement is not from a guardian")
+	}
+	if len(a.Guardian) != ed25519.PublicKeySize || !ed25519.Verify(a.Guardian, a.SigningMessage(), a.Signature) {
+		return errors.New("invalid signature from guardian %s", guardian.Username)
+	}
+	if a.Severity < SeverityInfo || a.Severity > SeverityCritical {
+		return errors.New("unknown severity: %d", a.Severity)
+	}
+	if a.Created.After(now.Add(maxAnnouncementSkew)) {
+		return errors.New("announcement is from the future: %s", a.Created)
+	}
+	if !now.Before(a.Expires) {
+		return errors.New("announcement expired at %s", a.Expires)
+	}
+	return nil
+}
+
+// maxAnnouncements is the number of announcements that the coordinator
+// keeps for clients that connect after they were sent.
+const maxAnnouncements = 16
+
+// announcementStore keeps the latest announcements until they expire.
+type announcementStore struct {
+	mu            sync.Mutex
+	announcements []*GlobalAnnouncement
+}
+
+// add stores a, dropping the oldest announcement if the store is full.
+func (s *announcementStore) add(a *GlobalAnnouncement) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.announcements = append(s.announcements, a)
+	if len(s.announcements) > maxAnnouncements {
+		s.announcements = s.announcements[len(s.announcements)-maxAnnouncements:]
+	}
+}
+
+// active returns the announcements that have not expired at time now,
+// oldest first, and forgets the rest.
+func (s *announcementStore) active(now time.Time) []*GlobalAnnouncement {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	active := s.announcements[:0]
+	for _, a := range s.announcements {
+		if now.Before(a.Expires) {
+			active = append(active, a)
+		}
+	}
+	s.announcements = active
+	return append([]*GlobalAnnouncement(nil), active...)
+}
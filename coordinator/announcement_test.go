@@ -0,0 +1,89 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package coordinator
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ed25519"
+
+	"vuvuzela.io/alpenhorn/config"
+)
+
+func TestAnnouncement(t *testing.T) {
+	guardianPub, guardianPriv, _ := ed25519.GenerateKey(rand.Reader)
+	_, otherPriv, _ := ed25519.GenerateKey(rand.Reader)
+	guardians := []config.Guardian{{Username: "alice", Key: guardianPub}}
+
+	now := time.Now()
+	a := &GlobalAnnouncement{
+		Message:  "Please upgrade your client",
+		Severity: SeverityWarning,
+		Created:  now,
+		Expires:  now.Add(time.Hour),
+	}
+	a.Sign(guardianPriv)
+
+	// Clients get the announcement as JSON.
+	data, err := json.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a2 := new(GlobalAnnouncement)
+	if err := json.Unmarshal(data, a2); err != nil {
+		t.Fatal(err)
+	}
+	if err := a2.Verify(guardians, now); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a2.Verify(guardians, now.Add(2*time.Hour)); err == nil {
+		t.Fatal("expected error for expired announcement")
+	}
+	if err := a2.Verify(guardians, now.Add(-time.Hour)); err == nil {
+		t.Fatal("expected error for announcement from the future")
+	}
+
+	a2.Severity = SeverityCritical
+	if err := a2.Verify(guardians, now); err == nil {
+		t.Fatal("expected error for modified announcement")
+	}
+
+	a.Sign(otherPriv)
+	if err := a.Verify(guardians, now); err == nil {
+		t.Fatal("expected error for announcement from someone else")
+	}
+}
+
+func TestAnnouncementStore(t *testing.T) {
+	now := time.Now()
+	var s announcementStore
+	for i := 0; i < maxAnnouncements+5; i++ {
+		s.add(&GlobalAnnouncement{
+			Message: fmt.Sprintf("announcement %d", i),
+			Expires: now.Add(time.Duration(i) * time.Minute),
+		})
+	}
+
+	active := s.active(now)
+	if len(active) != maxAnnouncements {
+		t.Fatalf("expected %d announcements, got %d", maxAnnouncements, len(active))
+	}
+	if active[0].Message != "announcement 5" {
+		t.Fatalf("expected the oldest announcements to be dropped, got %q first", active[0].Message)
+	}
+
+	active = s.active(now.Add(10 * time.Minute))
+	if len(active) != maxAnnouncements+5-11 {
+		t.Fatalf("expected %d unexpired announcements, got %d", maxAnnouncements+5-11, len(active))
+	}
+	if active[0].Message != "announcement 11" {
+		t.Fatalf("got %q first, want announcement 11", active[0].Message)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package coordinator
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"vuvuzela.io/alpenhorn/log"
+	"vuvuzela.io/internal/ioutil2"
+	"vuvuzela.io/vuvuzela/mixnet"
+)
+
+// auditRound challenges the mixers on chain c to reveal a random half
+// of their links for the round and checks the result. The onions are
+// the batch that the coordinator sent to the chain's first mixer.
+func (srv *Server) auditRound(c int, chain []mixnet.PublicServerConfig, round uint32, onions [][]byte) {
+	logger := log.WithFields(log.Fields{"round": round, "chain": c})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	evidence, err := srv.mixnetClient.Audit(ctx, chain, srv.Service, round)
+	if err != nil {
+		logger.WithFields(log.Fields{"call": "mixnet.Audit"}).Error(err)
+		roundErrors.WithLabelValues(srv.Service, "mixnet.Audit").Inc()
+		return
+	}
+	evidence.InputRoot = mixnet.MerkleRoot(onions)
+
+	results, err := evidence.Verify()
+	if err != nil {
+		logger.Errorf("Invalid shuffle audit evidence: %s", err)
+	}
+	for _, result := range results {
+		mixLogger := logger.WithFields(log.Fields{
+			"pos":        result.Position,
+			"revealed":   result.Revealed,
+			"noise":      result.Noise,
+			"invalid":    result.Invalid,
+			"duplicates": result.Duplicates,
+			"replays":    result.Replays,
+		})
+		if result.OK() {
+			mixLogger.Info("Mixer passed shuffle audit")
+		} else {
+			mixLogger.Errorf("Mixer failed shuffle audit: %s", result.Error)
+			failedAudits.WithLabelValues(srv.Service, strconv.Itoa(c), strconv.Itoa(result.Position)).Inc()
+		}
+	}
+
+	if srv.AuditDir == "" {
+		return
+	}
+	data, err := json.Marshal(evidence)
+	if err != nil {
+		logger.Errorf("Error encoding shuffle audit evidence: %s", err)
+		return
+	}
+	path := filepath.Join(srv.AuditDir, fmt.Sprintf("%s-%d-%d.json", strings.ToLower(srv.Service), round, c))
+	if err := ioutil2.WriteFileAtomic(path, data, 0600); err != nil {
+		logger.Errorf("Error saving shuffle audit evidence: %s", err)
+	}
+}
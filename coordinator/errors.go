@@ -0,0 +1,114 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package coordinator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrorCodesVersion is the version of the error codes in RoundError.
+// Coordinators that predate error codes send version 0.
+const ErrorCodesVersion = 1
+
+// An ErrorCode says what went wrong in a RoundError, so that clients
+// can react to errors without parsing their text.
+type ErrorCode int
+
+const (
+	CodeUnknown ErrorCode = iota
+
+	// CodeRoundNotFound and CodeRoundClosed reject onions for rounds
+	// that are not open. Clients retransmit their messages in a later
+	// round.
+	CodeRoundNotFound
+	CodeRoundClosed
+
+	// CodeRateLimited rejects a bundle that would exceed one of the
+	// coordinator's Limits, which is named in the error's Limit.
+	CodeRateLimited
+
+	// CodeBadChains and CodeBadOnionSize reject malformed bundles.
+	CodeBadChains
+	CodeBadOnionSize
+
+	// CodeBadSolution and CodeSolutionReused reject a bundle without
+	// a valid proof-of-work solution.
+	CodeBadSolution
+	CodeSolutionReused
+
+	// CodeBadToken rejects a bundle without a valid admission token.
+	CodeBadToken
+
+	// CodeServerError is sent to every client when a round fails
+	// on the mixers.
+	CodeServerError
+)
+
+var errorCodeNames = map[ErrorCode]string{
+	CodeUnknown:        "unknown",
+	CodeRoundNotFound:  "round not found",
+	CodeRoundClosed:    "round closed",
+	CodeRateLimited:    "rate limited",
+	CodeBadChains:      "bad chains",
+	CodeBadOnionSize:   "bad onion size",
+	CodeBadSolution:    "bad puzzle solution",
+	CodeSolutionReused: "puzzle solution reused",
+	CodeBadToken:       "bad admission token",
+	CodeServerError:    "server error",
+}
+
+func (c ErrorCode) String() string {
+	if name, ok := errorCodeNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("ErrorCode(%d)", int(c))
+}
+
+type RoundError struct {
+	Round uint32
+	Err   string
+
+	// Version is ErrorCodesVersion, and Code is the kind of error.
+	Version int
+	Code    ErrorCode
+
+	// Limit is set if the bundle was rejected because
+	// it would exceed one of the coordinator's Limits.
+	Limit Limit `json:",omitempty"`
+}
+
+func newRoundError(round uint32, code ErrorCode, format string, args ...interface{}) *RoundError {
+	return &RoundError{
+		Round:   round,
+		Err:     fmt.Sprintf(format, args...),
+		Version: ErrorCodesVersion,
+		Code:    code,
+	}
+}
+
+func (e *RoundError) Error() string {
+	return fmt.Sprintf("round %d: %s", e.Round, e.Err)
+}
+
+// ErrorCode returns the error's code. For errors from coordinators
+// that predate error codes, it guesses the code from the error text.
+func (e *RoundError) ErrorCode() ErrorCode {
+	if e.Version > 0 {
+		return e.Code
+	}
+	switch {
+	case e.Limit != "":
+		return CodeRateLimited
+	case strings.HasPrefix(e.Err, "round not found"):
+		return CodeRoundNotFound
+	case strings.HasPrefix(e.Err, "round is closed:"):
+		return CodeRoundClosed
+	case e.Err == "server error":
+		return CodeServerError
+	default:
+		return CodeUnknown
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package coordinator
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestErrorCodes(t *testing.T) {
+	srv := &Server{
+		rounds: make(map[uint32]*roundState),
+	}
+	conn := new(recordingConn)
+	srv.incomingOnion(conn, onionMsg(7, 1, 100))
+	if len(conn.sent) != 1 || conn.ids[0] != "error" {
+		t.Fatalf("expected an error, got %v", conn.ids)
+	}
+
+	// Clients get the error as JSON.
+	data, err := json.Marshal(conn.sent[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := new(RoundError)
+	if err := json.Unmarshal(data, e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Round != 7 || e.ErrorCode() != CodeRoundNotFound {
+		t.Fatalf("got %q with code %s, want round not found", e, e.ErrorCode())
+	}
+
+	// Errors from older coordinators only have text.
+	legacy := []struct {
+		err  RoundError
+		code ErrorCode
+	}{
+		{RoundError{Err: "round not found"}, CodeRoundNotFound},
+		{RoundError{Err: "round is closed: deadline was 1s ago"}, CodeRoundClosed},
+		{RoundError{Err: "round is full", Limit: LimitRoundMemory}, CodeRateLimited},
+		{RoundError{Err: "server error"}, CodeServerError},
+		{RoundError{Err: "something else"}, CodeUnknown},
+	}
+	for _, l := range legacy {
+		if code := l.err.ErrorCode(); code != l.code {
+			t.Errorf("%q: got code %s, want %s", l.err.Err, code, l.code)
+		}
+	}
+}
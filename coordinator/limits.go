@@ -0,0 +1,102 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package coordinator
+
+import (
+	"vuvuzela.io/alpenhorn/typesocket"
+)
+
+// Limits bounds how much a client can submit in a round, so that one
+// client can not flood a round and exhaust the memory of the
+// coordinator and the mixers. Bundles that would exceed a limit are
+// rejected whole with a RoundError. Zero values use the corresponding
+// DefaultLimits.
+type Limits struct {
+	// ConnOnions is the number of onions a connection can submit per
+	// round. Honest clients send the same number of onions every round.
+	ConnOnions int
+
+	// BundleSize is the size in bytes of the largest OnionMsg.
+	BundleSize int
+
+	// RoundMemory is the number of bytes of onions that the
+	// coordinator accepts per round, from all connections.
+	RoundMemory int64
+}
+
+var DefaultLimits = Limits{
+	// The client sends 5 onions per round.
+	ConnOnions:  5,
+	BundleSize:  64 * 1024,
+	RoundMemory: 512 * 1024 * 1024,
+}
+
+func (srv *Server) limits() Limits {
+	l := srv.Limits
+	if l.ConnOnions <= 0 {
+		l.ConnOnions = DefaultLimits.ConnOnions
+	}
+	if l.BundleSize <= 0 {
+		l.BundleSize = DefaultLimits.BundleSize
+	}
+	if l.RoundMemory <= 0 {
+		l.RoundMemory = DefaultLimits.RoundMemory
+	}
+	return l
+}
+
+// A Limit names one of the Limits in a RoundError.
+type Limit string
+
+const (
+	LimitConnOnions  Limit = "conn_onions"
+	LimitBundleSize  Limit = "bundle_size"
+	LimitRoundMemory Limit = "round_memory"
+)
+
+func limitError(round uint32, limit Limit, format string, args ...interface{}) *RoundError {
+	e := newRoundError(round, CodeRateLimited, format, args...)
+	e.Limit = limit
+	return e
+}
+
+// roundUsage tracks what a round has accepted so far.
+type roundUsage struct {
+	bytes      int64
+	connOnions map[typesocket.Conn]int
+}
+
+func bundleSize(onions [][]byte) int {
+	size := 0
+	for _, onion := range onions {
+		size += len(onion)
+	}
+	return size
+}
+
+// check checks the bundle against the limits. It returns a RoundError
+// if a limit would be hit.
+func (u *roundUsage) check(l Limits, c typesocket.Conn, o OnionMsg) *RoundError {
+	size := bundleSize(o.Onions)
+	if size > l.BundleSize {
+		return limitError(o.Round, LimitBundleSize, "onion bundle is too large: %d bytes (limit %d)", size, l.BundleSize)
+	}
+	if n := u.connOnions[c] + len(o.Onions); n > l.ConnOnions {
+		return limitError(o.Round, LimitConnOnions, "too many onions from connection: %d (limit %d per round)", n, l.ConnOnions)
+	}
+	if u.bytes+int64(size) > l.RoundMemory {
+		return limitError(o.Round, LimitRoundMemory, "round is full: %d bytes of onions (limit %d)", u.bytes, l.RoundMemory)
+	}
+	return nil
+}
+
+// add adds a bundle that passed check to the usage.
+func (u *roundUsage) add(c typesocket.Conn, o OnionMsg) {
+	if u.connOnions == nil {
+		u.connOnions = make(map[typesocket.Conn]int)
+	}
+	u.connOnions[c] += len(o.Onions)
+	u.bytes += int64(bundleSize(o.Onions))
+}
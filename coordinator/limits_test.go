@@ -0,0 +1,166 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package coordinator
+
+import (
+	"testing"
+	"time"
+
+	"vuvuzela.io/alpenhorn/typesocket"
+	"vuvuzela.io/vuvuzela/admission"
+)
+
+// recordingConn is a typesocket.Conn that records the IDs and values
+// of the messages sent to it.
+type recordingConn struct {
+	ids  []string
+	sent []interface{}
+}
+
+func (c *recordingConn) Send(msgID string, v interface{}) error {
+	c.ids = append(c.ids, msgID)
+	c.sent = append(c.sent, v)
+	return nil
+}
+
+func (c *recordingConn) Close() error                   { return nil }
+func (c *recordingConn) Serve(mux typesocket.Mux) error { return nil }
+
+func (c *recordingConn) lastLimit() Limit {
+	if len(c.sent) == 0 {
+		return ""
+	}
+	if e, ok := c.sent[len(c.sent)-1].(*RoundError); ok {
+		return e.Limit
+	}
+	return ""
+}
+
+func onionMsg(round uint32, n int, size int) OnionMsg {
+	o := OnionMsg{Round: round}
+	for i := 0; i < n; i++ {
+		o.Onions = append(o.Onions, make([]byte, size))
+	}
+	return o
+}
+
+func TestLimits(t *testing.T) {
+	st := &roundState{
+		roundInfo: &NewRound{
+			Round:  1,
+			Chains: make([]ChainSettings, 1),
+		},
+		open: true,
+	}
+	srv := &Server{
+		Service: "Convo",
+		Limits: Limits{
+			ConnOnions:  3,
+			BundleSize:  1000,
+			RoundMemory: 1000,
+		},
+		rounds: map[uint32]*roundState{1: st},
+	}
+
+	alice := new(recordingConn)
+	bob := new(recordingConn)
+
+	srv.incomingOnion(alice, onionMsg(1, 2, 100))
+	if len(alice.sent) != 0 {
+		t.Fatalf("unexpected error: %v", alice.sent)
+	}
+	// Alice's second bundle goes over her per-round onion count.
+	srv.incomingOnion(alice, onionMsg(1, 2, 100))
+	if alice.lastLimit() != LimitConnOnions {
+		t.Fatalf("expected %s error, got %v", LimitConnOnions, alice.sent)
+	}
+	srv.incomingOnion(bob, onionMsg(1, 1, 1001))
+	if bob.lastLimit() != LimitBundleSize {
+		t.Fatalf("expected %s error, got %v", LimitBundleSize, bob.sent)
+	}
+	srv.incomingOnion(bob, onionMsg(1, 3, 200))
+	if len(bob.sent) != 1 {
+		t.Fatalf("unexpected error: %v", bob.sent)
+	}
+	// The round has 800 bytes of onions.
+	srv.incomingOnion(new(recordingConn), onionMsg(1, 1, 100))
+	carol := new(recordingConn)
+	srv.incomingOnion(carol, onionMsg(1, 1, 200))
+	if carol.lastLimit() != LimitRoundMemory {
+		t.Fatalf("expected %s error, got %v", LimitRoundMemory, carol.sent)
+	}
+
+	if len(st.onions) != 3 {
+		t.Fatalf("expected 3 bundles, got %d", len(st.onions))
+	}
+}
+
+func TestRejectedBundleKeepsToken(t *testing.T) {
+	key, err := admission.GenerateKey(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	epoch := admission.Epoch(time.Now(), time.Hour)
+	req, err := key.PublicKey.Blind(epoch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := key.SignBlinded(epoch, req.Blinded())
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := req.Unblind(sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st := &roundState{
+		roundInfo: &NewRound{
+			Round:  1,
+			Chains: make([]ChainSettings, 1),
+		},
+		open: true,
+	}
+	srv := &Server{
+		Service: "Convo",
+		Limits: Limits{
+			ConnOnions:  1,
+			BundleSize:  1000,
+			RoundMemory: 1000,
+		},
+		Admission: &admission.Verifier{
+			Key:           &key.PublicKey,
+			EpochDuration: time.Hour,
+		},
+		rounds: map[uint32]*roundState{1: st},
+	}
+
+	// The bundle goes over the onion limit, so it is rejected before
+	// the token is spent.
+	alice := new(recordingConn)
+	o := onionMsg(1, 2, 100)
+	o.Token = token
+	srv.incomingOnion(alice, o)
+	if alice.lastLimit() != LimitConnOnions {
+		t.Fatalf("expected %s error, got %v", LimitConnOnions, alice.sent)
+	}
+
+	o = onionMsg(1, 1, 100)
+	o.Token = token
+	srv.incomingOnion(alice, o)
+	if len(alice.sent) != 1 {
+		t.Fatalf("unexpected error: %v", alice.sent[1:])
+	}
+	if len(st.onions) != 1 {
+		t.Fatalf("expected 1 bundle, got %d", len(st.onions))
+	}
+
+	// The token is spent now.
+	bob := new(recordingConn)
+	srv.incomingOnion(bob, o)
+	if len(bob.sent) != 1 || bob.sent[0].(*RoundError).Code != CodeBadToken {
+		t.Fatalf("expected %s error, got %v", CodeBadToken, bob.sent)
+	}
+}
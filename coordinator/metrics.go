@@ -0,0 +1,116 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package coordinator
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Prometheus metrics for the coordinator. The mixnet package reports
+// the time and bytes spent talking to the first mixer.
+var (
+	currentRound = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "vuvuzela",
+		Subsystem: "coordinator",
+		Name:      "round",
+		Help:      "The most recently started round.",
+	}, []string{"service"})
+
+	roundOnions = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "vuvuzela",
+		Subsystem: "coordinator",
+		Name:      "round_onions",
+		Help:      "Number of onions sent to each mix chain per round, including traps.",
+		Buckets:   prometheus.ExponentialBuckets(16, 4, 10),
+	}, []string{"service"})
+
+	mixSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "vuvuzela",
+		Subsystem: "coordinator",
+		Name:      "mix_seconds",
+		Help:      "Time from sending a round's onions to the mixnet until the replies came back.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12),
+	}, []string{"service"})
+
+	roundErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "vuvuzela",
+		Subsystem: "coordinator",
+		Name:      "round_errors_total",
+		Help:      "Number of rounds that failed, by the call that failed.",
+	}, []string{"service", "call"})
+
+	missingTraps = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "vuvuzela",
+		Subsystem: "coordinator",
+		Name:      "missing_traps_total",
+		Help:      "Number of trap messages that did not come back intact.",
+	}, []string{"service"})
+
+	failedAudits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "vuvuzela",
+		Subsystem: "coordinator",
+		Name:      "failed_audits_total",
+		Help:      "Number of shuffle audits failed by a mixer, by chain and position.",
+	}, []string{"service", "chain", "position"})
+
+	limitedBundles = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "vuvuzela",
+		Subsystem: "coordinator",
+		Name:      "limited_bundles_total",
+		Help:      "Number of onion bundles rejected for exceeding a limit, by limit.",
+	}, []string{"service", "limit"})
+
+	rejectedTokens = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "vuvuzela",
+		Subsystem: "coordinator",
+		Name:      "rejected_tokens_total",
+		Help:      "Number of onion bundles rejected for a missing, invalid, or spent admission token.",
+	}, []string{"service"})
+
+	rejectedSolutions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "vuvuzela",
+		Subsystem: "coordinator",
+		Name:      "rejected_solutions_total",
+		Help:      "Number of onion bundles rejected for an invalid or reused puzzle solution.",
+	}, []string{"service"})
+
+	powDifficulty = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "vuvuzela",
+		Subsystem: "coordinator",
+		Name:      "pow_difficulty",
+		Help:      "Puzzle difficulty in bits of the most recently started round.",
+	}, []string{"service"})
+
+	scheduleDelay = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "vuvuzela",
+		Subsystem: "coordinator",
+		Name:      "round_delay_seconds",
+		Help:      "Time between the deadlines of consecutive rounds in the current schedule.",
+	}, []string{"service"})
+
+	scheduleInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "vuvuzela",
+		Subsystem: "coordinator",
+		Name:      "rounds_in_flight_limit",
+		Help:      "Number of rounds that can be open or mixing at once in the current schedule.",
+	}, []string{"service"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		currentRound,
+		roundOnions,
+		mixSeconds,
+		roundErrors,
+		missingTraps,
+		failedAudits,
+		limitedBundles,
+		rejectedTokens,
+		rejectedSolutions,
+		powDifficulty,
+		scheduleDelay,
+		scheduleInFlight,
+	)
+}
@@ -9,16 +9,67 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"sort"
 	"sync/atomic"
+	"time"
 
+	"golang.org/x/net/context"
+
+	"vuvuzela.io/alpenhorn/errors"
+	"vuvuzela.io/alpenhorn/log"
 	"vuvuzela.io/internal/ioutil2"
+	"vuvuzela.io/vuvuzela/mixnet"
 )
 
 // version is the current version number of the persisted state format.
-const version byte = 1
+const version byte = 2
 
+// persistedState is the coordinator state that survives a restart.
 type persistedState struct {
+	// Round is the highest round number the coordinator has used.
+	// A round is persisted before it starts on the mixers, so round
+	// numbers never repeat after a restart.
 	Round uint32
+
+	// LastAnnounced is the last round announced to clients.
+	LastAnnounced uint32
+
+	// InFlight has the rounds that were started on the mixers
+	// but have not finished.
+	InFlight []*inFlightRound
+}
+
+// inFlightRound is the metadata the coordinator needs to clean up a
+// round on the mixers if it crashes during the round.
+type inFlightRound struct {
+	Round      uint32
+	ConfigHash string
+	Chains     [][]mixnet.PublicServerConfig
+
+	// EndTime is the round's deadline; it is zero if the round
+	// was not announced.
+	EndTime time.Time
+}
+
+// migrations[v] converts the persisted state from version v to
+// version v+1.
+var migrations = map[byte]func(data []byte) ([]byte, error){
+	1: migrateV1,
+}
+
+// migrateV1 converts version 1 state, which only has the round number
+// and was saved every 20 rounds. The version 1 coordinator skipped 100
+// rounds on startup to avoid reusing round numbers, so we do the same.
+func migrateV1(data []byte) ([]byte, error) {
+	var v1 struct {
+		Round uint32
+	}
+	if err := json.Unmarshal(data, &v1); err != nil {
+		return nil, err
+	}
+	return json.Marshal(&persistedState{
+		Round: v1.Round + 100,
+	})
 }
 
 func (srv *Server) LoadPersistedState() error {
@@ -30,30 +81,62 @@ func (srv *Server) LoadPersistedState() error {
 		return fmt.Errorf("no data: %s", srv.PersistPath)
 	}
 
-	// For evaluation purposes, we ignore the version number.
-	/*
-		ver := data[0]
-		if ver != version {
-			return fmt.Errorf("unexpected version: want version %d, got %d", version, ver)
+	ver := data[0]
+	data = data[1:]
+	if ver > version || ver == 0 {
+		return errors.New("unexpected version: want version %d, got %d", version, ver)
+	}
+	for ; ver < version; ver++ {
+		data, err = migrations[ver](data)
+		if err != nil {
+			return errors.Wrap(err, "migrating state from version %d", ver)
 		}
-	*/
+	}
 
 	var st persistedState
-	err = json.Unmarshal(data[1:], &st)
+	err = json.Unmarshal(data, &st)
 	if err != nil {
 		return err
 	}
 
-	atomic.StoreUint32(&srv.round, st.Round)
+	round := st.Round
+	if st.LastAnnounced > round {
+		round = st.LastAnnounced
+	}
+	atomic.StoreUint32(&srv.round, round)
+
+	srv.mu.Lock()
+	srv.lastAnnounced = st.LastAnnounced
+	srv.orphans = st.InFlight
+	srv.inFlight = make(map[uint32]*inFlightRound)
+	srv.mu.Unlock()
 
 	return nil
 }
 
 func (srv *Server) Persist() error {
-	round := atomic.LoadUint32(&srv.round)
+	// Serialize writes so that an older state never replaces a newer one.
+	srv.persistMu.Lock()
+	defer srv.persistMu.Unlock()
+
+	srv.mu.Lock()
 	st := &persistedState{
-		Round: round,
+		Round:         atomic.LoadUint32(&srv.round),
+		LastAnnounced: srv.lastAnnounced,
+		InFlight:      make([]*inFlightRound, 0, len(srv.inFlight)+len(srv.orphans)),
+	}
+	for _, r := range srv.inFlight {
+		// Copy the round, since announceInFlight can change it.
+		c := *r
+		st.InFlight = append(st.InFlight, &c)
 	}
+	// Keep the orphans until they are deleted from the mixers.
+	st.InFlight = append(st.InFlight, srv.orphans...)
+	srv.mu.Unlock()
+
+	sort.Slice(st.InFlight, func(i, j int) bool {
+		return st.InFlight[i].Round < st.InFlight[j].Round
+	})
 
 	buf := new(bytes.Buffer)
 	buf.WriteByte(version)
@@ -66,3 +149,100 @@ func (srv *Server) Persist() error {
 
 	return ioutil2.WriteFileAtomic(srv.PersistPath, buf.Bytes(), 0600)
 }
+
+// startInFlight records a round before it starts on the mixers.
+func (srv *Server) startInFlight(r *inFlightRound) error {
+	srv.mu.Lock()
+	if srv.inFlight == nil {
+		srv.inFlight = make(map[uint32]*inFlightRound)
+	}
+	srv.inFlight[r.Round] = r
+	srv.mu.Unlock()
+	return srv.Persist()
+}
+
+// announceInFlight records that a round is about to be announced.
+func (srv *Server) announceInFlight(round uint32, endTime time.Time) error {
+	srv.mu.Lock()
+	if r, ok := srv.inFlight[round]; ok {
+		r.EndTime = endTime
+	}
+	if round > srv.lastAnnounced {
+		srv.lastAnnounced = round
+	}
+	srv.mu.Unlock()
+	return srv.Persist()
+}
+
+// finishInFlight forgets a round once it is done on the mixers.
+func (srv *Server) finishInFlight(round uint32) {
+	srv.mu.Lock()
+	delete(srv.inFlight, round)
+	srv.mu.Unlock()
+	if err := srv.Persist(); err != nil {
+		log.WithFields(log.Fields{"round": round}).Errorf("error persisting state: %s", err)
+	}
+}
+
+// deleteOrphans deletes the rounds that were in flight when the
+// coordinator stopped. Otherwise the mixers would keep them until
+// they are reaped.
+func (srv *Server) deleteOrphans() {
+	srv.mu.Lock()
+	orphans := srv.orphans
+	srv.mu.Unlock()
+	if len(orphans) == 0 {
+		return
+	}
+
+	var failed []*inFlightRound
+	for _, r := range orphans {
+		logger := log.WithFields(log.Fields{"round": r.Round})
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		err := srv.deleteOrphan(ctx, r)
+		cancel()
+		if err != nil {
+			logger.Errorf("failed to delete orphaned round: %s", err)
+			failed = append(failed, r)
+			continue
+		}
+		logger.Info("Deleted orphaned round")
+	}
+
+	srv.mu.Lock()
+	// Keep the rounds that runRound orphaned in the meantime.
+	srv.orphans = append(failed, srv.orphans[len(orphans):]...)
+	srv.mu.Unlock()
+	if err := srv.Persist(); err != nil {
+		log.Errorf("error persisting state: %s", err)
+	}
+}
+
+// abandonRound deletes a round that runRound gave up on from the
+// mixers, which would otherwise keep it until it is reaped. If that
+// fails, the round is kept as an orphan.
+func (srv *Server) abandonRound(round uint32, chains [][]mixnet.PublicServerConfig) {
+	r := &inFlightRound{Round: round, Chains: chains}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	err := srv.deleteOrphan(ctx, r)
+	cancel()
+	if err != nil {
+		log.WithFields(log.Fields{"service": srv.Service, "round": round}).Errorf("failed to delete round: %s", err)
+		srv.mu.Lock()
+		srv.orphans = append(srv.orphans, r)
+		srv.mu.Unlock()
+	}
+}
+
+func (srv *Server) deleteOrphan(ctx context.Context, r *inFlightRound) error {
+	var err error
+	for c, chain := range r.Chains {
+		for i, server := range chain {
+			e := srv.mixnetClient.DeleteRound(ctx, server, srv.Service, r.Round)
+			if e != nil && err == nil {
+				err = errors.Wrap(e, "chain %d, mixer %d", c, i)
+			}
+		}
+	}
+	return err
+}
@@ -0,0 +1,87 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package coordinator
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"vuvuzela.io/vuvuzela/mixnet"
+)
+
+func TestPersistInFlight(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vuvuzela_coordinator_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	srv := &Server{
+		Service:     "Convo",
+		PersistPath: filepath.Join(dir, "state"),
+		round:       41,
+	}
+	chains := [][]mixnet.PublicServerConfig{{{Address: "localhost:1"}}}
+	for round := uint32(40); round <= 41; round++ {
+		err := srv.startInFlight(&inFlightRound{
+			Round:  round,
+			Chains: chains,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := srv.announceInFlight(40, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	srv.finishInFlight(40)
+
+	restarted := &Server{
+		PersistPath: srv.PersistPath,
+	}
+	if err := restarted.LoadPersistedState(); err != nil {
+		t.Fatal(err)
+	}
+	if restarted.round != 41 || restarted.lastAnnounced != 40 {
+		t.Fatalf("got round %d, last announced %d", restarted.round, restarted.lastAnnounced)
+	}
+	if len(restarted.orphans) != 1 || restarted.orphans[0].Round != 41 {
+		t.Fatalf("expected round 41 to be orphaned, got %v", restarted.orphans)
+	}
+	if restarted.orphans[0].Chains[0][0].Address != "localhost:1" {
+		t.Fatalf("orphaned round lost its chains: %v", restarted.orphans[0].Chains)
+	}
+}
+
+func TestPersistMigration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vuvuzela_coordinator_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "state")
+	if err := ioutil.WriteFile(path, []byte("\x01{\"Round\": 300}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	srv := &Server{PersistPath: path}
+	if err := srv.LoadPersistedState(); err != nil {
+		t.Fatal(err)
+	}
+	// Version 1 only saved the round every 20 rounds.
+	if srv.round <= 320 {
+		t.Fatalf("round %d could repeat a version 1 round", srv.round)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("\x09{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.LoadPersistedState(); err == nil {
+		t.Fatalf("expected error for unknown version")
+	}
+}
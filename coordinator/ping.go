@@ -0,0 +1,32 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package coordinator
+
+import (
+	"time"
+
+	"vuvuzela.io/alpenhorn/typesocket"
+)
+
+// Ping is sent by clients to measure their latency to the coordinator
+// and the offset of their clock from the coordinator's clock, which
+// sets the round deadlines.
+type Ping struct {
+	ClientTime time.Time
+}
+
+// Pong is the coordinator's reply to a Ping.
+type Pong struct {
+	// ClientTime is copied from the Ping.
+	ClientTime time.Time
+	ServerTime time.Time
+}
+
+func (srv *Server) ping(c typesocket.Conn, p Ping) {
+	c.Send("pong", Pong{
+		ClientTime: p.ClientTime,
+		ServerTime: time.Now(),
+	})
+}
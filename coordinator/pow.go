@@ -0,0 +1,77 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package coordinator
+
+import (
+	"sync"
+)
+
+// PoWSettings configures the proof of work that clients must do for
+// every onion bundle (see admission.Puzzle). The difficulty adjusts to
+// the number of onions the coordinator sees: it goes up by one bit
+// (doubling the work) after a round with more than TargetOnions onions,
+// and down by one bit after a round with less than half of TargetOnions.
+// Zero values use the corresponding DefaultPoWSettings.
+type PoWSettings struct {
+	MinDifficulty int
+	MaxDifficulty int
+	TargetOnions  int
+}
+
+var DefaultPoWSettings = PoWSettings{
+	MinDifficulty: 8,
+	MaxDifficulty: 24,
+	TargetOnions:  100000,
+}
+
+func (s PoWSettings) withDefaults() PoWSettings {
+	if s.MinDifficulty <= 0 {
+		s.MinDifficulty = DefaultPoWSettings.MinDifficulty
+	}
+	if s.MaxDifficulty <= 0 {
+		s.MaxDifficulty = DefaultPoWSettings.MaxDifficulty
+	}
+	if s.MaxDifficulty < s.MinDifficulty {
+		s.MaxDifficulty = s.MinDifficulty
+	}
+	if s.TargetOnions <= 0 {
+		s.TargetOnions = DefaultPoWSettings.TargetOnions
+	}
+	return s
+}
+
+// powController tracks the current difficulty.
+type powController struct {
+	mu         sync.Mutex
+	difficulty int
+}
+
+// next returns the difficulty for the next round.
+func (p *powController) next(s PoWSettings) int {
+	s = s.withDefaults()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.difficulty < s.MinDifficulty {
+		p.difficulty = s.MinDifficulty
+	}
+	if p.difficulty > s.MaxDifficulty {
+		p.difficulty = s.MaxDifficulty
+	}
+	return p.difficulty
+}
+
+// observe adjusts the difficulty after a round with the given number
+// of onions.
+func (p *powController) observe(s PoWSettings, onions int) {
+	s = s.withDefaults()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch {
+	case onions > s.TargetOnions && p.difficulty < s.MaxDifficulty:
+		p.difficulty++
+	case onions < s.TargetOnions/2 && p.difficulty > s.MinDifficulty:
+		p.difficulty--
+	}
+}
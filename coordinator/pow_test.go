@@ -0,0 +1,88 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package coordinator
+
+import (
+	"testing"
+	"time"
+
+	"vuvuzela.io/vuvuzela/admission"
+)
+
+func TestProofOfWork(t *testing.T) {
+	puzzle := admission.NewPuzzle(1, 8)
+	st := &roundState{
+		roundInfo: &NewRound{
+			Round:  1,
+			Chains: make([]ChainSettings, 1),
+			Puzzle: puzzle,
+		},
+		open:      true,
+		solutions: make(map[[32]byte]struct{}),
+	}
+	srv := &Server{
+		Service:     "Convo",
+		ProofOfWork: &PoWSettings{},
+		rounds:      map[uint32]*roundState{1: st},
+	}
+
+	o := onionMsg(1, 2, 100)
+	solution, err := puzzle.Solve(o.Onions, time.Now().Add(10*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.Solution = solution
+
+	alice := new(recordingConn)
+	srv.incomingOnion(alice, o)
+	if len(alice.sent) != 0 {
+		t.Fatalf("unexpected error: %v", alice.sent)
+	}
+	// Bob replays Alice's bundle.
+	bob := new(recordingConn)
+	srv.incomingOnion(bob, o)
+	if len(bob.sent) != 1 {
+		t.Fatalf("expected error for reused solution")
+	}
+	// The solution does not carry over to another bundle.
+	other := onionMsg(1, 2, 101)
+	other.Solution = solution
+	if _, ok := puzzle.Check(other.Onions, solution); !ok {
+		srv.incomingOnion(bob, other)
+		if len(bob.sent) != 2 {
+			t.Fatalf("expected error for invalid solution")
+		}
+	}
+	if len(st.onions) != 1 {
+		t.Fatalf("expected 1 bundle, got %d", len(st.onions))
+	}
+}
+
+func TestPoWDifficulty(t *testing.T) {
+	s := PoWSettings{
+		MinDifficulty: 4,
+		MaxDifficulty: 6,
+		TargetOnions:  100,
+	}
+	p := new(powController)
+	if d := p.next(s); d != 4 {
+		t.Fatalf("expected min difficulty, got %d", d)
+	}
+	for i := 0; i < 5; i++ {
+		p.observe(s, 1000)
+	}
+	if d := p.next(s); d != 6 {
+		t.Fatalf("expected max difficulty, got %d", d)
+	}
+	// Between half the target and the target, the difficulty stays.
+	p.observe(s, 70)
+	if d := p.next(s); d != 6 {
+		t.Fatalf("expected difficulty 6, got %d", d)
+	}
+	p.observe(s, 10)
+	if d := p.next(s); d != 5 {
+		t.Fatalf("expected difficulty 5, got %d", d)
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package coordinator
+
+import (
+	"sync"
+	"time"
+
+	"vuvuzela.io/alpenhorn/errors"
+)
+
+// ScheduleBounds bounds the coordinator's adaptive round schedule.
+//
+// The coordinator starts a round whenever there is room in its in-flight
+// window, and the deadlines of consecutive rounds are a round delay
+// apart. A round stays in the window until it is mixed, so when mixing
+// slows down, the coordinator first widens the window to make room for
+// the rounds being mixed, and then spaces out the deadlines once the
+// window is at its maximum. Zero values use DefaultScheduleBounds.
+type ScheduleBounds struct {
+	// MinRoundDelay is the shortest time between deadlines. If zero,
+	// it is the server's RoundDelay.
+	MinRoundDelay time.Duration
+
+	// MaxRoundDelay is the longest time between deadlines. If zero,
+	// it is 10 times MinRoundDelay. It must be at least MinRoundDelay.
+	MaxRoundDelay time.Duration
+
+	// MinInFlight is the size of the window when mixing is instant.
+	// These rounds are open for onions at the same time, so it sets
+	// how long before its deadline a round is announced.
+	MinInFlight int
+
+	// MaxInFlight is the largest size of the window. It must be
+	// larger than MinInFlight. If zero, it is 15 more than MinInFlight.
+	MaxInFlight int
+}
+
+var DefaultScheduleBounds = ScheduleBounds{
+	MinInFlight: 5,
+	MaxInFlight: 20,
+}
+
+func (b ScheduleBounds) withDefaults(roundDelay time.Duration) (ScheduleBounds, error) {
+	if b.MinRoundDelay == 0 {
+		b.MinRoundDelay = roundDelay
+	}
+	if b.MinRoundDelay <= 0 {
+		return b, errors.New("round delay must be positive: %s", b.MinRoundDelay)
+	}
+	if b.MaxRoundDelay == 0 {
+		b.MaxRoundDelay = 10 * b.MinRoundDelay
+	}
+	if b.MaxRoundDelay < b.MinRoundDelay {
+		return b, errors.New("max round delay %s is less than min round delay %s", b.MaxRoundDelay, b.MinRoundDelay)
+	}
+	if b.MinInFlight == 0 {
+		b.MinInFlight = DefaultScheduleBounds.MinInFlight
+	}
+	if b.MinInFlight < 0 {
+		return b, errors.New("min in-flight rounds must be positive: %d", b.MinInFlight)
+	}
+	if b.MaxInFlight == 0 {
+		b.MaxInFlight = b.MinInFlight + DefaultScheduleBounds.MaxInFlight - DefaultScheduleBounds.MinInFlight
+	}
+	if b.MaxInFlight <= b.MinInFlight {
+		return b, errors.New("max in-flight rounds %d is not more than min in-flight rounds %d", b.MaxInFlight, b.MinInFlight)
+	}
+	return b, nil
+}
+
+// RoundSchedule is the coordinator's schedule at the start of a round.
+// It is announced to clients with every round.
+type RoundSchedule struct {
+	// Delay is the time between the deadlines of consecutive rounds.
+	Delay time.Duration
+
+	// InFlight is the number of rounds that can be open or mixing
+	// at the same time.
+	InFlight int
+
+	// MixDuration is the coordinator's estimate of how long it takes
+	// to mix a round.
+	MixDuration time.Duration
+}
+
+// mixDurationWeight is the weight of the latest round in the estimate
+// of the mixing duration.
+const mixDurationWeight = 0.25
+
+// scheduler adapts the schedule to the measured mixing duration.
+type scheduler struct {
+	bounds ScheduleBounds
+
+	mu          sync.Mutex
+	mixDuration time.Duration
+	schedule    RoundSchedule
+}
+
+func newScheduler(bounds ScheduleBounds, roundDelay time.Duration) (*scheduler, error) {
+	bounds, err := bounds.withDefaults(roundDelay)
+	if err != nil {
+		return nil, err
+	}
+	s := &scheduler{
+		bounds: bounds,
+	}
+	s.update()
+	return s, nil
+}
+
+// current returns the current schedule.
+func (s *scheduler) current() RoundSchedule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.schedule
+}
+
+// wallClock returns the current schedule for rounds that are a fixed
+// period apart. Only the window adapts to the mixing duration.
+func (s *scheduler) wallClock(period time.Duration) RoundSchedule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return RoundSchedule{
+		Delay:       period,
+		InFlight:    s.inFlight(period),
+		MixDuration: s.mixDuration,
+	}
+}
+
+// observe updates the schedule after a round took d to mix.
+func (s *scheduler) observe(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.mixDuration == 0 {
+		s.mixDuration = d
+	} else {
+		s.mixDuration += time.Duration(mixDurationWeight * float64(d-s.mixDuration))
+	}
+	s.update()
+}
+
+// update computes the schedule from the mixing duration. Rounds that
+// are being mixed take up ceil(mixDuration/delay) places in the window,
+// on top of the MinInFlight rounds that are open for onions.
+func (s *scheduler) update() {
+	b := s.bounds
+	delay := b.MinRoundDelay
+	if s.inFlight(delay) >= b.MaxInFlight {
+		delay = s.mixDuration / time.Duration(b.MaxInFlight-b.MinInFlight)
+	}
+	if delay < b.MinRoundDelay {
+		delay = b.MinRoundDelay
+	}
+	if delay > b.MaxRoundDelay {
+		delay = b.MaxRoundDelay
+	}
+
+	s.schedule = RoundSchedule{
+		Delay:       delay,
+		InFlight:    s.inFlight(delay),
+		MixDuration: s.mixDuration,
+	}
+}
+
+// inFlight returns the size of the window for deadlines that are
+// delay apart, which must be positive.
+func (s *scheduler) inFlight(delay time.Duration) int {
+	mixing := int((s.mixDuration + delay - 1) / delay)
+	n := s.bounds.MinInFlight + mixing
+	if n > s.bounds.MaxInFlight {
+		n = s.bounds.MaxInFlight
+	}
+	return n
+}
@@ -0,0 +1,71 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package coordinator
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduler(t *testing.T) {
+	s, err := newScheduler(ScheduleBounds{
+		MinInFlight:   2,
+		MaxInFlight:   6,
+		MaxRoundDelay: 2 * time.Second,
+	}, 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(delay time.Duration, inFlight int) {
+		sched := s.current()
+		if sched.Delay != delay || sched.InFlight != inFlight {
+			t.Fatalf("expected delay %s and window %d, got %s and %d (mixing %s)", delay, inFlight, sched.Delay, sched.InFlight, sched.MixDuration)
+		}
+	}
+	check(100*time.Millisecond, 2)
+
+	// Slow mixing first widens the window.
+	s.observe(250 * time.Millisecond)
+	check(100*time.Millisecond, 5)
+
+	// With a wall-clock schedule, the window depends on the period.
+	if n := s.wallClock(time.Second).InFlight; n != 3 {
+		t.Fatalf("expected window 3 for 1s period, got %d", n)
+	}
+
+	// Then it spaces out the rounds.
+	s.observe(2250 * time.Millisecond)
+	// The estimate is 250ms + (2250ms - 250ms)/4 = 750ms.
+	check(750*time.Millisecond/4, 6)
+
+	for i := 0; i < 50; i++ {
+		s.observe(time.Minute)
+	}
+	check(2*time.Second, 6)
+
+	// Fast mixing brings the schedule back down.
+	for i := 0; i < 100; i++ {
+		s.observe(10 * time.Millisecond)
+	}
+	check(100*time.Millisecond, 3)
+}
+
+func TestSchedulerBounds(t *testing.T) {
+	for _, b := range []struct {
+		bounds     ScheduleBounds
+		roundDelay time.Duration
+	}{
+		{ScheduleBounds{}, 0},
+		{ScheduleBounds{MinRoundDelay: -time.Second}, time.Second},
+		{ScheduleBounds{MinRoundDelay: time.Second, MaxRoundDelay: time.Millisecond}, 0},
+		{ScheduleBounds{MinInFlight: 5, MaxInFlight: 5}, time.Second},
+		{ScheduleBounds{MinInFlight: -1}, time.Second},
+	} {
+		if _, err := newScheduler(b.bounds, b.roundDelay); err == nil {
+			t.Fatalf("expected error for %+v with round delay %s", b.bounds, b.roundDelay)
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -18,27 +19,76 @@ import (
 	"golang.org/x/crypto/ed25519"
 	"golang.org/x/net/context"
 
-	"github.com/numbleroot/vuvuzela/eval"
 	"vuvuzela.io/alpenhorn/config"
 	"vuvuzela.io/alpenhorn/edtls"
 	"vuvuzela.io/alpenhorn/errors"
 	"vuvuzela.io/alpenhorn/log"
 	"vuvuzela.io/alpenhorn/typesocket"
 	"vuvuzela.io/concurrency"
+	"vuvuzela.io/crypto/onionbox"
+	"vuvuzela.io/crypto/rand"
+	"vuvuzela.io/crypto/shuffle"
+	"vuvuzela.io/vuvuzela/admission"
+	"vuvuzela.io/vuvuzela/configsource"
 	"vuvuzela.io/vuvuzela/convo"
 	"vuvuzela.io/vuvuzela/mixnet"
 )
 
-// Server is the coordinator (entry) server for the
-// Vuvuzela conversation protocol.
+// Server is the coordinator (entry) server for a Vuvuzela mix service,
+// such as the conversation protocol. A coordinator process can run one
+// Server per service, each with its own round loop.
 type Server struct {
 	Service    string
 	PrivateKey ed25519.PrivateKey
 
-	ConfigClient *config.Client
+	// MixService is the service that the mixers run for Service. It
+	// must be bidirectional. The coordinator only uses it to check the
+	// size of the onions that clients send.
+	MixService mixnet.MixService
+
+	// ConfigSource provides the service's config. The coordinator
+	// checks it for new configs, and only switches to a new config
+	// if the guardians of its current config signed it.
+	ConfigSource configsource.Source
+
+	// ConfigService is the name of the service's config on the config
+	// server. The config's inner config must be a ChainConfig. If empty,
+	// ConfigService is the same as Service.
+	ConfigService string
 
 	RoundDelay time.Duration
 
+	// Schedule bounds how the coordinator adapts the round delay and
+	// the number of rounds in flight to the mixing time (see
+	// schedule.go). RoundDelay is the default minimum round delay.
+	// If the config has a wall-clock schedule, the round delay is
+	// the schedule's period, and only the window adapts.
+	Schedule ScheduleBounds
+
+	// NumTraps is the number of trap onions that the coordinator
+	// mixes into every round to audit the mixers (see traps.go).
+	// Trap auditing is disabled if NumTraps is zero. Traps are Convo
+	// messages, so only the Convo service supports them.
+	NumTraps int
+
+	// AuditShuffles makes the coordinator audit the mixers' shuffles
+	// after every round (see audit.go). The evidence is written to
+	// AuditDir, if set.
+	AuditShuffles bool
+	AuditDir      string
+
+	// Limits bounds the onions that clients can submit per round
+	// (see limits.go).
+	Limits Limits
+
+	// Admission, if set, requires an unspent admission token with
+	// every onion bundle.
+	Admission *admission.Verifier
+
+	// ProofOfWork, if set, requires a puzzle solution with every
+	// onion bundle (see pow.go).
+	ProofOfWork *PoWSettings
+
 	PersistPath string
 
 	// round is updated atomically.
@@ -50,22 +100,56 @@ type Server struct {
 	shutdown     chan struct{}
 	freshConfig  bool
 	latestConfig *config.SignedConfig
+	trapReports  []TrapReport
+
+	pow   powController
+	sched *scheduler
+
+	// persistMu serializes calls to Persist. The in-flight rounds
+	// and lastAnnounced are protected by mu (see persist.go).
+	persistMu     sync.Mutex
+	lastAnnounced uint32
+	inFlight      map[uint32]*inFlightRound
+	orphans       []*inFlightRound
+
+	// announcements are sent to clients when they connect.
+	announcements announcementStore
 
 	hub          *typesocket.Hub
 	mixnetClient *mixnet.Client
 }
 
+// A ChainConfig is the inner config of a service that the coordinator
+// can run, such as convo.ConvoConfig.
+type ChainConfig interface {
+	// Chains returns the mix chains for the round.
+	Chains(round uint32) [][]mixnet.PublicServerConfig
+}
+
+// A WallClockConfig is a config that can fix round deadlines to the
+// wall clock, such as convo.ConvoConfig.
+type WallClockConfig interface {
+	// WallClock returns the round schedule, or nil if the
+	// coordinator decides the rounds.
+	WallClock() *convo.Schedule
+}
+
 type roundState struct {
 	roundInfo *NewRound
 
 	mu     sync.Mutex
 	open   bool
 	onions []onionBundle
+	usage  roundUsage
+
+	// solutions has the digests of the round's puzzle solutions.
+	solutions map[[32]byte]struct{}
 }
 
 type onionBundle struct {
 	sender typesocket.Conn
 	onions [][]byte
+	chains []int
 }
 
 var ErrServerClosed = errors.New("coordinator: server closed")
@@ -74,17 +158,34 @@ func (srv *Server) Run() error {
 	if srv.PersistPath == "" {
 		return errors.New("no persist path specified")
 	}
+	if srv.MixService == nil {
+		return errors.New("no mix service specified")
+	}
+	if !srv.MixService.Bidirectional() {
+		return errors.New("%s: mix service is not bidirectional", srv.Service)
+	}
+	if _, ok := srv.MixService.(*convo.ConvoService); srv.NumTraps > 0 && !ok {
+		return errors.New("%s: trap messages are only supported by the Convo service", srv.Service)
+	}
 
 	mux := typesocket.NewMux(map[string]interface{}{
 		"onion": srv.incomingOnion,
+		"ping":  srv.ping,
 	})
 	srv.hub = &typesocket.Hub{
-		Mux: mux,
+		Mux:       mux,
+		OnConnect: srv.onConnect,
 	}
 
 	srv.mixnetClient = &mixnet.Client{
 		Key: srv.PrivateKey,
 	}
+	sched, err := newScheduler(srv.Schedule, srv.RoundDelay)
+	if err != nil {
+		return errors.Wrap(err, "%s: schedule", srv.Service)
+	}
+	srv.sched = sched
+	go srv.deleteOrphans()
 
 	srv.mu.Lock()
 	srv.rounds = make(map[uint32]*roundState)
@@ -122,6 +223,8 @@ func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		srv.hub.ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/sendannouncement"):
 		srv.sendAnnouncementHandler(w, r)
+	case strings.HasPrefix(r.URL.Path, "/traps"):
+		srv.trapReportsHandler(w, r)
 	default:
 		http.Error(w, "not found", http.StatusNotFound)
 	}
@@ -130,23 +233,41 @@ func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 type OnionMsg struct {
 	Round  uint32
 	Onions [][]byte
+
+	// Chains is the index of the mix chain for each onion, chosen by
+	// the client from the onion's dead drop. If empty, all onions go
+	// to the first chain. Replies never set Chains.
+	Chains []int
+
+	// Token is the admission token for the bundle, if the coordinator
+	// requires one. Replies never set Token.
+	Token *admission.Token `json:",omitempty"`
+
+	// Solution solves the round's Puzzle for the bundle, if the
+	// coordinator requires proof of work. Replies never set Solution.
+	Solution uint64 `json:",omitempty"`
 }
 
 type NewRound struct {
-	Round         uint32
-	ConfigHash    string
-	MixSettings   mixnet.RoundSettings
-	MixSignatures [][]byte
-	EndTime       time.Time
-}
+	Round      uint32
+	ConfigHash string
+
+	// Chains has the mixnet settings for each of the config's mix
+	// chains, in the config's order.
+	Chains []ChainSettings
+
+	// Puzzle is set if clients must solve it for every onion bundle.
+	Puzzle *admission.Puzzle `json:",omitempty"`
 
-type RoundError struct {
-	Round uint32
-	Err   string
+	// Schedule is the coordinator's schedule when the round started.
+	Schedule RoundSchedule
+
+	EndTime time.Time
 }
 
-type GlobalAnnouncement struct {
-	Message string
+type ChainSettings struct {
+	MixSettings   mixnet.RoundSettings
+	MixSignatures [][]byte
 }
 
 func (srv *Server) sendAnnouncementHandler(w http.ResponseWriter, req *http.Request) {
@@ -187,7 +308,14 @@ func (srv *Server) sendAnnouncementHandler(w http.ResponseWriter, req *http.Requ
 		http.Error(w, "error decoding json", http.StatusBadRequest)
 		return
 	}
+	// Clients check the announcement too, but we should not relay
+	// announcements that they will drop.
+	if err := args.Verify(conf.Guardians, time.Now()); err != nil {
+		http.Error(w, fmt.Sprintf("invalid announcement: %s", err), http.StatusBadRequest)
+		return
+	}
 
+	srv.announcements.add(&args)
 	err = srv.hub.Broadcast("announcement", args)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("failed to broadcast message: %s", err), http.StatusInternalServerError)
@@ -197,6 +325,9 @@ func (srv *Server) sendAnnouncementHandler(w http.ResponseWriter, req *http.Requ
 	w.Write([]byte("OK"))
 }
 
+// onConnect catches up a new connection: it sends the rounds that are
+// open for onions, oldest first, and the announcements that have not
+// expired.
 func (srv *Server) onConnect(c typesocket.Conn) error {
 	rounds := make([]*roundState, 0, 4)
 	srv.mu.Lock()
@@ -204,6 +335,9 @@ func (srv *Server) onConnect(c typesocket.Conn) error {
 		rounds = append(rounds, st)
 	}
 	srv.mu.Unlock()
+	sort.Slice(rounds, func(i, j int) bool {
+		return rounds[i].roundInfo.Round < rounds[j].roundInfo.Round
+	})
 
 	for _, st := range rounds {
 		if time.Until(st.roundInfo.EndTime) < 100*time.Millisecond {
@@ -215,6 +349,13 @@ func (srv *Server) onConnect(c typesocket.Conn) error {
 		}
 	}
 
+	for _, a := range srv.announcements.active(time.Now()) {
+		err := c.Send("announcement", a)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -223,40 +364,127 @@ func (srv *Server) incomingOnion(c typesocket.Conn, o OnionMsg) {
 	st, ok := srv.rounds[o.Round]
 	srv.mu.Unlock()
 	if !ok {
-		c.Send("error", RoundError{
-			Round: o.Round,
-			Err:   "round not found",
-		})
+		c.Send("error", newRoundError(o.Round, CodeRoundNotFound, "round not found"))
 		return
 	}
 
+	if err := checkChains(o, len(st.roundInfo.Chains)); err != nil {
+		c.Send("error", newRoundError(o.Round, CodeBadChains, "%s", err))
+		return
+	}
+	if srv.MixService != nil {
+		if err := checkOnionSizes(o, st.roundInfo.Chains, srv.MixService.SizeIncomingMessage()); err != nil {
+			c.Send("error", newRoundError(o.Round, CodeBadOnionSize, "%s", err))
+			return
+		}
+	}
+
+	var solution [32]byte
+	if puzzle := st.roundInfo.Puzzle; puzzle != nil {
+		var solved bool
+		solution, solved = puzzle.Check(o.Onions, o.Solution)
+		if !solved {
+			rejectedSolutions.WithLabelValues(srv.Service).Inc()
+			c.Send("error", newRoundError(o.Round, CodeBadSolution, "invalid puzzle solution"))
+			return
+		}
+	}
+
 	ok = false
+	var limitErr *RoundError
+	var tokenErr error
+	reused := false
 	st.mu.Lock()
 	if st.open {
-		st.onions = append(st.onions, onionBundle{
-			sender: c,
-			onions: o.Onions,
-		})
+		if st.roundInfo.Puzzle != nil {
+			_, reused = st.solutions[solution]
+		}
+		if !reused {
+			limitErr = st.usage.check(srv.limits(), c, o)
+		}
+		// Spend the token last, so that a rejected bundle does not
+		// cost the client its token.
+		if !reused && limitErr == nil && srv.Admission != nil {
+			tokenErr = srv.Admission.Spend(o.Token)
+		}
+		if !reused && limitErr == nil && tokenErr == nil {
+			st.usage.add(c, o)
+			st.onions = append(st.onions, onionBundle{
+				sender: c,
+				onions: o.Onions,
+				chains: o.Chains,
+			})
+			if st.roundInfo.Puzzle != nil {
+				st.solutions[solution] = struct{}{}
+			}
+		}
 		ok = true
 	}
 	st.mu.Unlock()
 
+	if reused {
+		rejectedSolutions.WithLabelValues(srv.Service).Inc()
+		c.Send("error", newRoundError(o.Round, CodeSolutionReused, "puzzle solution was already used"))
+		return
+	}
+	if limitErr != nil {
+		limitedBundles.WithLabelValues(srv.Service, string(limitErr.Limit)).Inc()
+		c.Send("error", limitErr)
+		return
+	}
+	if tokenErr != nil {
+		rejectedTokens.WithLabelValues(srv.Service).Inc()
+		c.Send("error", newRoundError(o.Round, CodeBadToken, "%s", tokenErr))
+		return
+	}
 	if !ok {
-		c.Send("error", RoundError{
-			Round: o.Round,
-			Err:   fmt.Sprintf("round is closed: deadline was %s ago", time.Now().Sub(st.roundInfo.EndTime)),
-		})
+		c.Send("error", newRoundError(o.Round, CodeRoundClosed, "round is closed: deadline was %s ago", time.Now().Sub(st.roundInfo.EndTime)))
+	}
+}
+
+func checkChains(o OnionMsg, numChains int) error {
+	if len(o.Chains) == 0 {
+		return nil
+	}
+	if len(o.Chains) != len(o.Onions) {
+		return errors.New("got %d chain indexes for %d onions", len(o.Chains), len(o.Onions))
+	}
+	for _, chain := range o.Chains {
+		if chain < 0 || chain >= numChains {
+			return errors.New("invalid chain index: %d (round has %d chains)", chain, numChains)
+		}
+	}
+	return nil
+}
+
+// checkOnionSizes checks that every onion has the size that the first
+// mixer of its chain expects for messages of the given size.
+func checkOnionSizes(o OnionMsg, chains []ChainSettings, messageSize int) error {
+	for i, onion := range o.Onions {
+		c := 0
+		if len(o.Chains) > 0 {
+			c = o.Chains[i]
+		}
+		size := len(chains[c].MixSettings.OnionKeys)*onionbox.Overhead + messageSize
+		if len(onion) != size {
+			return errors.New("onion %d has size %d, expected %d", i, len(onion), size)
+		}
 	}
+	return nil
+}
+
+func (srv *Server) configService() string {
+	if srv.ConfigService != "" {
+		return srv.ConfigService
+	}
+	return srv.Service
 }
 
 func (srv *Server) updateConfigLoop() {
 	for {
 		log.Infof("Fetching latest config")
 
-		// For evaluation purposes, we swap the Alpenhorn
-		// config file retrieval with a static one.
-		// currentConfig, err := srv.ConfigClient.CurrentConfig(srv.Service)
-		currentConfig, err := eval.StaticConfig()
+		currentConfig, err := srv.ConfigSource.CurrentConfig(srv.configService())
 		if err != nil {
 			log.Errorf("failed to fetch current config: %s", err)
 			srv.mu.Lock()
@@ -267,58 +495,126 @@ func (srv *Server) updateConfigLoop() {
 		}
 
 		srv.mu.Lock()
-		srv.freshConfig = true
-		srv.latestConfig = currentConfig
+		prevConfig := srv.latestConfig
 		srv.mu.Unlock()
 
-		time.Sleep(1 * time.Minute)
+		chain, err := configsource.Verify(srv.ConfigSource, prevConfig, currentConfig)
+		if err != nil {
+			// Keep running with the config we have.
+			log.Errorf("ignoring new config %s: %s", currentConfig.Hash(), err)
+		} else {
+			if len(chain) > 1 {
+				log.WithFields(log.Fields{
+					"service": srv.Service,
+					"prev":    prevConfig.Hash(),
+					"next":    currentConfig.Hash(),
+				}).Info("Loaded new config")
+			}
+			srv.mu.Lock()
+			srv.latestConfig = currentConfig
+			srv.mu.Unlock()
+		}
+
+		srv.mu.Lock()
+		srv.freshConfig = srv.latestConfig != nil
+		srv.mu.Unlock()
+
+		time.Sleep(configsource.Interval(srv.ConfigSource))
 	}
 }
 
 func (srv *Server) loop() {
-	numInFlight := 5
-	flights := make(chan struct{}, numInFlight)
-	for i := 0; i < numInFlight; i++ {
-		flights <- struct{}{}
-	}
-
-	atomic.AddUint32(&srv.round, 100)
+	// done is buffered so that rounds can finish after the loop stops.
+	done := make(chan struct{}, srv.sched.bounds.MaxInFlight)
+	running := 0
 
 	lastDeadline := time.Now()
-	for _ = range flights {
-		round := atomic.AddUint32(&srv.round, 1)
-
-		// Persist every 20 rounds.
-		if round%uint32(20) == 0 {
-			go func() {
-				err := srv.Persist()
-				if err != nil {
-					panic(err)
-				}
-			}()
+	for {
+		wc := srv.wallClock()
+		current := func() RoundSchedule {
+			if wc != nil {
+				return srv.sched.wallClock(wc.Period)
+			}
+			return srv.sched.current()
 		}
-
-		if time.Now().After(lastDeadline) {
-			lastDeadline = time.Now()
+		schedule := current()
+		for running >= schedule.InFlight {
+			select {
+			case <-done:
+				running--
+			case <-srv.shutdown:
+				log.Info("Shutting down")
+				return
+			}
+			schedule = current()
+		}
+		running++
+
+		var round uint32
+		if wc != nil {
+			var deadline time.Time
+			round, deadline = srv.nextScheduledRound(wc)
+			// Announce rounds no further ahead than the window.
+			wait := time.Until(deadline) - time.Duration(schedule.InFlight)*wc.Period
+			if wait > 0 && !srv.sleep(wait) {
+				log.Info("Shutting down")
+				return
+			}
+			lastDeadline = deadline
+		} else {
+			// The round is persisted before it starts (see persist.go).
+			round = atomic.AddUint32(&srv.round, 1)
+
+			if time.Now().After(lastDeadline) {
+				lastDeadline = time.Now()
+			}
+			lastDeadline = lastDeadline.Add(schedule.Delay)
 		}
-		lastDeadline = lastDeadline.Add(srv.RoundDelay)
-		go func() {
-			srv.runRound(context.Background(), round, lastDeadline)
-			flights <- struct{}{}
-		}()
+
+		scheduleDelay.WithLabelValues(srv.Service).Set(schedule.Delay.Seconds())
+		scheduleInFlight.WithLabelValues(srv.Service).Set(float64(schedule.InFlight))
+		go func(round uint32, deadline time.Time, schedule RoundSchedule) {
+			srv.runRound(context.Background(), round, deadline, schedule)
+			done <- struct{}{}
+		}(round, lastDeadline, schedule)
+	}
+}
+
+// wallClock returns the latest config's round schedule, if any.
+func (srv *Server) wallClock() *convo.Schedule {
+	srv.mu.Lock()
+	conf := srv.latestConfig
+	srv.mu.Unlock()
+	if conf == nil {
+		return nil
+	}
+	if wc, ok := conf.Inner.(WallClockConfig); ok {
+		return wc.WallClock()
 	}
+	return nil
+}
 
-	log.Info("Shutting down")
+// nextScheduledRound picks the next round from the schedule. It skips
+// the rounds that leave clients less than a period to send onions, and
+// never reuses a round number, even if the round counter is ahead of
+// the schedule.
+func (srv *Server) nextScheduledRound(wc *convo.Schedule) (uint32, time.Time) {
+	round := atomic.LoadUint32(&srv.round) + 1
+	if r := wc.Round(time.Now().Add(wc.Period)); r > round {
+		round = r
+	}
+	atomic.StoreUint32(&srv.round, round)
+	return round, wc.Deadline(round)
 }
 
-func (srv *Server) runRound(ctx context.Context, round uint32, deadline time.Time) {
+func (srv *Server) runRound(ctx context.Context, round uint32, deadline time.Time, schedule RoundSchedule) {
 	defer func() {
 		srv.mu.Lock()
 		delete(srv.rounds, round)
 		srv.mu.Unlock()
 	}()
 
-	logger := log.WithFields(log.Fields{"round": round})
+	logger := log.WithFields(log.Fields{"service": srv.Service, "round": round})
 
 	srv.mu.Lock()
 	conf := srv.latestConfig
@@ -331,31 +627,60 @@ func (srv *Server) runRound(ctx context.Context, round uint32, deadline time.Tim
 		return
 	}
 
-	mixServers := conf.Inner.(*convo.ConvoConfig).MixServers
-	logger.Infof("Starting new round with %d mixers", len(mixServers))
+	chainConf, ok := conf.Inner.(ChainConfig)
+	if !ok {
+		logger.Errorf("%s config does not have mix chains: %T", conf.Service, conf.Inner)
+		time.Sleep(10 * time.Second)
+		return
+	}
+	chains := chainConf.Chains(round)
+	logger.Infof("Starting new round with %d chains", len(chains))
 
-	mixSettings := mixnet.RoundSettings{
-		Service: "Convo",
-		Round:   round,
+	err := srv.startInFlight(&inFlightRound{
+		Round:      round,
+		ConfigHash: conf.Hash(),
+		Chains:     chains,
+	})
+	if err != nil {
+		logger.Errorf("error persisting state: %s", err)
+		return
 	}
-	mixSigs, err := srv.mixnetClient.NewRound(context.Background(), mixServers, &mixSettings)
+	defer srv.finishInFlight(round)
+
+	chainSettings, err := srv.newMixRound(chains, round)
 	if err != nil {
 		logger.WithFields(log.Fields{"call": "mixnet.NewRound"}).Error(err)
+		roundErrors.WithLabelValues(srv.Service, "mixnet.NewRound").Inc()
+		srv.abandonRound(round, chains)
 		return
 	}
+	currentRound.WithLabelValues(srv.Service).Set(float64(round))
 
 	roundInfo := &NewRound{
-		Round:         round,
-		ConfigHash:    conf.Hash(),
-		MixSettings:   mixSettings,
-		MixSignatures: mixSigs,
-		EndTime:       deadline,
+		Round:      round,
+		ConfigHash: conf.Hash(),
+		Chains:     chainSettings,
+		Schedule:   schedule,
+		EndTime:    deadline,
 	}
 	st := &roundState{
 		roundInfo: roundInfo,
 		open:      true,
 		onions:    make([]onionBundle, 0, 512),
 	}
+	if srv.ProofOfWork != nil {
+		difficulty := srv.pow.next(*srv.ProofOfWork)
+		roundInfo.Puzzle = admission.NewPuzzle(round, difficulty)
+		st.solutions = make(map[[32]byte]struct{})
+		powDifficulty.WithLabelValues(srv.Service).Set(float64(difficulty))
+	}
+	if err := srv.announceInFlight(round, deadline); err != nil {
+		logger.Errorf("error persisting state: %s", err)
+		srv.abandonRound(round, chains)
+		return
+	}
+
+	// New connections get the round from onConnect once it is here.
 	srv.mu.Lock()
 	srv.rounds[round] = st
 	srv.mu.Unlock()
@@ -370,9 +695,58 @@ func (srv *Server) runRound(ctx context.Context, round uint32, deadline time.Tim
 	st.mu.Lock()
 	st.open = false
 	onions := st.onions
+	st.usage = roundUsage{}
+	st.solutions = nil
 	st.mu.Unlock()
 
-	srv.mixOnions(ctx, mixServers[0], round, onions)
+	if srv.ProofOfWork != nil {
+		numOnions := 0
+		for _, bundle := range onions {
+			numOnions += len(bundle.onions)
+		}
+		srv.pow.observe(*srv.ProofOfWork, numOnions)
+	}
+
+	traps := make([]*trapSet, len(chains))
+	if srv.NumTraps > 0 {
+		for i := range traps {
+			traps[i] = newTrapSet(&chainSettings[i].MixSettings, srv.NumTraps)
+		}
+	}
+
+	srv.mixOnions(ctx, chains, round, onions, traps)
+}
+
+// newMixRound starts the round on every chain.
+func (srv *Server) newMixRound(chains [][]mixnet.PublicServerConfig, round uint32) ([]ChainSettings, error) {
+	settings := make([]ChainSettings, len(chains))
+	errs := make(chan error, len(chains))
+	for i := range chains {
+		go func(i int) {
+			settings[i].MixSettings = mixnet.RoundSettings{
+				Service: srv.Service,
+				Round:   round,
+			}
+			sigs, err := srv.mixnetClient.NewRound(context.Background(), chains[i], &settings[i].MixSettings)
+			if err != nil {
+				errs <- errors.Wrap(err, "chain %d", i)
+				return
+			}
+			settings[i].MixSignatures = sigs
+			errs <- nil
+		}(i)
+	}
+
+	var err error
+	for range chains {
+		if e := <-errs; e != nil && err == nil {
+			err = e
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return settings, nil
 }
 
 func (srv *Server) sleep(d time.Duration) bool {
@@ -391,7 +765,10 @@ type senderRange struct {
 	start, end int
 }
 
-func (srv *Server) mixOnions(ctx context.Context, firstServer mixnet.PublicServerConfig, round uint32, out []onionBundle) {
+// mixOnions sends each chain its share of the onions, and sends the
+// replies back to the clients once every chain has finished. If any
+// chain fails, the whole round fails.
+func (srv *Server) mixOnions(ctx context.Context, chains [][]mixnet.PublicServerConfig, round uint32, out []onionBundle, traps []*trapSet) {
 	numOnions := 0
 	for _, bundle := range out {
 		numOnions += len(bundle.onions)
@@ -399,28 +776,50 @@ func (srv *Server) mixOnions(ctx context.Context, firstServer mixnet.PublicServe
 
 	onions := make([][]byte, 0, numOnions)
 	senders := make([]senderRange, len(out))
+	// batches[c] holds the positions in onions of chain c's onions.
+	batches := make([][]int, len(chains))
 	for i, o := range out {
 		senders[i] = senderRange{
 			sender: o.sender,
 			start:  len(onions),
 			end:    len(onions) + len(o.onions),
 		}
+		for j := range o.onions {
+			c := 0
+			if len(o.chains) > 0 {
+				c = o.chains[j]
+			}
+			batches[c] = append(batches[c], len(onions)+j)
+		}
 		onions = append(onions, o.onions...)
 	}
 
-	logger := log.WithFields(log.Fields{"round": round, "onions": len(onions)})
+	logger := log.WithFields(log.Fields{"round": round, "onions": len(onions), "chains": len(chains)})
 	logger.Info("Start mixing")
 	start := time.Now()
 
-	replies, err := srv.mixnetClient.RunRoundBidirectional(ctx, firstServer, srv.Service, round, onions)
-	if err != nil {
-		logger.WithFields(log.Fields{"call": "RunRound"}).Error(err)
-		srv.hub.Broadcast("error", RoundError{Round: round, Err: "server error"})
+	replies := make([][]byte, len(onions))
+	errs := make(chan error, len(chains))
+	for c := range chains {
+		go func(c int) {
+			errs <- srv.mixChain(ctx, c, chains[c], round, onions, batches[c], traps[c], replies)
+		}(c)
+	}
+	failed := false
+	for range chains {
+		if err := <-errs; err != nil {
+			failed = true
+		}
+	}
+	if failed {
+		srv.hub.Broadcast("error", newRoundError(round, CodeServerError, "server error"))
 		return
 	}
 
 	end := time.Now()
 	logger.WithFields(log.Fields{"duration": end.Sub(start)}).Info("Done mixing")
+	srv.sched.observe(end.Sub(start))
+	mixSeconds.WithLabelValues(srv.Service).Observe(end.Sub(start).Seconds())
 
 	concurrency.ParallelFor(len(senders), func(p *concurrency.P) {
 		for i, ok := p.Next(); ok; i, ok = p.Next() {
@@ -432,3 +831,50 @@ func (srv *Server) mixOnions(ctx context.Context, firstServer mixnet.PublicServe
 		}
 	})
 }
+
+// mixChain runs the onions at the given positions through the chain
+// with index c, and stores their replies at the same positions.
+func (srv *Server) mixChain(ctx context.Context, c int, chain []mixnet.PublicServerConfig, round uint32, onions [][]byte, positions []int, traps *trapSet, replies [][]byte) error {
+	numTraps := 0
+	if traps != nil {
+		numTraps = len(traps.onions)
+	}
+
+	batch := make([][]byte, len(positions), len(positions)+numTraps)
+	for k, pos := range positions {
+		batch[k] = onions[pos]
+	}
+
+	var shuffler shuffle.Shuffler
+	if numTraps > 0 {
+		batch = append(batch, traps.onions...)
+		// Hide the traps among the real onions; otherwise the first
+		// mixer could recognize them by their position in the batch.
+		shuffler = shuffle.New(rand.Reader, len(batch))
+		shuffler.Shuffle(batch)
+	}
+	roundOnions.WithLabelValues(srv.Service).Observe(float64(len(batch)))
+
+	chainReplies, err := srv.mixnetClient.RunRoundBidirectional(ctx, chain[0], srv.Service, round, batch)
+	if err != nil {
+		log.WithFields(log.Fields{"round": round, "chain": c, "call": "RunRound"}).Error(err)
+		roundErrors.WithLabelValues(srv.Service, "RunRound").Inc()
+		return err
+	}
+
+	if srv.AuditShuffles {
+		go srv.auditRound(c, chain, round, batch)
+	}
+
+	if numTraps > 0 {
+		shuffler.Unshuffle(chainReplies)
+		report := traps.check(round, chainReplies[len(chainReplies)-numTraps:])
+		report.Chain = c
+		srv.addTrapReport(report)
+	}
+
+	for k, pos := range positions {
+		replies[pos] = chainReplies[k]
+	}
+	return nil
+}
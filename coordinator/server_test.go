@@ -0,0 +1,102 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package coordinator
+
+import (
+	"testing"
+	"time"
+
+	"vuvuzela.io/crypto/onionbox"
+	"vuvuzela.io/vuvuzela/convo"
+	"vuvuzela.io/vuvuzela/mixnet"
+)
+
+func TestOnionSizes(t *testing.T) {
+	service := new(convo.ConvoService)
+	chains := make([]ChainSettings, 2)
+	for i := range chains {
+		chains[i].MixSettings = mixnet.RoundSettings{
+			Round:     1,
+			OnionKeys: make([]*[32]byte, i+2),
+		}
+	}
+	st := &roundState{
+		roundInfo: &NewRound{
+			Round:  1,
+			Chains: chains,
+		},
+		open: true,
+	}
+	srv := &Server{
+		Service:    "Convo",
+		MixService: service,
+		rounds:     map[uint32]*roundState{1: st},
+	}
+
+	size := func(chainLen int) int {
+		return chainLen*onionbox.Overhead + service.SizeIncomingMessage()
+	}
+	o := OnionMsg{
+		Round:  1,
+		Onions: [][]byte{make([]byte, size(2)), make([]byte, size(3))},
+		Chains: []int{0, 1},
+	}
+	alice := new(recordingConn)
+	srv.incomingOnion(alice, o)
+	if len(alice.sent) != 0 {
+		t.Fatalf("unexpected error: %v", alice.sent)
+	}
+
+	// The second onion is sized for the wrong chain.
+	o.Chains = []int{0, 0}
+	bob := new(recordingConn)
+	srv.incomingOnion(bob, o)
+	if len(bob.sent) != 1 {
+		t.Fatalf("expected error for onion of the wrong size")
+	}
+	if len(st.onions) != 1 {
+		t.Fatalf("expected 1 bundle, got %d", len(st.onions))
+	}
+}
+
+func TestOnConnect(t *testing.T) {
+	now := time.Now()
+	srv := &Server{
+		rounds: make(map[uint32]*roundState),
+	}
+	for _, r := range []struct {
+		round   uint32
+		endTime time.Time
+	}{
+		{12, now.Add(20 * time.Second)},
+		{10, now.Add(-time.Second)}, // closed and mixing
+		{11, now.Add(10 * time.Second)},
+	} {
+		srv.rounds[r.round] = &roundState{
+			roundInfo: &NewRound{Round: r.round, EndTime: r.endTime},
+		}
+	}
+	expired := &GlobalAnnouncement{Message: "old", Expires: now.Add(-time.Minute)}
+	active := &GlobalAnnouncement{Message: "new", Expires: now.Add(time.Hour)}
+	srv.announcements.add(expired)
+	srv.announcements.add(active)
+
+	conn := new(recordingConn)
+	if err := srv.onConnect(conn); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(conn.sent) != 3 {
+		t.Fatalf("expected 3 messages, got %d: %v", len(conn.sent), conn.ids)
+	}
+	for i, round := range []uint32{11, 12} {
+		if conn.ids[i] != "newround" || conn.sent[i].(*NewRound).Round != round {
+			t.Fatalf("message %d: got %s %v, want newround %d", i, conn.ids[i], conn.sent[i], round)
+		}
+	}
+	if conn.ids[2] != "announcement" || conn.sent[2].(*GlobalAnnouncement) != active {
+		t.Fatalf("message 2: got %s %v, want the active announcement", conn.ids[2], conn.sent[2])
+	}
+}
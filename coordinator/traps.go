@@ -0,0 +1,158 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package coordinator
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/nacl/box"
+
+	"vuvuzela.io/alpenhorn/log"
+	"vuvuzela.io/crypto/onionbox"
+	"vuvuzela.io/crypto/rand"
+	"vuvuzela.io/vuvuzela/convo"
+	"vuvuzela.io/vuvuzela/mixnet"
+)
+
+// maxTrapReports is the number of trap reports kept in memory.
+const maxTrapReports = 256
+
+// Trap messages are onions that the coordinator submits on its own behalf.
+// Each trap is addressed to a fresh random dead drop, so the last server
+// sees an idle user and echoes the trap's message back. The coordinator
+// knows the shared key for every layer of the reply onion, so it can peel
+// the reply one layer at a time. An honest mixer seals the reply for every
+// onion it received with that onion's shared key. If a mixer drops a trap
+// or replaces it with its own onion, the layer added by the mixer that was
+// supposed to receive the trap is sealed with a different key and fails to
+// open. This lets the coordinator narrow the fault down to that mixer or
+// the mixer before it (which might have forwarded something else in the
+// trap's place).
+type trapSet struct {
+	onions    [][]byte
+	messages  [][]byte
+	onionKeys [][]*[32]byte
+}
+
+func newTrapSet(settings *mixnet.RoundSettings, numTraps int) *trapSet {
+	traps := &trapSet{
+		onions:    make([][]byte, numTraps),
+		messages:  make([][]byte, numTraps),
+		onionKeys: make([][]*[32]byte, numTraps),
+	}
+
+	nonce := mixnet.ForwardNonce(settings.Round)
+	for i := range traps.onions {
+		msg := new(convo.DeadDropMessage)
+		rand.Read(msg.DeadDrop[:])
+		rand.Read(msg.EncryptedMessage[:])
+
+		traps.messages[i] = msg.EncryptedMessage[:]
+		traps.onions[i], traps.onionKeys[i] = onionbox.Seal(msg.Marshal(), nonce, settings.OnionKeys)
+	}
+
+	return traps
+}
+
+// TrapReport summarizes the trap messages for a single round.
+type TrapReport struct {
+	Round uint32
+	Time  time.Time
+
+	// Chain is the index of the mix chain that the traps went through.
+	Chain int
+
+	// Traps is the number of traps that were mixed into the round.
+	Traps int
+
+	// Missing is the number of traps that did not come back intact.
+	Missing int
+
+	// Lost counts the missing traps by chain position: Lost[i] is the
+	// number of traps whose reply layer from mixer i failed to open.
+	// A trap counted at position i was dropped or replaced by mixer i
+	// or by mixer i-1. A trap that opens correctly but carries the wrong
+	// message is counted at the last position.
+	Lost []int
+}
+
+// check opens the trap replies and reports the traps that went missing.
+func (traps *trapSet) check(round uint32, replies [][]byte) TrapReport {
+	chainLen := 0
+	if len(traps.onionKeys) > 0 {
+		chainLen = len(traps.onionKeys[0])
+	}
+
+	report := TrapReport{
+		Round: round,
+		Time:  time.Now(),
+		Traps: len(traps.onions),
+		Lost:  make([]int, chainLen),
+	}
+
+	nonce := mixnet.BackwardNonce(round)
+	for i, reply := range replies {
+		lostAt := -1
+		msg := reply
+		for pos, key := range traps.onionKeys[i] {
+			var ok bool
+			msg, ok = box.OpenAfterPrecomputation(nil, msg, nonce, key)
+			if !ok {
+				lostAt = pos
+				break
+			}
+		}
+		if lostAt == -1 && !bytes.Equal(msg, traps.messages[i]) {
+			lostAt = chainLen - 1
+		}
+		if lostAt != -1 {
+			report.Missing++
+			report.Lost[lostAt]++
+		}
+	}
+
+	return report
+}
+
+func (srv *Server) addTrapReport(report TrapReport) {
+	logger := log.WithFields(log.Fields{"round": report.Round, "chain": report.Chain, "traps": report.Traps, "missing": report.Missing})
+	if report.Missing == 0 {
+		logger.Info("Trap messages intact")
+	} else {
+		logger.WithFields(log.Fields{"lost": report.Lost}).Error("Trap messages missing")
+	}
+	missingTraps.WithLabelValues(srv.Service).Add(float64(report.Missing))
+
+	srv.mu.Lock()
+	srv.trapReports = append(srv.trapReports, report)
+	if len(srv.trapReports) > maxTrapReports {
+		srv.trapReports = srv.trapReports[len(srv.trapReports)-maxTrapReports:]
+	}
+	srv.mu.Unlock()
+}
+
+// TrapReports returns the trap reports for the most recent rounds,
+// oldest first.
+func (srv *Server) TrapReports() []TrapReport {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	reports := make([]TrapReport, len(srv.trapReports))
+	copy(reports, srv.trapReports)
+	return reports
+}
+
+func (srv *Server) trapReportsHandler(w http.ResponseWriter, req *http.Request) {
+	data, err := json.MarshalIndent(srv.TrapReports(), "", "  ")
+	if err != nil {
+		http.Error(w, "error encoding json", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
@@ -0,0 +1,115 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package coordinator
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"golang.org/x/crypto/nacl/box"
+
+	"vuvuzela.io/vuvuzela/mixnet"
+)
+
+const trapChainLen = 3
+
+// mixTrap simulates the chain on a single trap onion. If dropAt is a
+// valid position, the mixer at that position drops the onion and
+// returns a reply sealed with a fresh key, like Server.encryptReplies.
+func mixTrap(t *testing.T, round uint32, privateKeys []*[32]byte, onion []byte, dropAt int) []byte {
+	forward := mixnet.ForwardNonce(round)
+	backward := mixnet.BackwardNonce(round)
+
+	sharedKeys := make([]*[32]byte, len(privateKeys))
+	msg := onion
+	for pos, priv := range privateKeys {
+		var theirPublic [32]byte
+		copy(theirPublic[:], msg[0:32])
+		sharedKeys[pos] = new([32]byte)
+		box.Precompute(sharedKeys[pos], &theirPublic, priv)
+		var ok bool
+		msg, ok = box.OpenAfterPrecomputation(nil, msg[32:], forward, sharedKeys[pos])
+		if !ok {
+			t.Fatalf("mixer %d failed to open trap", pos)
+		}
+	}
+
+	// The last server echoes the message for an idle dead drop.
+	reply := msg[16:]
+	for pos := len(privateKeys) - 1; pos >= 0; pos-- {
+		key := sharedKeys[pos]
+		if pos == dropAt {
+			key = new([32]byte)
+			rand.Read(key[:])
+		}
+		reply = box.SealAfterPrecomputation(nil, reply, backward, key)
+	}
+	return reply
+}
+
+func TestTrapCheck(t *testing.T) {
+	round := uint32(42)
+	settings := &mixnet.RoundSettings{
+		Service:   "Convo",
+		Round:     round,
+		OnionKeys: make([]*[32]byte, trapChainLen),
+	}
+	privateKeys := make([]*[32]byte, trapChainLen)
+	for i := range privateKeys {
+		settings.OnionKeys[i], privateKeys[i], _ = box.GenerateKey(rand.Reader)
+	}
+
+	for dropAt := -1; dropAt < trapChainLen; dropAt++ {
+		traps := newTrapSet(settings, 4)
+		replies := make([][]byte, len(traps.onions))
+		for i, onion := range traps.onions {
+			if i == 0 {
+				replies[i] = mixTrap(t, round, privateKeys, onion, dropAt)
+			} else {
+				replies[i] = mixTrap(t, round, privateKeys, onion, -1)
+			}
+		}
+
+		report := traps.check(round, replies)
+		if report.Traps != 4 {
+			t.Fatalf("dropAt=%d: unexpected trap count: got %d, want 4", dropAt, report.Traps)
+		}
+		if dropAt == -1 {
+			if report.Missing != 0 {
+				t.Fatalf("honest chain: %d traps missing: %v", report.Missing, report.Lost)
+			}
+			continue
+		}
+		if report.Missing != 1 {
+			t.Fatalf("dropAt=%d: got %d missing traps, want 1", dropAt, report.Missing)
+		}
+		if report.Lost[dropAt] != 1 {
+			t.Fatalf("dropAt=%d: trap blamed on wrong position: %v", dropAt, report.Lost)
+		}
+	}
+}
+
+func TestTrapCheckWrongMessage(t *testing.T) {
+	round := uint32(7)
+	settings := &mixnet.RoundSettings{
+		Service:   "Convo",
+		Round:     round,
+		OnionKeys: make([]*[32]byte, trapChainLen),
+	}
+	privateKeys := make([]*[32]byte, trapChainLen)
+	for i := range privateKeys {
+		settings.OnionKeys[i], privateKeys[i], _ = box.GenerateKey(rand.Reader)
+	}
+
+	traps := newTrapSet(settings, 1)
+	// Pretend the last server swapped the trap's message.
+	traps.messages[0] = make([]byte, len(traps.messages[0]))
+	replies := [][]byte{mixTrap(t, round, privateKeys, traps.onions[0], -1)}
+
+	report := traps.check(round, replies)
+	if report.Missing != 1 || report.Lost[trapChainLen-1] != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
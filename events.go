@@ -0,0 +1,189 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package vuvuzela
+
+import (
+	"crypto/rand"
+	"sync"
+
+	"golang.org/x/net/context"
+
+	"vuvuzela.io/alpenhorn/config"
+	"vuvuzela.io/alpenhorn/errors"
+	"vuvuzela.io/vuvuzela/convo"
+	"vuvuzela.io/vuvuzela/coordinator"
+)
+
+// An Event is something that happened in the client, sent on the
+// channel of an EventHandler. It is one of RepliesEvent, ConfigEvent,
+// ErrorEvent, or AnnouncementEvent.
+type Event interface {
+	isEvent()
+}
+
+// RepliesEvent has the replies to the messages sent in a round.
+type RepliesEvent struct {
+	Round   uint32
+	Replies [][]byte
+}
+
+// ConfigEvent has the config chain when the convo config changes,
+// newest config first.
+type ConfigEvent struct {
+	Chain []*config.SignedConfig
+}
+
+// ErrorEvent has an error. Debug errors only matter when debugging.
+type ErrorEvent struct {
+	Err   error
+	Debug bool
+}
+
+// AnnouncementEvent has a verified global announcement.
+type AnnouncementEvent struct {
+	Announcement *coordinator.GlobalAnnouncement
+}
+
+func (RepliesEvent) isEvent()      {}
+func (ConfigEvent) isEvent()       {}
+func (ErrorEvent) isEvent()        {}
+func (AnnouncementEvent) isEvent() {}
+
+// An OutgoingFunc returns the messages to send in a round. It must
+// return before ctx is done; otherwise the EventHandler sends cover
+// messages in the round.
+type OutgoingFunc func(ctx context.Context, round uint32) []*convo.DeadDropMessage
+
+// EventHandler is a ContextConvoHandler for applications that would
+// rather receive events on a channel than implement ConvoHandler.
+// The outgoing messages of a round come from the OutgoingFunc set for
+// the round, or from the one set for every round.
+//
+// The number of messages a client sends is visible to the coordinator,
+// so the EventHandler always sends the same number: it pads the
+// messages with cover messages to random dead drops, and drops the
+// messages beyond that number.
+//
+// The channel must be drained: the client waits to deliver replies,
+// but drops debug errors if the channel is full.
+type EventHandler struct {
+	events      chan Event
+	numOutgoing int
+
+	mu       sync.Mutex
+	outgoing OutgoingFunc
+	rounds   map[uint32]OutgoingFunc
+}
+
+// NewEventHandler returns an EventHandler whose channel can buffer
+// the given number of events, and that sends numOutgoing messages in
+// every round.
+func NewEventHandler(buffer, numOutgoing int) *EventHandler {
+	return &EventHandler{
+		events:      make(chan Event, buffer),
+		numOutgoing: numOutgoing,
+		rounds:      make(map[uint32]OutgoingFunc),
+	}
+}
+
+// Events returns the channel of events.
+func (h *EventHandler) Events() <-chan Event {
+	return h.events
+}
+
+// SetOutgoing sets the OutgoingFunc for every round without its own.
+func (h *EventHandler) SetOutgoing(f OutgoingFunc) {
+	h.mu.Lock()
+	h.outgoing = f
+	h.mu.Unlock()
+}
+
+// SetRoundOutgoing sets the OutgoingFunc for one round. It is
+// forgotten once the client asks for the round's messages, or for the
+// messages of a later round.
+func (h *EventHandler) SetRoundOutgoing(round uint32, f OutgoingFunc) {
+	h.mu.Lock()
+	h.rounds[round] = f
+	h.mu.Unlock()
+}
+
+func (h *EventHandler) OutgoingContext(ctx context.Context, round uint32) []*convo.DeadDropMessage {
+	h.mu.Lock()
+	f, ok := h.rounds[round]
+	if !ok {
+		f = h.outgoing
+	}
+	// Forget this round and the rounds that the client skipped.
+	for r := range h.rounds {
+		if r <= round {
+			delete(h.rounds, r)
+		}
+	}
+	h.mu.Unlock()
+	if f == nil {
+		return h.pad(nil)
+	}
+
+	// Do not let a slow OutgoingFunc hold up the round.
+	done := make(chan []*convo.DeadDropMessage, 1)
+	go func() {
+		done <- f(ctx, round)
+	}()
+	select {
+	case msgs := <-done:
+		if len(msgs) > h.numOutgoing {
+			h.DebugError(errors.New("round %d: dropping %d of %d outgoing messages", round, len(msgs)-h.numOutgoing, len(msgs)))
+			msgs = msgs[:h.numOutgoing]
+		}
+		return h.pad(msgs)
+	case <-ctx.Done():
+		h.DebugError(errors.New("round %d: no outgoing messages before the deadline: %s", round, ctx.Err()))
+		return h.pad(nil)
+	}
+}
+
+// pad fills msgs up to numOutgoing messages with cover messages.
+func (h *EventHandler) pad(msgs []*convo.DeadDropMessage) []*convo.DeadDropMessage {
+	out := make([]*convo.DeadDropMessage, 0, h.numOutgoing)
+	for _, msg := range msgs {
+		if msg != nil {
+			out = append(out, msg)
+		}
+	}
+	for len(out) < h.numOutgoing {
+		msg := new(convo.DeadDropMessage)
+		rand.Read(msg.DeadDrop[:])
+		rand.Read(msg.EncryptedMessage[:])
+		out = append(out, msg)
+	}
+	return out
+}
+
+func (h *EventHandler) Outgoing(round uint32) []*convo.DeadDropMessage {
+	return h.OutgoingContext(context.Background(), round)
+}
+
+func (h *EventHandler) Replies(round uint32, replies [][]byte) {
+	h.events <- RepliesEvent{Round: round, Replies: replies}
+}
+
+func (h *EventHandler) NewConfig(chain []*config.SignedConfig) {
+	h.events <- ConfigEvent{Chain: chain}
+}
+
+func (h *EventHandler) Error(err error) {
+	h.events <- ErrorEvent{Err: err}
+}
+
+func (h *EventHandler) DebugError(err error) {
+	select {
+	case h.events <- ErrorEvent{Err: err, Debug: true}:
+	default:
+	}
+}
+
+func (h *EventHandler) GlobalAnnouncement(a *coordinator.GlobalAnnouncement) {
+	h.events <- AnnouncementEvent{Announcement: a}
+}
@@ -0,0 +1,130 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package vuvuzela
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"vuvuzela.io/vuvuzela/convo"
+)
+
+// messages returns an OutgoingFunc that returns n messages, each
+// tagged with n in its first byte.
+func messages(n int) OutgoingFunc {
+	return func(ctx context.Context, round uint32) []*convo.DeadDropMessage {
+		msgs := make([]*convo.DeadDropMessage, n)
+		for i := range msgs {
+			msgs[i] = new(convo.DeadDropMessage)
+			msgs[i].EncryptedMessage[0] = byte(n)
+		}
+		return msgs
+	}
+}
+
+// countTagged counts the messages tagged with n by messages(n).
+func countTagged(msgs []*convo.DeadDropMessage, n int) int {
+	count := 0
+	for _, msg := range msgs {
+		if msg.EncryptedMessage[0] == byte(n) && msg.DeadDrop == (convo.DeadDrop{}) {
+			count++
+		}
+	}
+	return count
+}
+
+func TestEventHandlerOutgoing(t *testing.T) {
+	const numOutgoing = 3
+	h := NewEventHandler(4, numOutgoing)
+	var _ ContextConvoHandler = h
+
+	// Without an OutgoingFunc, the messages are all cover messages.
+	msgs := h.Outgoing(1)
+	if len(msgs) != numOutgoing {
+		t.Fatalf("got %d messages, want %d", len(msgs), numOutgoing)
+	}
+	if countTagged(msgs, 0) != 0 {
+		t.Fatal("expected cover messages")
+	}
+
+	h.SetOutgoing(messages(1))
+	h.SetRoundOutgoing(3, messages(3))
+	h.SetRoundOutgoing(5, messages(5))
+	for _, r := range []struct {
+		round uint32
+		tag   int
+		n     int
+	}{
+		// Short results are padded.
+		{2, 1, 1},
+		// Long results are truncated.
+		{5, 5, numOutgoing},
+		// Round 3 was skipped, so its OutgoingFunc is gone.
+		{3, 1, 1},
+		{6, 1, 1},
+	} {
+		msgs := h.Outgoing(r.round)
+		if len(msgs) != numOutgoing {
+			t.Fatalf("round %d: got %d messages, want %d", r.round, len(msgs), numOutgoing)
+		}
+		if got := countTagged(msgs, r.tag); got != r.n {
+			t.Fatalf("round %d: got %d messages from the OutgoingFunc, want %d", r.round, got, r.n)
+		}
+	}
+	// Drain the debug error about the truncated round.
+	<-h.Events()
+
+	// An OutgoingFunc that misses its deadline does not hold up the
+	// round, and the round has only cover messages.
+	h.SetRoundOutgoing(7, func(ctx context.Context, round uint32) []*convo.DeadDropMessage {
+		time.Sleep(time.Second)
+		return messages(2)(ctx, round)
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	msgs = h.OutgoingContext(ctx, 7)
+	if len(msgs) != numOutgoing {
+		t.Fatalf("got %d messages after the deadline, want %d", len(msgs), numOutgoing)
+	}
+	if countTagged(msgs, 2) != 0 {
+		t.Fatal("expected cover messages after the deadline")
+	}
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Fatalf("OutgoingContext took %s", d)
+	}
+	select {
+	case e := <-h.Events():
+		if err, ok := e.(ErrorEvent); !ok || !err.Debug {
+			t.Fatalf("expected a debug error, got %#v", e)
+		}
+	default:
+		t.Fatal("expected an error event")
+	}
+}
+
+func TestEventHandlerEvents(t *testing.T) {
+	h := NewEventHandler(1, 1)
+
+	h.Replies(4, [][]byte{[]byte("hello")})
+	e, ok := (<-h.Events()).(RepliesEvent)
+	if !ok || e.Round != 4 || string(e.Replies[0]) != "hello" {
+		t.Fatalf("unexpected event: %#v", e)
+	}
+
+	// Debug errors do not block when the channel is full.
+	h.Replies(5, nil)
+	h.DebugError(context.Canceled)
+	if e, ok := (<-h.Events()).(RepliesEvent); !ok || e.Round != 5 {
+		t.Fatalf("unexpected event: %#v", e)
+	}
+	select {
+	case e := <-h.Events():
+		t.Fatalf("expected the debug error to be dropped, got %#v", e)
+	default:
+	}
+}
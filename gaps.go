@@ -0,0 +1,61 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package vuvuzela
+
+import (
+	"time"
+
+	"vuvuzela.io/vuvuzela/convo"
+)
+
+// roundGaps finds the scheduled rounds that the coordinator did not
+// announce. With a mix pool, every round's chains are fixed by its
+// number, and the schedule fixes when each round runs; a coordinator
+// that skips rounds could be running only the rounds whose chains it
+// likes. Rounds are announced in any order, so a round counts as
+// skipped once its deadline passes without an announcement.
+type roundGaps struct {
+	// checked is the round up to which every round was either seen
+	// or reported as skipped.
+	checked uint32
+	seen    map[uint32]bool
+}
+
+// reset forgets the rounds seen so far, for example when the client
+// reconnects and may have missed announcements.
+func (g *roundGaps) reset() {
+	g.checked = 0
+	g.seen = nil
+}
+
+// observe records that round was announced, and returns the rounds
+// that were skipped since the last call, as of time now.
+func (g *roundGaps) observe(round uint32, sched *convo.Schedule, now time.Time) []uint32 {
+	if round == 0 {
+		return nil
+	}
+	if g.seen == nil {
+		g.seen = make(map[uint32]bool)
+		g.checked = round - 1
+	}
+	if round <= g.checked {
+		return nil
+	}
+	g.seen[round] = true
+
+	var skipped []uint32
+	for {
+		next := g.checked + 1
+		if g.seen[next] {
+			delete(g.seen, next)
+		} else if sched.Deadline(next).Before(now) {
+			skipped = append(skipped, next)
+		} else {
+			break
+		}
+		g.checked = next
+	}
+	return skipped
+}
@@ -0,0 +1,52 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package vuvuzela
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"vuvuzela.io/vuvuzela/convo"
+)
+
+func TestRoundGaps(t *testing.T) {
+	sched := &convo.Schedule{
+		Genesis: time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC),
+		Period:  10 * time.Second,
+	}
+	at := func(round uint32) time.Time {
+		return sched.Deadline(round).Add(-time.Second)
+	}
+
+	var g roundGaps
+	for _, r := range []struct {
+		round   uint32
+		now     time.Time
+		skipped []uint32
+	}{
+		{100, at(98), nil},
+		// Rounds can be announced out of order.
+		{102, at(100), nil},
+		{101, at(100), nil},
+		// Round 104 is announced before round 103's deadline.
+		{104, at(102), nil},
+		{103, at(103), nil},
+		// Rounds 105 and 106 are never announced.
+		{107, at(107), []uint32{105, 106}},
+		{105, at(107), nil},
+	} {
+		skipped := g.observe(r.round, sched, r.now)
+		if !reflect.DeepEqual(skipped, r.skipped) {
+			t.Fatalf("round %d: skipped %v, want %v", r.round, skipped, r.skipped)
+		}
+	}
+
+	// After a reconnect, the client may have missed announcements.
+	g.reset()
+	if skipped := g.observe(200, sched, at(200)); skipped != nil {
+		t.Fatalf("skipped %v after reset", skipped)
+	}
+}
@@ -0,0 +1,785 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package mixnet
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/nacl/box"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"vuvuzela.io/alpenhorn/errors"
+	"vuvuzela.io/alpenhorn/log"
+	"vuvuzela.io/concurrency"
+	"vuvuzela.io/crypto/shuffle"
+	pb "vuvuzela.io/vuvuzela/mixnet/convopb"
+)
+
+// Shuffle auditing uses random partial checking (Jakobsson, Juels, and
+// Rivest, USENIX Security 2002). When a round closes, every mixer signs a
+// Commitment to the batch of onions it received and the batch it sent to
+// the next mixer. Once the round is over, the commitments of the whole
+// chain determine a random challenge, and each mixer reveals the links
+// selected by the challenge: the input onion, the shared key that opens
+// it, and Merkle proofs that place the onion and its decryption in the
+// committed batches. A mixer that drops or replaces a message is caught
+// with probability 1/2 per message.
+//
+// Mixers are paired up along the chain: for each message passing between
+// mixers 2i and 2i+1, the challenge bit selects whether mixer 2i reveals
+// where the message came from or mixer 2i+1 reveals where it went, but
+// never both. So every message keeps at least one hidden link. The last
+// mixer does not shuffle and reveals nothing. Auditing requires a chain
+// of at least three mixers; otherwise the revealed links would connect
+// senders to their messages.
+//
+// Mixers also account for every onion they discarded. An onion that
+// failed to decrypt is revealed with its shared key, which shows that it
+// does not open. A replay (see replay.go) has the same shared key as the
+// onion it copies, so revealing its key would let anyone peel the
+// original; instead the mixer points to an identical onion in the same
+// batch, with Merkle proofs for both. A replay of an onion from an
+// earlier batch that used the same onion key can not be proven this way;
+// the verifier reports these but can not tell them apart from a mixer
+// that drops real messages while claiming they were replayed. Similarly,
+// noise claims are only checked against the amount of noise the mixer
+// committed to.
+
+// maxAudits is the number of rounds a mixer keeps audit state for.
+const maxAudits = 16
+
+// Commitment is a mixer's signed commitment to its input and output
+// batches for a round.
+type Commitment struct {
+	Service  string
+	Round    uint32
+	Position uint32
+
+	// NumInputs and InputRoot commit to the onions the mixer received,
+	// in the order it received them.
+	NumInputs uint32
+	InputRoot []byte
+
+	// NumOutputs and OutputRoot commit to the onions the mixer sent
+	// to the next mixer, in the order it sent them. The last mixer
+	// commits to its inputs only.
+	NumOutputs uint32
+	OutputRoot []byte
+
+	// NumNoise is the number of noise onions among the outputs.
+	NumNoise uint32
+
+	// Signature is the mixer's signature of the signing message;
+	// it is not included in the signing message.
+	Signature []byte
+}
+
+func (c Commitment) SigningMessage() []byte {
+	c.Signature = nil
+	buf := new(bytes.Buffer)
+	buf.WriteString("MixCommitment")
+	json.NewEncoder(buf).Encode(c)
+	return buf.Bytes()
+}
+
+func (c Commitment) Proto() *pb.MixCommitment {
+	return &pb.MixCommitment{
+		Service:    c.Service,
+		Round:      c.Round,
+		Position:   c.Position,
+		NumInputs:  c.NumInputs,
+		InputRoot:  c.InputRoot,
+		NumOutputs: c.NumOutputs,
+		OutputRoot: c.OutputRoot,
+		NumNoise:   c.NumNoise,
+		Signature:  c.Signature,
+	}
+}
+
+func (c *Commitment) FromProto(pbc *pb.MixCommitment) error {
+	if len(pbc.InputRoot) != sha256.Size {
+		return errors.New("invalid input root in MixCommitment protobuf: %#v", pbc.InputRoot)
+	}
+	if len(pbc.OutputRoot) != 0 && len(pbc.OutputRoot) != sha256.Size {
+		return errors.New("invalid output root in MixCommitment protobuf: %#v", pbc.OutputRoot)
+	}
+	c.Service = pbc.Service
+	c.Round = pbc.Round
+	c.Position = pbc.Position
+	c.NumInputs = pbc.NumInputs
+	c.InputRoot = pbc.InputRoot
+	c.NumOutputs = pbc.NumOutputs
+	c.OutputRoot = pbc.OutputRoot
+	c.NumNoise = pbc.NumNoise
+	c.Signature = pbc.Signature
+	return nil
+}
+
+// verifyCommitments checks the signatures on the chain's commitments
+// and that each mixer's outputs are the next mixer's inputs.
+func verifyCommitments(chain []PublicServerConfig, service string, round uint32, commitments []Commitment) error {
+	if len(chain) < 3 {
+		return errors.New("shuffle audits need at least 3 mixers, got %d", len(chain))
+	}
+	if len(commitments) != len(chain) {
+		return errors.New("want %d commitments, got %d", len(chain), len(commitments))
+	}
+	for i, c := range commitments {
+		if c.Service != service || c.Round != round || c.Position != uint32(i) {
+			return errors.New("commitment %d is for service %q round %d position %d", i, c.Service, c.Round, c.Position)
+		}
+		if !ed25519.Verify(chain[i].Key, c.SigningMessage(), c.Signature) {
+			return errors.New("invalid signature on commitment from mixer %d", i)
+		}
+		if i == len(chain)-1 {
+			if c.NumOutputs != 0 || len(c.OutputRoot) != 0 {
+				return errors.New("last mixer committed to outputs")
+			}
+			continue
+		}
+		if c.NumNoise > c.NumOutputs || c.NumOutputs-c.NumNoise > c.NumInputs {
+			return errors.New("mixer %d committed to %d outputs with %d noise for %d inputs", i, c.NumOutputs, c.NumNoise, c.NumInputs)
+		}
+		next := commitments[i+1]
+		if c.NumOutputs != next.NumInputs || !bytes.Equal(c.OutputRoot, next.InputRoot) {
+			return errors.New("outputs of mixer %d do not match inputs of mixer %d", i, i+1)
+		}
+	}
+	return nil
+}
+
+// auditChallenge derives the challenge bits from the chain's commitments.
+// Signatures are deterministic, so the mixers can not influence the
+// challenge except by changing the batches they committed to.
+type auditChallenge [32]byte
+
+func newAuditChallenge(commitments []Commitment) *auditChallenge {
+	h := sha256.New()
+	h.Write([]byte("RevealLinks"))
+	for _, c := range commitments {
+		h.Write(c.Signature)
+	}
+	c := new(auditChallenge)
+	h.Sum(c[:0])
+	return c
+}
+
+// bits returns the challenge bits for the n messages that pass between
+// mixer batch and mixer batch+1.
+func (c *auditChallenge) bits(batch int, n int) []bool {
+	bits := make([]bool, n)
+	var block [40]byte
+	copy(block[0:32], c[:])
+	binary.BigEndian.PutUint32(block[32:36], uint32(batch))
+	for i := 0; i < n; i += 256 {
+		binary.BigEndian.PutUint32(block[36:40], uint32(i/256))
+		sum := sha256.Sum256(block[:])
+		for j := 0; j < 256 && i+j < n; j++ {
+			bits[i+j] = sum[j/8]&(1<<uint(j%8)) != 0
+		}
+	}
+	return bits
+}
+
+// revealsOutputs reports whether the mixer at pos answers challenges by
+// revealing where its outputs came from (even positions) rather than
+// where its inputs went (odd positions).
+func revealsOutputs(pos int) bool {
+	return pos%2 == 0
+}
+
+// roundAudit is the state a mixer keeps to answer a round's challenge.
+type roundAudit struct {
+	commitment Commitment
+	chain      []PublicServerConfig
+	myPos      int
+
+	inputs     [][]byte
+	sharedKeys [][32]byte
+	inputTree  *merkleTree
+	outputTree *merkleTree
+
+	// forward maps input indexes to output indexes; -1 means discarded.
+	forward []int
+	// twins maps discarded inputs to an identical input, or to -1.
+	twins map[int]int
+	// backward maps output indexes to input indexes; -1 means noise.
+	backward []int
+}
+
+// commitRound commits to the round's batches. It must be called after
+// the outgoing batch is shuffled with shuffler. The last server calls
+// it with a nil shuffler.
+func (srv *Server) commitRound(st *roundState, numNonNoise int, shuffler shuffle.Shuffler, outgoing [][]byte) {
+	audit := &roundAudit{
+		chain:      st.chain,
+		myPos:      st.myPos,
+		inputs:     st.rawIncoming,
+		sharedKeys: st.sharedKeys,
+		inputTree:  newMerkleTree(st.rawIncoming),
+	}
+	st.rawIncoming = nil
+	st.audited = true
+
+	audit.commitment = Commitment{
+		Service:   st.settings.Service,
+		Round:     st.settings.Round,
+		Position:  uint32(st.myPos),
+		NumInputs: uint32(len(audit.inputs)),
+		InputRoot: audit.inputTree.Root(),
+	}
+
+	if shuffler != nil {
+		// Recover the permutation by shuffling the indexes.
+		indexes := make([][]byte, len(outgoing))
+		buf := make([]byte, 4*len(outgoing))
+		for i := range indexes {
+			indexes[i] = buf[4*i : 4*i+4]
+			binary.BigEndian.PutUint32(indexes[i], uint32(i))
+		}
+		shuffler.Shuffle(indexes)
+
+		// st.incomingIndex maps inputs to their position before the shuffle.
+		unshuffled := make([]int, numNonNoise)
+		audit.forward = make([]int, len(audit.inputs))
+		for i, v := range st.incomingIndex {
+			audit.forward[i] = -1
+			if v > -1 {
+				unshuffled[v] = i
+			}
+		}
+		audit.backward = make([]int, len(outgoing))
+		for j, index := range indexes {
+			v := int(binary.BigEndian.Uint32(index))
+			if v < numNonNoise {
+				audit.backward[j] = unshuffled[v]
+				audit.forward[unshuffled[v]] = j
+			} else {
+				audit.backward[j] = -1
+			}
+		}
+
+		audit.twins = findTwins(audit.inputs, audit.forward)
+
+		audit.outputTree = newMerkleTree(outgoing)
+		audit.commitment.NumOutputs = uint32(len(outgoing))
+		audit.commitment.OutputRoot = audit.outputTree.Root()
+		audit.commitment.NumNoise = uint32(len(outgoing) - numNonNoise)
+	}
+
+	audit.commitment.Signature = ed25519.Sign(srv.SigningKey, audit.commitment.SigningMessage())
+
+	key := serviceRound{st.settings.Service, st.settings.Round}
+	srv.auditsMu.Lock()
+	if srv.audits == nil {
+		srv.audits = make(map[serviceRound]*roundAudit)
+	}
+	if _, ok := srv.audits[key]; !ok {
+		srv.auditOrder = append(srv.auditOrder, key)
+	}
+	srv.audits[key] = audit
+	for len(srv.auditOrder) > maxAudits {
+		srv.audits[srv.auditOrder[0]].zeroize()
+		delete(srv.audits, srv.auditOrder[0])
+		srv.auditOrder = srv.auditOrder[1:]
+	}
+	srv.auditsMu.Unlock()
+}
+
+func (srv *Server) getAudit(service string, round uint32) (*roundAudit, error) {
+	srv.auditsMu.Lock()
+	audit, ok := srv.audits[serviceRound{service, round}]
+	srv.auditsMu.Unlock()
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "no commitment for round %d", round)
+	}
+	return audit, nil
+}
+
+func (srv *Server) deleteAudit(service string, round uint32) {
+	key := serviceRound{service, round}
+	srv.auditsMu.Lock()
+	if audit, ok := srv.audits[key]; ok {
+		audit.zeroize()
+	}
+	delete(srv.audits, key)
+	for i, k := range srv.auditOrder {
+		if k == key {
+			srv.auditOrder = append(srv.auditOrder[:i], srv.auditOrder[i+1:]...)
+			break
+		}
+	}
+	srv.auditsMu.Unlock()
+}
+
+// zeroize erases the shared keys that the audit would have revealed.
+func (a *roundAudit) zeroize() {
+	for i := range a.sharedKeys {
+		a.sharedKeys[i] = [32]byte{}
+	}
+}
+
+// findTwins maps each discarded input to an identical input, preferring
+// one that was mixed, or to -1 if there is none.
+func findTwins(inputs [][]byte, forward []int) map[int]int {
+	first := make(map[string]int)
+	for i, onion := range inputs {
+		j, ok := first[string(onion)]
+		if !ok || forward[j] == -1 && forward[i] != -1 {
+			first[string(onion)] = i
+		}
+	}
+	twins := make(map[int]int)
+	for i, j := range forward {
+		if j != -1 {
+			continue
+		}
+		twins[i] = -1
+		if k := first[string(inputs[i])]; k != i {
+			twins[i] = k
+		}
+	}
+	return twins
+}
+
+// discarded returns the link that accounts for a discarded input. It
+// reveals the shared key only if the onion does not decrypt with it, so
+// that a replay never reveals the key of the onion it copies.
+func (a *roundAudit) discarded(input int, nonce *[24]byte) *pb.RevealedLink {
+	link := &pb.RevealedLink{
+		Input:      uint32(input),
+		Discarded:  true,
+		Onion:      a.inputs[input],
+		InputProof: a.inputTree.Proof(input),
+	}
+	if twin := a.twins[input]; twin != -1 {
+		link.Replay = true
+		link.Twin = uint32(twin)
+		link.TwinProof = a.inputTree.Proof(twin)
+		return link
+	}
+	onion := a.inputs[input]
+	if len(onion) > 32 {
+		if _, ok := box.OpenAfterPrecomputation(nil, onion[32:], nonce, &a.sharedKeys[input]); ok {
+			// A replay of an onion from an earlier batch.
+			link.Replay = true
+			return link
+		}
+	}
+	link.SharedKey = a.sharedKeys[input][:]
+	return link
+}
+
+func (a *roundAudit) link(input, output int) *pb.RevealedLink {
+	return &pb.RevealedLink{
+		Input:       uint32(input),
+		Output:      uint32(output),
+		Onion:       a.inputs[input],
+		SharedKey:   a.sharedKeys[input][:],
+		InputProof:  a.inputTree.Proof(input),
+		OutputProof: a.outputTree.Proof(output),
+	}
+}
+
+// reveal returns the links selected by the challenge, along with every
+// discarded onion.
+func (a *roundAudit) reveal(challenge *auditChallenge) []*pb.RevealedLink {
+	links := make([]*pb.RevealedLink, 0, len(a.inputs)/2)
+	nonce := ForwardNonce(a.commitment.Round)
+	for i, j := range a.forward {
+		if j == -1 {
+			links = append(links, a.discarded(i, nonce))
+		}
+	}
+
+	if revealsOutputs(a.myPos) {
+		bits := challenge.bits(a.myPos, len(a.backward))
+		for j, bit := range bits {
+			if bit {
+				continue
+			}
+			if a.backward[j] == -1 {
+				links = append(links, &pb.RevealedLink{
+					Output: uint32(j),
+					Noise:  true,
+				})
+			} else {
+				links = append(links, a.link(a.backward[j], j))
+			}
+		}
+	} else {
+		bits := challenge.bits(a.myPos-1, len(a.forward))
+		for i, bit := range bits {
+			if bit && a.forward[i] != -1 {
+				links = append(links, a.link(i, a.forward[i]))
+			}
+		}
+	}
+	return links
+}
+
+// GetCommitment is an RPC used by the coordinator to fetch a mixer's
+// commitment for a round. Mixers only commit to rounds if AuditShuffles
+// is enabled.
+func (srv *Server) GetCommitment(ctx context.Context, req *pb.GetCommitmentRequest) (*pb.MixCommitment, error) {
+	if err := srv.auth(ctx, srv.CoordinatorKey); err != nil {
+		return nil, err
+	}
+
+	audit, err := srv.getAudit(req.Service, req.Round)
+	if err != nil {
+		return nil, err
+	}
+	return audit.commitment.Proto(), nil
+}
+
+// RevealLinks is an RPC used by the coordinator to challenge a mixer
+// after a round. The request carries the commitments of the whole chain,
+// which determine the challenge. The mixer forgets the round's audit
+// state after answering.
+func (srv *Server) RevealLinks(req *pb.RevealLinksRequest, stream pb.Mixnet_RevealLinksServer) error {
+	if err := srv.auth(stream.Context(), srv.CoordinatorKey); err != nil {
+		return err
+	}
+
+	audit, err := srv.getAudit(req.Service, req.Round)
+	if err != nil {
+		return err
+	}
+
+	commitments := make([]Commitment, len(req.Commitments))
+	for i, c := range req.Commitments {
+		if err := commitments[i].FromProto(c); err != nil {
+			return status.Errorf(codes.InvalidArgument, "commitment %d: %s", i, err)
+		}
+	}
+	if err := verifyCommitments(audit.chain, req.Service, req.Round, commitments); err != nil {
+		return status.Errorf(codes.InvalidArgument, "bad commitments: %s", err)
+	}
+	if !bytes.Equal(commitments[audit.myPos].Signature, audit.commitment.Signature) {
+		return status.Errorf(codes.InvalidArgument, "bad commitments: wrong commitment at position %d", audit.myPos)
+	}
+
+	var links []*pb.RevealedLink
+	if audit.myPos < len(audit.chain)-1 {
+		links = audit.reveal(newAuditChallenge(commitments))
+	}
+	log.WithFields(log.Fields{"rpc": "RevealLinks", "service": req.Service, "round": req.Round, "links": len(links)}).Info()
+
+	spans := concurrency.Spans(len(links), 16)
+	for _, span := range spans {
+		err := stream.Send(&pb.RevealLinksResponse{
+			Links: links[span.Start : span.Start+span.Count],
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	srv.deleteAudit(req.Service, req.Round)
+	return nil
+}
+
+// AuditEvidence is the record of a shuffle audit. It can be published
+// and checked by anyone who trusts the mixers' signing keys.
+type AuditEvidence struct {
+	Service string
+	Round   uint32
+	Chain   []PublicServerConfig
+
+	// InputRoot is the root of the batch that the coordinator sent
+	// to the first mixer. It is optional; if set, Verify checks it
+	// against the first mixer's commitment.
+	InputRoot []byte
+
+	Commitments []Commitment
+
+	// Links[i] are the links revealed by mixer i.
+	Links [][]*pb.RevealedLink
+}
+
+// AuditResult is the outcome of auditing a single mixer.
+type AuditResult struct {
+	Position int
+
+	// Revealed is the number of input-to-output links that were checked.
+	Revealed int
+
+	// Noise is the number of revealed outputs claimed to be noise.
+	Noise int
+
+	// Invalid is the number of discarded onions that fail to decrypt.
+	Invalid int
+
+	// Duplicates is the number of discarded onions that are copies of
+	// another onion in the same batch.
+	Duplicates int
+
+	// Replays is the number of discarded onions that the mixer claims
+	// were replays of onions from an earlier batch. These can not be
+	// checked.
+	Replays int
+
+	// Error describes why the mixer failed the audit; it is empty
+	// if the mixer passed.
+	Error string
+}
+
+func (r AuditResult) OK() bool {
+	return r.Error == ""
+}
+
+// Audit fetches the commitments from the chain and challenges every
+// mixer to reveal its links for the round.
+func (c *Client) Audit(ctx context.Context, chain []PublicServerConfig, service string, round uint32) (*AuditEvidence, error) {
+	conns := make([]pb.MixnetClient, len(chain))
+	for i, server := range chain {
+		conn, err := c.getConn(server)
+		if err != nil {
+			return nil, err
+		}
+		conns[i] = conn
+	}
+
+	commitReq := &pb.GetCommitmentRequest{
+		Service: service,
+		Round:   round,
+	}
+	commitments := make([]Commitment, len(chain))
+	errs := make(chan error, 1)
+	for i, server := range chain {
+		go func(i int, server PublicServerConfig) {
+			response, err := conns[i].GetCommitment(ctx, commitReq)
+			if err != nil {
+				errs <- errors.Wrap(err, "server %s: GetCommitment", server.Address)
+				return
+			}
+			if err := commitments[i].FromProto(response); err != nil {
+				errs <- errors.Wrap(err, "server %s", server.Address)
+				return
+			}
+			errs <- nil
+		}(i, server)
+	}
+	var commitErr error
+	for i := 0; i < len(chain); i++ {
+		err := <-errs
+		if err != nil && commitErr == nil {
+			commitErr = err
+		}
+	}
+	if commitErr != nil {
+		return nil, commitErr
+	}
+	if err := verifyCommitments(chain, service, round, commitments); err != nil {
+		return nil, err
+	}
+
+	revealReq := &pb.RevealLinksRequest{
+		Service:     service,
+		Round:       round,
+		Commitments: make([]*pb.MixCommitment, len(commitments)),
+	}
+	for i := range commitments {
+		revealReq.Commitments[i] = commitments[i].Proto()
+	}
+
+	links := make([][]*pb.RevealedLink, len(chain))
+	for i, server := range chain {
+		go func(i int, server PublicServerConfig) {
+			stream, err := conns[i].RevealLinks(ctx, revealReq)
+			if err != nil {
+				errs <- errors.Wrap(err, "server %s: RevealLinks", server.Address)
+				return
+			}
+			for {
+				resp, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					errs <- errors.Wrap(err, "server %s: RevealLinks", server.Address)
+					return
+				}
+				links[i] = append(links[i], resp.Links...)
+			}
+			errs <- nil
+		}(i, server)
+	}
+	var revealErr error
+	for i := 0; i < len(chain); i++ {
+		err := <-errs
+		if err != nil && revealErr == nil {
+			revealErr = err
+		}
+	}
+	if revealErr != nil {
+		return nil, revealErr
+	}
+
+	return &AuditEvidence{
+		Service:     service,
+		Round:       round,
+		Chain:       chain,
+		Commitments: commitments,
+		Links:       links,
+	}, nil
+}
+
+// Verify checks the evidence and returns a result for every mixer
+// on the chain. It returns an error if the commitments themselves
+// are invalid, in which case the evidence says nothing about the
+// individual mixers.
+func (e *AuditEvidence) Verify() ([]AuditResult, error) {
+	if err := verifyCommitments(e.Chain, e.Service, e.Round, e.Commitments); err != nil {
+		return nil, err
+	}
+	if e.InputRoot != nil && !bytes.Equal(e.InputRoot, e.Commitments[0].InputRoot) {
+		return nil, errors.New("first mixer's input root does not match the coordinator's batch")
+	}
+	if len(e.Links) != len(e.Chain) {
+		return nil, errors.New("want links from %d mixers, got %d", len(e.Chain), len(e.Links))
+	}
+
+	challenge := newAuditChallenge(e.Commitments)
+	results := make([]AuditResult, len(e.Chain))
+	for pos := range e.Chain {
+		results[pos] = e.verifyMixer(challenge, pos)
+	}
+	return results, nil
+}
+
+func (e *AuditEvidence) verifyMixer(challenge *auditChallenge, pos int) AuditResult {
+	result := AuditResult{
+		Position: pos,
+	}
+	fail := func(format string, args ...interface{}) AuditResult {
+		result.Error = errors.New(format, args...).Error()
+		return result
+	}
+
+	c := e.Commitments[pos]
+	links := e.Links[pos]
+	if pos == len(e.Chain)-1 {
+		if len(links) > 0 {
+			return fail("last mixer revealed %d links", len(links))
+		}
+		return result
+	}
+
+	var bits []bool
+	if revealsOutputs(pos) {
+		bits = challenge.bits(pos, int(c.NumOutputs))
+	} else {
+		bits = challenge.bits(pos-1, int(c.NumInputs))
+	}
+
+	nonce := ForwardNonce(e.Round)
+	seenInputs := make(map[uint32]bool)
+	seenOutputs := make(map[uint32]bool)
+	for _, link := range links {
+		if link.Noise {
+			if !revealsOutputs(pos) || link.Discarded {
+				return fail("unexpected noise claim for output %d", link.Output)
+			}
+			if link.Output >= c.NumOutputs || seenOutputs[link.Output] {
+				return fail("bad noise claim for output %d", link.Output)
+			}
+			seenOutputs[link.Output] = true
+			result.Noise++
+			continue
+		}
+
+		if link.Input >= c.NumInputs || seenInputs[link.Input] {
+			return fail("bad link for input %d", link.Input)
+		}
+		seenInputs[link.Input] = true
+		if !verifyMerkleProof(c.InputRoot, int(c.NumInputs), int(link.Input), link.Onion, link.InputProof) {
+			return fail("invalid proof for input %d", link.Input)
+		}
+
+		if link.Replay {
+			if !link.Discarded || len(link.SharedKey) != 0 {
+				return fail("bad replay claim for input %d", link.Input)
+			}
+			if len(link.TwinProof) == 0 {
+				result.Replays++
+				continue
+			}
+			if link.Twin >= c.NumInputs || link.Twin == link.Input {
+				return fail("bad twin %d for input %d", link.Twin, link.Input)
+			}
+			if !verifyMerkleProof(c.InputRoot, int(c.NumInputs), int(link.Twin), link.Onion, link.TwinProof) {
+				return fail("input %d is not a copy of input %d", link.Input, link.Twin)
+			}
+			result.Duplicates++
+			continue
+		}
+
+		if len(link.SharedKey) != 32 {
+			return fail("invalid shared key for input %d", link.Input)
+		}
+		var msg []byte
+		var ok bool
+		if len(link.Onion) > 32 {
+			key := new([32]byte)
+			copy(key[:], link.SharedKey)
+			msg, ok = box.OpenAfterPrecomputation(nil, link.Onion[32:], nonce, key)
+		}
+
+		if link.Discarded {
+			if ok {
+				return fail("discarded input %d decrypts with the revealed key", link.Input)
+			}
+			result.Invalid++
+			continue
+		}
+
+		if !ok {
+			return fail("input %d does not decrypt with the revealed key", link.Input)
+		}
+		if link.Output >= c.NumOutputs || seenOutputs[link.Output] {
+			return fail("bad link from input %d to output %d", link.Input, link.Output)
+		}
+		seenOutputs[link.Output] = true
+		if !verifyMerkleProof(c.OutputRoot, int(c.NumOutputs), int(link.Output), msg, link.OutputProof) {
+			return fail("input %d does not decrypt to output %d", link.Input, link.Output)
+		}
+		challenged := bits[link.Input]
+		if revealsOutputs(pos) {
+			challenged = !bits[link.Output]
+		}
+		if !challenged {
+			return fail("revealed unchallenged link from input %d to output %d", link.Input, link.Output)
+		}
+		result.Revealed++
+	}
+
+	discarded := result.Invalid + result.Duplicates + result.Replays
+	if uint32(discarded) != c.NumInputs-(c.NumOutputs-c.NumNoise) {
+		return fail("revealed %d discarded onions, but committed to %d", discarded, c.NumInputs-(c.NumOutputs-c.NumNoise))
+	}
+	if uint32(result.Noise) > c.NumNoise {
+		return fail("claimed %d noise outputs, but committed to %d", result.Noise, c.NumNoise)
+	}
+
+	for i, bit := range bits {
+		if revealsOutputs(pos) && !bit && !seenOutputs[uint32(i)] {
+			return fail("did not reveal challenged output %d", i)
+		}
+		if !revealsOutputs(pos) && bit && !seenInputs[uint32(i)] {
+			return fail("did not reveal challenged input %d", i)
+		}
+	}
+
+	return result
+}
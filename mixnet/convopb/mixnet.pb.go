@@ -24,6 +24,14 @@
 		GetOnionsRequest
 		GetOnionsResponse
 		DeleteRoundRequest
+		GetCommitmentRequest
+		MixCommitment
+		RevealLinksRequest
+		RevealedLink
+		RevealLinksResponse
+		StatusRequest
+		StatusResponse
+		RoundStatus
 */
 package convopb
 
@@ -352,6 +360,350 @@ func (m *DeleteRoundRequest) GetRound() uint32 {
 	return 0
 }
 
+type GetCommitmentRequest struct {
+	Service string `protobuf:"bytes,1,opt,name=service,proto3" json:"service,omitempty"`
+	Round   uint32 `protobuf:"varint,2,opt,name=round,proto3" json:"round,omitempty"`
+}
+
+func (m *GetCommitmentRequest) Reset()                    { *m = GetCommitmentRequest{} }
+func (m *GetCommitmentRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetCommitmentRequest) ProtoMessage()               {}
+func (*GetCommitmentRequest) Descriptor() ([]byte, []int) { return fileDescriptorMixnet, []int{13} }
+
+func (m *GetCommitmentRequest) GetService() string {
+	if m != nil {
+		return m.Service
+	}
+	return ""
+}
+
+func (m *GetCommitmentRequest) GetRound() uint32 {
+	if m != nil {
+		return m.Round
+	}
+	return 0
+}
+
+type MixCommitment struct {
+	Service    string `protobuf:"bytes,1,opt,name=service,proto3" json:"service,omitempty"`
+	Round      uint32 `protobuf:"varint,2,opt,name=round,proto3" json:"round,omitempty"`
+	Position   uint32 `protobuf:"varint,3,opt,name=position,proto3" json:"position,omitempty"`
+	NumInputs  uint32 `protobuf:"varint,4,opt,name=num_inputs,json=numInputs,proto3" json:"num_inputs,omitempty"`
+	InputRoot  []byte `protobuf:"bytes,5,opt,name=input_root,json=inputRoot,proto3" json:"input_root,omitempty"`
+	NumOutputs uint32 `protobuf:"varint,6,opt,name=num_outputs,json=numOutputs,proto3" json:"num_outputs,omitempty"`
+	OutputRoot []byte `protobuf:"bytes,7,opt,name=output_root,json=outputRoot,proto3" json:"output_root,omitempty"`
+	NumNoise   uint32 `protobuf:"varint,8,opt,name=num_noise,json=numNoise,proto3" json:"num_noise,omitempty"`
+	Signature  []byte `protobuf:"bytes,9,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (m *MixCommitment) Reset()                    { *m = MixCommitment{} }
+func (m *MixCommitment) String() string            { return proto.CompactTextString(m) }
+func (*MixCommitment) ProtoMessage()               {}
+func (*MixCommitment) Descriptor() ([]byte, []int) { return fileDescriptorMixnet, []int{14} }
+
+func (m *MixCommitment) GetService() string {
+	if m != nil {
+		return m.Service
+	}
+	return ""
+}
+
+func (m *MixCommitment) GetRound() uint32 {
+	if m != nil {
+		return m.Round
+	}
+	return 0
+}
+
+func (m *MixCommitment) GetPosition() uint32 {
+	if m != nil {
+		return m.Position
+	}
+	return 0
+}
+
+func (m *MixCommitment) GetNumInputs() uint32 {
+	if m != nil {
+		return m.NumInputs
+	}
+	return 0
+}
+
+func (m *MixCommitment) GetInputRoot() []byte {
+	if m != nil {
+		return m.InputRoot
+	}
+	return nil
+}
+
+func (m *MixCommitment) GetNumOutputs() uint32 {
+	if m != nil {
+		return m.NumOutputs
+	}
+	return 0
+}
+
+func (m *MixCommitment) GetOutputRoot() []byte {
+	if m != nil {
+		return m.OutputRoot
+	}
+	return nil
+}
+
+func (m *MixCommitment) GetNumNoise() uint32 {
+	if m != nil {
+		return m.NumNoise
+	}
+	return 0
+}
+
+func (m *MixCommitment) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+type RevealLinksRequest struct {
+	Service     string           `protobuf:"bytes,1,opt,name=service,proto3" json:"service,omitempty"`
+	Round       uint32           `protobuf:"varint,2,opt,name=round,proto3" json:"round,omitempty"`
+	Commitments []*MixCommitment `protobuf:"bytes,3,rep,name=commitments" json:"commitments,omitempty"`
+}
+
+func (m *RevealLinksRequest) Reset()                    { *m = RevealLinksRequest{} }
+func (m *RevealLinksRequest) String() string            { return proto.CompactTextString(m) }
+func (*RevealLinksRequest) ProtoMessage()               {}
+func (*RevealLinksRequest) Descriptor() ([]byte, []int) { return fileDescriptorMixnet, []int{15} }
+
+func (m *RevealLinksRequest) GetService() string {
+	if m != nil {
+		return m.Service
+	}
+	return ""
+}
+
+func (m *RevealLinksRequest) GetRound() uint32 {
+	if m != nil {
+		return m.Round
+	}
+	return 0
+}
+
+func (m *RevealLinksRequest) GetCommitments() []*MixCommitment {
+	if m != nil {
+		return m.Commitments
+	}
+	return nil
+}
+
+type RevealedLink struct {
+	Input       uint32   `protobuf:"varint,1,opt,name=input,proto3" json:"input,omitempty"`
+	Output      uint32   `protobuf:"varint,2,opt,name=output,proto3" json:"output,omitempty"`
+	Discarded   bool     `protobuf:"varint,3,opt,name=discarded,proto3" json:"discarded,omitempty"`
+	Noise       bool     `protobuf:"varint,4,opt,name=noise,proto3" json:"noise,omitempty"`
+	Onion       []byte   `protobuf:"bytes,5,opt,name=onion,proto3" json:"onion,omitempty"`
+	SharedKey   []byte   `protobuf:"bytes,6,opt,name=shared_key,json=sharedKey,proto3" json:"shared_key,omitempty"`
+	InputProof  [][]byte `protobuf:"bytes,7,rep,name=input_proof,json=inputProof" json:"input_proof,omitempty"`
+	OutputProof [][]byte `protobuf:"bytes,8,rep,name=output_proof,json=outputProof" json:"output_proof,omitempty"`
+	Replay      bool     `protobuf:"varint,9,opt,name=replay,proto3" json:"replay,omitempty"`
+	Twin        uint32   `protobuf:"varint,10,opt,name=twin,proto3" json:"twin,omitempty"`
+	TwinProof   [][]byte `protobuf:"bytes,11,rep,name=twin_proof,json=twinProof" json:"twin_proof,omitempty"`
+}
+
+func (m *RevealedLink) Reset()                    { *m = RevealedLink{} }
+func (m *RevealedLink) String() string            { return proto.CompactTextString(m) }
+func (*RevealedLink) ProtoMessage()               {}
+func (*RevealedLink) Descriptor() ([]byte, []int) { return fileDescriptorMixnet, []int{16} }
+
+func (m *RevealedLink) GetInput() uint32 {
+	if m != nil {
+		return m.Input
+	}
+	return 0
+}
+
+func (m *RevealedLink) GetOutput() uint32 {
+	if m != nil {
+		return m.Output
+	}
+	return 0
+}
+
+func (m *RevealedLink) GetDiscarded() bool {
+	if m != nil {
+		return m.Discarded
+	}
+	return false
+}
+
+func (m *RevealedLink) GetNoise() bool {
+	if m != nil {
+		return m.Noise
+	}
+	return false
+}
+
+func (m *RevealedLink) GetOnion() []byte {
+	if m != nil {
+		return m.Onion
+	}
+	return nil
+}
+
+func (m *RevealedLink) GetSharedKey() []byte {
+	if m != nil {
+		return m.SharedKey
+	}
+	return nil
+}
+
+func (m *RevealedLink) GetInputProof() [][]byte {
+	if m != nil {
+		return m.InputProof
+	}
+	return nil
+}
+
+func (m *RevealedLink) GetOutputProof() [][]byte {
+	if m != nil {
+		return m.OutputProof
+	}
+	return nil
+}
+
+func (m *RevealedLink) GetReplay() bool {
+	if m != nil {
+		return m.Replay
+	}
+	return false
+}
+
+func (m *RevealedLink) GetTwin() uint32 {
+	if m != nil {
+		return m.Twin
+	}
+	return 0
+}
+
+func (m *RevealedLink) GetTwinProof() [][]byte {
+	if m != nil {
+		return m.TwinProof
+	}
+	return nil
+}
+
+type RevealLinksResponse struct {
+	Links []*RevealedLink `protobuf:"bytes,1,rep,name=links" json:"links,omitempty"`
+}
+
+func (m *RevealLinksResponse) Reset()                    { *m = RevealLinksResponse{} }
+func (m *RevealLinksResponse) String() string            { return proto.CompactTextString(m) }
+func (*RevealLinksResponse) ProtoMessage()               {}
+func (*RevealLinksResponse) Descriptor() ([]byte, []int) { return fileDescriptorMixnet, []int{17} }
+
+func (m *RevealLinksResponse) GetLinks() []*RevealedLink {
+	if m != nil {
+		return m.Links
+	}
+	return nil
+}
+
+type StatusRequest struct {
+}
+
+func (m *StatusRequest) Reset()                    { *m = StatusRequest{} }
+func (m *StatusRequest) String() string            { return proto.CompactTextString(m) }
+func (*StatusRequest) ProtoMessage()               {}
+func (*StatusRequest) Descriptor() ([]byte, []int) { return fileDescriptorMixnet, []int{18} }
+
+type StatusResponse struct {
+	Version         string         `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	Services        []string       `protobuf:"bytes,2,rep,name=services" json:"services,omitempty"`
+	Rounds          []*RoundStatus `protobuf:"bytes,3,rep,name=rounds" json:"rounds,omitempty"`
+	DecryptionQueue uint32         `protobuf:"varint,4,opt,name=decryption_queue,json=decryptionQueue,proto3" json:"decryption_queue,omitempty"`
+	UptimeSeconds   uint64         `protobuf:"varint,5,opt,name=uptime_seconds,json=uptimeSeconds,proto3" json:"uptime_seconds,omitempty"`
+	Draining        bool           `protobuf:"varint,6,opt,name=draining,proto3" json:"draining,omitempty"`
+}
+
+func (m *StatusResponse) Reset()                    { *m = StatusResponse{} }
+func (m *StatusResponse) String() string            { return proto.CompactTextString(m) }
+func (*StatusResponse) ProtoMessage()               {}
+func (*StatusResponse) Descriptor() ([]byte, []int) { return fileDescriptorMixnet, []int{19} }
+
+func (m *StatusResponse) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *StatusResponse) GetServices() []string {
+	if m != nil {
+		return m.Services
+	}
+	return nil
+}
+
+func (m *StatusResponse) GetRounds() []*RoundStatus {
+	if m != nil {
+		return m.Rounds
+	}
+	return nil
+}
+
+func (m *StatusResponse) GetDecryptionQueue() uint32 {
+	if m != nil {
+		return m.DecryptionQueue
+	}
+	return 0
+}
+
+func (m *StatusResponse) GetUptimeSeconds() uint64 {
+	if m != nil {
+		return m.UptimeSeconds
+	}
+	return 0
+}
+
+func (m *StatusResponse) GetDraining() bool {
+	if m != nil {
+		return m.Draining
+	}
+	return false
+}
+
+type RoundStatus struct {
+	Service string `protobuf:"bytes,1,opt,name=service,proto3" json:"service,omitempty"`
+	Round   uint32 `protobuf:"varint,2,opt,name=round,proto3" json:"round,omitempty"`
+	Phase   string `protobuf:"bytes,3,opt,name=phase,proto3" json:"phase,omitempty"`
+}
+
+func (m *RoundStatus) Reset()                    { *m = RoundStatus{} }
+func (m *RoundStatus) String() string            { return proto.CompactTextString(m) }
+func (*RoundStatus) ProtoMessage()               {}
+func (*RoundStatus) Descriptor() ([]byte, []int) { return fileDescriptorMixnet, []int{20} }
+
+func (m *RoundStatus) GetService() string {
+	if m != nil {
+		return m.Service
+	}
+	return ""
+}
+
+func (m *RoundStatus) GetRound() uint32 {
+	if m != nil {
+		return m.Round
+	}
+	return 0
+}
+
+func (m *RoundStatus) GetPhase() string {
+	if m != nil {
+		return m.Phase
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*Nothing)(nil), "convopb.Nothing")
 	proto.RegisterType((*NewRoundRequest)(nil), "convopb.NewRoundRequest")
@@ -366,6 +718,14 @@ func init() {
 	proto.RegisterType((*GetOnionsRequest)(nil), "convopb.GetOnionsRequest")
 	proto.RegisterType((*GetOnionsResponse)(nil), "convopb.GetOnionsResponse")
 	proto.RegisterType((*DeleteRoundRequest)(nil), "convopb.DeleteRoundRequest")
+	proto.RegisterType((*GetCommitmentRequest)(nil), "convopb.GetCommitmentRequest")
+	proto.RegisterType((*MixCommitment)(nil), "convopb.MixCommitment")
+	proto.RegisterType((*RevealLinksRequest)(nil), "convopb.RevealLinksRequest")
+	proto.RegisterType((*RevealedLink)(nil), "convopb.RevealedLink")
+	proto.RegisterType((*RevealLinksResponse)(nil), "convopb.RevealLinksResponse")
+	proto.RegisterType((*StatusRequest)(nil), "convopb.StatusRequest")
+	proto.RegisterType((*StatusResponse)(nil), "convopb.StatusResponse")
+	proto.RegisterType((*RoundStatus)(nil), "convopb.RoundStatus")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -385,6 +745,9 @@ type MixnetClient interface {
 	CloseRound(ctx context.Context, in *CloseRoundRequest, opts ...grpc.CallOption) (*CloseRoundResponse, error)
 	GetOnions(ctx context.Context, in *GetOnionsRequest, opts ...grpc.CallOption) (Mixnet_GetOnionsClient, error)
 	DeleteRound(ctx context.Context, in *DeleteRoundRequest, opts ...grpc.CallOption) (*Nothing, error)
+	GetCommitment(ctx context.Context, in *GetCommitmentRequest, opts ...grpc.CallOption) (*MixCommitment, error)
+	RevealLinks(ctx context.Context, in *RevealLinksRequest, opts ...grpc.CallOption) (Mixnet_RevealLinksClient, error)
+	Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StatusResponse, error)
 }
 
 type mixnetClient struct {
@@ -497,6 +860,56 @@ func (c *mixnetClient) DeleteRound(ctx context.Context, in *DeleteRoundRequest,
 	return out, nil
 }
 
+func (c *mixnetClient) GetCommitment(ctx context.Context, in *GetCommitmentRequest, opts ...grpc.CallOption) (*MixCommitment, error) {
+	out := new(MixCommitment)
+	err := grpc.Invoke(ctx, "/convopb.Mixnet/GetCommitment", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mixnetClient) RevealLinks(ctx context.Context, in *RevealLinksRequest, opts ...grpc.CallOption) (Mixnet_RevealLinksClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Mixnet_serviceDesc.Streams[2], c.cc, "/convopb.Mixnet/RevealLinks", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &mixnetRevealLinksClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Mixnet_RevealLinksClient interface {
+	Recv() (*RevealLinksResponse, error)
+	grpc.ClientStream
+}
+
+type mixnetRevealLinksClient struct {
+	grpc.ClientStream
+}
+
+func (x *mixnetRevealLinksClient) Recv() (*RevealLinksResponse, error) {
+	m := new(RevealLinksResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *mixnetClient) Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StatusResponse, error) {
+	out := new(StatusResponse)
+	err := grpc.Invoke(ctx, "/convopb.Mixnet/Status", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for Mixnet service
 
 type MixnetServer interface {
@@ -506,6 +919,9 @@ type MixnetServer interface {
 	CloseRound(context.Context, *CloseRoundRequest) (*CloseRoundResponse, error)
 	GetOnions(*GetOnionsRequest, Mixnet_GetOnionsServer) error
 	DeleteRound(context.Context, *DeleteRoundRequest) (*Nothing, error)
+	GetCommitment(context.Context, *GetCommitmentRequest) (*MixCommitment, error)
+	RevealLinks(*RevealLinksRequest, Mixnet_RevealLinksServer) error
+	Status(context.Context, *StatusRequest) (*StatusResponse, error)
 }
 
 func RegisterMixnetServer(s *grpc.Server, srv MixnetServer) {
@@ -631,18 +1047,75 @@ func _Mixnet_DeleteRound_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
-var _Mixnet_serviceDesc = grpc.ServiceDesc{
-	ServiceName: "convopb.Mixnet",
-	HandlerType: (*MixnetServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "NewRound",
-			Handler:    _Mixnet_NewRound_Handler,
-		},
-		{
-			MethodName: "SetRoundSettings",
-			Handler:    _Mixnet_SetRoundSettings_Handler,
-		},
+func _Mixnet_GetCommitment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCommitmentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MixnetServer).GetCommitment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/convopb.Mixnet/GetCommitment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MixnetServer).GetCommitment(ctx, req.(*GetCommitmentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Mixnet_RevealLinks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RevealLinksRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MixnetServer).RevealLinks(m, &mixnetRevealLinksServer{stream})
+}
+
+type Mixnet_RevealLinksServer interface {
+	Send(*RevealLinksResponse) error
+	grpc.ServerStream
+}
+
+type mixnetRevealLinksServer struct {
+	grpc.ServerStream
+}
+
+func (x *mixnetRevealLinksServer) Send(m *RevealLinksResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Mixnet_Status_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MixnetServer).Status(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/convopb.Mixnet/Status",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MixnetServer).Status(ctx, req.(*StatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Mixnet_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "convopb.Mixnet",
+	HandlerType: (*MixnetServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "NewRound",
+			Handler:    _Mixnet_NewRound_Handler,
+		},
+		{
+			MethodName: "SetRoundSettings",
+			Handler:    _Mixnet_SetRoundSettings_Handler,
+		},
 		{
 			MethodName: "CloseRound",
 			Handler:    _Mixnet_CloseRound_Handler,
@@ -651,6 +1124,14 @@ var _Mixnet_serviceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteRound",
 			Handler:    _Mixnet_DeleteRound_Handler,
 		},
+		{
+			MethodName: "GetCommitment",
+			Handler:    _Mixnet_GetCommitment_Handler,
+		},
+		{
+			MethodName: "Status",
+			Handler:    _Mixnet_Status_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -663,6 +1144,11 @@ var _Mixnet_serviceDesc = grpc.ServiceDesc{
 			Handler:       _Mixnet_GetOnions_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "RevealLinks",
+			Handler:       _Mixnet_RevealLinks_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "mixnet.proto",
 }
@@ -1058,165 +1544,498 @@ func (m *DeleteRoundRequest) MarshalTo(dAtA []byte) (int, error) {
 	return i, nil
 }
 
-func encodeVarintMixnet(dAtA []byte, offset int, v uint64) int {
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
+func (m *GetCommitmentRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
-	dAtA[offset] = uint8(v)
-	return offset + 1
-}
-func (m *Nothing) Size() (n int) {
-	var l int
-	_ = l
-	return n
+	return dAtA[:n], nil
 }
 
-func (m *NewRoundRequest) Size() (n int) {
+func (m *GetCommitmentRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	l = len(m.Service)
-	if l > 0 {
-		n += 1 + l + sovMixnet(uint64(l))
+	if len(m.Service) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintMixnet(dAtA, i, uint64(len(m.Service)))
+		i += copy(dAtA[i:], m.Service)
 	}
 	if m.Round != 0 {
-		n += 1 + sovMixnet(uint64(m.Round))
-	}
-	if len(m.Chain) > 0 {
-		for _, e := range m.Chain {
-			l = e.Size()
-			n += 1 + l + sovMixnet(uint64(l))
-		}
-	}
-	return n
-}
-
-func (m *PublicServerConfig) Size() (n int) {
-	var l int
-	_ = l
-	l = len(m.Key)
-	if l > 0 {
-		n += 1 + l + sovMixnet(uint64(l))
-	}
-	l = len(m.Address)
-	if l > 0 {
-		n += 1 + l + sovMixnet(uint64(l))
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintMixnet(dAtA, i, uint64(m.Round))
 	}
-	return n
+	return i, nil
 }
 
-func (m *NewRoundResponse) Size() (n int) {
-	var l int
-	_ = l
-	l = len(m.OnionKey)
-	if l > 0 {
-		n += 1 + l + sovMixnet(uint64(l))
+func (m *MixCommitment) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
-	return n
+	return dAtA[:n], nil
 }
 
-func (m *RoundSettings) Size() (n int) {
+func (m *MixCommitment) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	l = len(m.Service)
-	if l > 0 {
-		n += 1 + l + sovMixnet(uint64(l))
+	if len(m.Service) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintMixnet(dAtA, i, uint64(len(m.Service)))
+		i += copy(dAtA[i:], m.Service)
 	}
 	if m.Round != 0 {
-		n += 1 + sovMixnet(uint64(m.Round))
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintMixnet(dAtA, i, uint64(m.Round))
 	}
-	if len(m.OnionKeys) > 0 {
-		for _, b := range m.OnionKeys {
-			l = len(b)
-			n += 1 + l + sovMixnet(uint64(l))
-		}
+	if m.Position != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintMixnet(dAtA, i, uint64(m.Position))
 	}
-	l = len(m.ServiceData)
-	if l > 0 {
-		n += 1 + l + sovMixnet(uint64(l))
+	if m.NumInputs != 0 {
+		dAtA[i] = 0x20
+		i++
+		i = encodeVarintMixnet(dAtA, i, uint64(m.NumInputs))
 	}
-	return n
-}
-
-func (m *SetRoundSettingsRequest) Size() (n int) {
-	var l int
-	_ = l
-	if m.Settings != nil {
-		l = m.Settings.Size()
-		n += 1 + l + sovMixnet(uint64(l))
+	if len(m.InputRoot) > 0 {
+		dAtA[i] = 0x2a
+		i++
+		i = encodeVarintMixnet(dAtA, i, uint64(len(m.InputRoot)))
+		i += copy(dAtA[i:], m.InputRoot)
 	}
-	return n
-}
-
-func (m *RoundSettingsSignature) Size() (n int) {
-	var l int
-	_ = l
-	l = len(m.Signature)
-	if l > 0 {
-		n += 1 + l + sovMixnet(uint64(l))
+	if m.NumOutputs != 0 {
+		dAtA[i] = 0x30
+		i++
+		i = encodeVarintMixnet(dAtA, i, uint64(m.NumOutputs))
 	}
-	return n
+	if len(m.OutputRoot) > 0 {
+		dAtA[i] = 0x3a
+		i++
+		i = encodeVarintMixnet(dAtA, i, uint64(len(m.OutputRoot)))
+		i += copy(dAtA[i:], m.OutputRoot)
+	}
+	if m.NumNoise != 0 {
+		dAtA[i] = 0x40
+		i++
+		i = encodeVarintMixnet(dAtA, i, uint64(m.NumNoise))
+	}
+	if len(m.Signature) > 0 {
+		dAtA[i] = 0x4a
+		i++
+		i = encodeVarintMixnet(dAtA, i, uint64(len(m.Signature)))
+		i += copy(dAtA[i:], m.Signature)
+	}
+	return i, nil
 }
 
-func (m *AddOnionsRequest) Size() (n int) {
-	var l int
-	_ = l
-	if m.Offset != 0 {
-		n += 1 + sovMixnet(uint64(m.Offset))
-	}
-	if len(m.Onions) > 0 {
-		for _, b := range m.Onions {
-			l = len(b)
-			n += 1 + l + sovMixnet(uint64(l))
-		}
+func (m *RevealLinksRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
-	return n
+	return dAtA[:n], nil
 }
 
-func (m *CloseRoundRequest) Size() (n int) {
+func (m *RevealLinksRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	l = len(m.Service)
-	if l > 0 {
-		n += 1 + l + sovMixnet(uint64(l))
+	if len(m.Service) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintMixnet(dAtA, i, uint64(len(m.Service)))
+		i += copy(dAtA[i:], m.Service)
 	}
 	if m.Round != 0 {
-		n += 1 + sovMixnet(uint64(m.Round))
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintMixnet(dAtA, i, uint64(m.Round))
 	}
-	return n
+	if len(m.Commitments) > 0 {
+		for _, msg := range m.Commitments {
+			dAtA[i] = 0x1a
+			i++
+			i = encodeVarintMixnet(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
+	return i, nil
 }
 
-func (m *CloseRoundResponse) Size() (n int) {
-	var l int
-	_ = l
-	l = len(m.Result)
-	if l > 0 {
-		n += 1 + l + sovMixnet(uint64(l))
+func (m *RevealedLink) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
-	return n
+	return dAtA[:n], nil
 }
 
-func (m *GetOnionsRequest) Size() (n int) {
+func (m *RevealedLink) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	l = len(m.Service)
-	if l > 0 {
-		n += 1 + l + sovMixnet(uint64(l))
+	if m.Input != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintMixnet(dAtA, i, uint64(m.Input))
 	}
-	if m.Round != 0 {
-		n += 1 + sovMixnet(uint64(m.Round))
+	if m.Output != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintMixnet(dAtA, i, uint64(m.Output))
 	}
-	if m.Offset != 0 {
-		n += 1 + sovMixnet(uint64(m.Offset))
+	if m.Discarded {
+		dAtA[i] = 0x18
+		i++
+		if m.Discarded {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
 	}
-	if m.Count != 0 {
-		n += 1 + sovMixnet(uint64(m.Count))
+	if m.Noise {
+		dAtA[i] = 0x20
+		i++
+		if m.Noise {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if len(m.Onion) > 0 {
+		dAtA[i] = 0x2a
+		i++
+		i = encodeVarintMixnet(dAtA, i, uint64(len(m.Onion)))
+		i += copy(dAtA[i:], m.Onion)
+	}
+	if len(m.SharedKey) > 0 {
+		dAtA[i] = 0x32
+		i++
+		i = encodeVarintMixnet(dAtA, i, uint64(len(m.SharedKey)))
+		i += copy(dAtA[i:], m.SharedKey)
+	}
+	if len(m.InputProof) > 0 {
+		for _, b := range m.InputProof {
+			dAtA[i] = 0x3a
+			i++
+			i = encodeVarintMixnet(dAtA, i, uint64(len(b)))
+			i += copy(dAtA[i:], b)
+		}
+	}
+	if len(m.OutputProof) > 0 {
+		for _, b := range m.OutputProof {
+			dAtA[i] = 0x42
+			i++
+			i = encodeVarintMixnet(dAtA, i, uint64(len(b)))
+			i += copy(dAtA[i:], b)
+		}
+	}
+	if m.Replay {
+		dAtA[i] = 0x48
+		i++
+		if m.Replay {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if m.Twin != 0 {
+		dAtA[i] = 0x50
+		i++
+		i = encodeVarintMixnet(dAtA, i, uint64(m.Twin))
+	}
+	if len(m.TwinProof) > 0 {
+		for _, b := range m.TwinProof {
+			dAtA[i] = 0x5a
+			i++
+			i = encodeVarintMixnet(dAtA, i, uint64(len(b)))
+			i += copy(dAtA[i:], b)
+		}
+	}
+	return i, nil
+}
+
+func (m *RevealLinksResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *RevealLinksResponse) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Links) > 0 {
+		for _, msg := range m.Links {
+			dAtA[i] = 0xa
+			i++
+			i = encodeVarintMixnet(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
+	return i, nil
+}
+
+func (m *StatusRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *StatusRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	return i, nil
+}
+
+func (m *StatusResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *StatusResponse) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Version) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintMixnet(dAtA, i, uint64(len(m.Version)))
+		i += copy(dAtA[i:], m.Version)
+	}
+	if len(m.Services) > 0 {
+		for _, s := range m.Services {
+			dAtA[i] = 0x12
+			i++
+			l = len(s)
+			for l >= 1<<7 {
+				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			dAtA[i] = uint8(l)
+			i++
+			i += copy(dAtA[i:], s)
+		}
+	}
+	if len(m.Rounds) > 0 {
+		for _, msg := range m.Rounds {
+			dAtA[i] = 0x1a
+			i++
+			i = encodeVarintMixnet(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
+	if m.DecryptionQueue != 0 {
+		dAtA[i] = 0x20
+		i++
+		i = encodeVarintMixnet(dAtA, i, uint64(m.DecryptionQueue))
+	}
+	if m.UptimeSeconds != 0 {
+		dAtA[i] = 0x28
+		i++
+		i = encodeVarintMixnet(dAtA, i, uint64(m.UptimeSeconds))
+	}
+	if m.Draining {
+		dAtA[i] = 0x30
+		i++
+		if m.Draining {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	return i, nil
+}
+
+func (m *RoundStatus) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *RoundStatus) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Service) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintMixnet(dAtA, i, uint64(len(m.Service)))
+		i += copy(dAtA[i:], m.Service)
+	}
+	if m.Round != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintMixnet(dAtA, i, uint64(m.Round))
+	}
+	if len(m.Phase) > 0 {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintMixnet(dAtA, i, uint64(len(m.Phase)))
+		i += copy(dAtA[i:], m.Phase)
+	}
+	return i, nil
+}
+
+func encodeVarintMixnet(dAtA []byte, offset int, v uint64) int {
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return offset + 1
+}
+func (m *Nothing) Size() (n int) {
+	var l int
+	_ = l
+	return n
+}
+
+func (m *NewRoundRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Service)
+	if l > 0 {
+		n += 1 + l + sovMixnet(uint64(l))
+	}
+	if m.Round != 0 {
+		n += 1 + sovMixnet(uint64(m.Round))
+	}
+	if len(m.Chain) > 0 {
+		for _, e := range m.Chain {
+			l = e.Size()
+			n += 1 + l + sovMixnet(uint64(l))
+		}
 	}
 	return n
 }
 
-func (m *GetOnionsResponse) Size() (n int) {
+func (m *PublicServerConfig) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Key)
+	if l > 0 {
+		n += 1 + l + sovMixnet(uint64(l))
+	}
+	l = len(m.Address)
+	if l > 0 {
+		n += 1 + l + sovMixnet(uint64(l))
+	}
+	return n
+}
+
+func (m *NewRoundResponse) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.OnionKey)
+	if l > 0 {
+		n += 1 + l + sovMixnet(uint64(l))
+	}
+	return n
+}
+
+func (m *RoundSettings) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Service)
+	if l > 0 {
+		n += 1 + l + sovMixnet(uint64(l))
+	}
+	if m.Round != 0 {
+		n += 1 + sovMixnet(uint64(m.Round))
+	}
+	if len(m.OnionKeys) > 0 {
+		for _, b := range m.OnionKeys {
+			l = len(b)
+			n += 1 + l + sovMixnet(uint64(l))
+		}
+	}
+	l = len(m.ServiceData)
+	if l > 0 {
+		n += 1 + l + sovMixnet(uint64(l))
+	}
+	return n
+}
+
+func (m *SetRoundSettingsRequest) Size() (n int) {
+	var l int
+	_ = l
+	if m.Settings != nil {
+		l = m.Settings.Size()
+		n += 1 + l + sovMixnet(uint64(l))
+	}
+	return n
+}
+
+func (m *RoundSettingsSignature) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Signature)
+	if l > 0 {
+		n += 1 + l + sovMixnet(uint64(l))
+	}
+	return n
+}
+
+func (m *AddOnionsRequest) Size() (n int) {
 	var l int
 	_ = l
 	if m.Offset != 0 {
@@ -1231,7 +2050,7 @@ func (m *GetOnionsResponse) Size() (n int) {
 	return n
 }
 
-func (m *DeleteRoundRequest) Size() (n int) {
+func (m *CloseRoundRequest) Size() (n int) {
 	var l int
 	_ = l
 	l = len(m.Service)
@@ -1244,7 +2063,249 @@ func (m *DeleteRoundRequest) Size() (n int) {
 	return n
 }
 
-func sovMixnet(x uint64) (n int) {
+func (m *CloseRoundResponse) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Result)
+	if l > 0 {
+		n += 1 + l + sovMixnet(uint64(l))
+	}
+	return n
+}
+
+func (m *GetOnionsRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Service)
+	if l > 0 {
+		n += 1 + l + sovMixnet(uint64(l))
+	}
+	if m.Round != 0 {
+		n += 1 + sovMixnet(uint64(m.Round))
+	}
+	if m.Offset != 0 {
+		n += 1 + sovMixnet(uint64(m.Offset))
+	}
+	if m.Count != 0 {
+		n += 1 + sovMixnet(uint64(m.Count))
+	}
+	return n
+}
+
+func (m *GetOnionsResponse) Size() (n int) {
+	var l int
+	_ = l
+	if m.Offset != 0 {
+		n += 1 + sovMixnet(uint64(m.Offset))
+	}
+	if len(m.Onions) > 0 {
+		for _, b := range m.Onions {
+			l = len(b)
+			n += 1 + l + sovMixnet(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *DeleteRoundRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Service)
+	if l > 0 {
+		n += 1 + l + sovMixnet(uint64(l))
+	}
+	if m.Round != 0 {
+		n += 1 + sovMixnet(uint64(m.Round))
+	}
+	return n
+}
+
+func (m *GetCommitmentRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Service)
+	if l > 0 {
+		n += 1 + l + sovMixnet(uint64(l))
+	}
+	if m.Round != 0 {
+		n += 1 + sovMixnet(uint64(m.Round))
+	}
+	return n
+}
+
+func (m *MixCommitment) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Service)
+	if l > 0 {
+		n += 1 + l + sovMixnet(uint64(l))
+	}
+	if m.Round != 0 {
+		n += 1 + sovMixnet(uint64(m.Round))
+	}
+	if m.Position != 0 {
+		n += 1 + sovMixnet(uint64(m.Position))
+	}
+	if m.NumInputs != 0 {
+		n += 1 + sovMixnet(uint64(m.NumInputs))
+	}
+	l = len(m.InputRoot)
+	if l > 0 {
+		n += 1 + l + sovMixnet(uint64(l))
+	}
+	if m.NumOutputs != 0 {
+		n += 1 + sovMixnet(uint64(m.NumOutputs))
+	}
+	l = len(m.OutputRoot)
+	if l > 0 {
+		n += 1 + l + sovMixnet(uint64(l))
+	}
+	if m.NumNoise != 0 {
+		n += 1 + sovMixnet(uint64(m.NumNoise))
+	}
+	l = len(m.Signature)
+	if l > 0 {
+		n += 1 + l + sovMixnet(uint64(l))
+	}
+	return n
+}
+
+func (m *RevealLinksRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Service)
+	if l > 0 {
+		n += 1 + l + sovMixnet(uint64(l))
+	}
+	if m.Round != 0 {
+		n += 1 + sovMixnet(uint64(m.Round))
+	}
+	if len(m.Commitments) > 0 {
+		for _, e := range m.Commitments {
+			l = e.Size()
+			n += 1 + l + sovMixnet(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *RevealedLink) Size() (n int) {
+	var l int
+	_ = l
+	if m.Input != 0 {
+		n += 1 + sovMixnet(uint64(m.Input))
+	}
+	if m.Output != 0 {
+		n += 1 + sovMixnet(uint64(m.Output))
+	}
+	if m.Discarded {
+		n += 2
+	}
+	if m.Noise {
+		n += 2
+	}
+	l = len(m.Onion)
+	if l > 0 {
+		n += 1 + l + sovMixnet(uint64(l))
+	}
+	l = len(m.SharedKey)
+	if l > 0 {
+		n += 1 + l + sovMixnet(uint64(l))
+	}
+	if len(m.InputProof) > 0 {
+		for _, b := range m.InputProof {
+			l = len(b)
+			n += 1 + l + sovMixnet(uint64(l))
+		}
+	}
+	if len(m.OutputProof) > 0 {
+		for _, b := range m.OutputProof {
+			l = len(b)
+			n += 1 + l + sovMixnet(uint64(l))
+		}
+	}
+	if m.Replay {
+		n += 2
+	}
+	if m.Twin != 0 {
+		n += 1 + sovMixnet(uint64(m.Twin))
+	}
+	if len(m.TwinProof) > 0 {
+		for _, b := range m.TwinProof {
+			l = len(b)
+			n += 1 + l + sovMixnet(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *RevealLinksResponse) Size() (n int) {
+	var l int
+	_ = l
+	if len(m.Links) > 0 {
+		for _, e := range m.Links {
+			l = e.Size()
+			n += 1 + l + sovMixnet(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *StatusRequest) Size() (n int) {
+	var l int
+	_ = l
+	return n
+}
+
+func (m *StatusResponse) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Version)
+	if l > 0 {
+		n += 1 + l + sovMixnet(uint64(l))
+	}
+	if len(m.Services) > 0 {
+		for _, s := range m.Services {
+			l = len(s)
+			n += 1 + l + sovMixnet(uint64(l))
+		}
+	}
+	if len(m.Rounds) > 0 {
+		for _, e := range m.Rounds {
+			l = e.Size()
+			n += 1 + l + sovMixnet(uint64(l))
+		}
+	}
+	if m.DecryptionQueue != 0 {
+		n += 1 + sovMixnet(uint64(m.DecryptionQueue))
+	}
+	if m.UptimeSeconds != 0 {
+		n += 1 + sovMixnet(uint64(m.UptimeSeconds))
+	}
+	if m.Draining {
+		n += 2
+	}
+	return n
+}
+
+func (m *RoundStatus) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Service)
+	if l > 0 {
+		n += 1 + l + sovMixnet(uint64(l))
+	}
+	if m.Round != 0 {
+		n += 1 + sovMixnet(uint64(m.Round))
+	}
+	l = len(m.Phase)
+	if l > 0 {
+		n += 1 + l + sovMixnet(uint64(l))
+	}
+	return n
+}
+
+func sovMixnet(x uint64) (n int) {
 	for {
 		n++
 		x >>= 7
@@ -1252,12 +2313,1213 @@ func sovMixnet(x uint64) (n int) {
 			break
 		}
 	}
-	return n
-}
-func sozMixnet(x uint64) (n int) {
-	return sovMixnet(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+	return n
+}
+func sozMixnet(x uint64) (n int) {
+	return sovMixnet(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *Nothing) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMixnet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Nothing: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Nothing: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMixnet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMixnet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *NewRoundRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMixnet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: NewRoundRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: NewRoundRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Service", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMixnet
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Service = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Round", wireType)
+			}
+			m.Round = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Round |= (uint32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Chain", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthMixnet
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Chain = append(m.Chain, &PublicServerConfig{})
+			if err := m.Chain[len(m.Chain)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMixnet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMixnet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *PublicServerConfig) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMixnet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: PublicServerConfig: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: PublicServerConfig: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMixnet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Key = append(m.Key[:0], dAtA[iNdEx:postIndex]...)
+			if m.Key == nil {
+				m.Key = []byte{}
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Address", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMixnet
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Address = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMixnet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMixnet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *NewRoundResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMixnet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: NewRoundResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: NewRoundResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field OnionKey", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMixnet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.OnionKey = append(m.OnionKey[:0], dAtA[iNdEx:postIndex]...)
+			if m.OnionKey == nil {
+				m.OnionKey = []byte{}
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMixnet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMixnet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *RoundSettings) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMixnet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: RoundSettings: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: RoundSettings: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Service", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMixnet
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Service = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Round", wireType)
+			}
+			m.Round = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Round |= (uint32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field OnionKeys", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMixnet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.OnionKeys = append(m.OnionKeys, make([]byte, postIndex-iNdEx))
+			copy(m.OnionKeys[len(m.OnionKeys)-1], dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ServiceData", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMixnet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ServiceData = append(m.ServiceData[:0], dAtA[iNdEx:postIndex]...)
+			if m.ServiceData == nil {
+				m.ServiceData = []byte{}
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMixnet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMixnet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *SetRoundSettingsRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMixnet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SetRoundSettingsRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SetRoundSettingsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Settings", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthMixnet
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Settings == nil {
+				m.Settings = &RoundSettings{}
+			}
+			if err := m.Settings.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMixnet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMixnet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *RoundSettingsSignature) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMixnet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: RoundSettingsSignature: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: RoundSettingsSignature: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Signature", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMixnet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Signature = append(m.Signature[:0], dAtA[iNdEx:postIndex]...)
+			if m.Signature == nil {
+				m.Signature = []byte{}
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMixnet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMixnet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *AddOnionsRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMixnet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: AddOnionsRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: AddOnionsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Offset", wireType)
+			}
+			m.Offset = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Offset |= (uint32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Onions", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMixnet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Onions = append(m.Onions, make([]byte, postIndex-iNdEx))
+			copy(m.Onions[len(m.Onions)-1], dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMixnet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMixnet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *CloseRoundRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMixnet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: CloseRoundRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: CloseRoundRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Service", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMixnet
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Service = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Round", wireType)
+			}
+			m.Round = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Round |= (uint32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMixnet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMixnet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *CloseRoundResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMixnet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: CloseRoundResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: CloseRoundResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Result", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMixnet
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Result = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMixnet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMixnet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GetOnionsRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMixnet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GetOnionsRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GetOnionsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Service", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMixnet
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Service = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Round", wireType)
+			}
+			m.Round = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Round |= (uint32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Offset", wireType)
+			}
+			m.Offset = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Offset |= (uint32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Count", wireType)
+			}
+			m.Count = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Count |= (uint32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMixnet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMixnet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GetOnionsResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMixnet
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GetOnionsResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GetOnionsResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Offset", wireType)
+			}
+			m.Offset = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Offset |= (uint32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Onions", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMixnet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Onions = append(m.Onions, make([]byte, postIndex-iNdEx))
+			copy(m.Onions[len(m.Onions)-1], dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMixnet(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMixnet
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
 }
-func (m *Nothing) Unmarshal(dAtA []byte) error {
+func (m *DeleteRoundRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1280,12 +3542,60 @@ func (m *Nothing) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Nothing: wiretype end group for non-group")
+			return fmt.Errorf("proto: DeleteRoundRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Nothing: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: DeleteRoundRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Service", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMixnet
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Service = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Round", wireType)
+			}
+			m.Round = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Round |= (uint32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMixnet(dAtA[iNdEx:])
@@ -1307,7 +3617,7 @@ func (m *Nothing) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *NewRoundRequest) Unmarshal(dAtA []byte) error {
+func (m *GetCommitmentRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1330,10 +3640,10 @@ func (m *NewRoundRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: NewRoundRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: GetCommitmentRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: NewRoundRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: GetCommitmentRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
@@ -1384,37 +3694,6 @@ func (m *NewRoundRequest) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
-		case 3:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Chain", wireType)
-			}
-			var msglen int
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowMixnet
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				msglen |= (int(b) & 0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			if msglen < 0 {
-				return ErrInvalidLengthMixnet
-			}
-			postIndex := iNdEx + msglen
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Chain = append(m.Chain, &PublicServerConfig{})
-			if err := m.Chain[len(m.Chain)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMixnet(dAtA[iNdEx:])
@@ -1436,7 +3715,7 @@ func (m *NewRoundRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *PublicServerConfig) Unmarshal(dAtA []byte) error {
+func (m *MixCommitment) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1459,17 +3738,17 @@ func (m *PublicServerConfig) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: PublicServerConfig: wiretype end group for non-group")
+			return fmt.Errorf("proto: MixCommitment: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: PublicServerConfig: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: MixCommitment: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Service", wireType)
 			}
-			var byteLen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMixnet
@@ -1479,28 +3758,83 @@ func (m *PublicServerConfig) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= (int(b) & 0x7F) << shift
+				stringLen |= (uint64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthMixnet
 			}
-			postIndex := iNdEx + byteLen
+			postIndex := iNdEx + intStringLen
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Key = append(m.Key[:0], dAtA[iNdEx:postIndex]...)
-			if m.Key == nil {
-				m.Key = []byte{}
-			}
+			m.Service = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Round", wireType)
+			}
+			m.Round = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Round |= (uint32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Position", wireType)
+			}
+			m.Position = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Position |= (uint32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field NumInputs", wireType)
+			}
+			m.NumInputs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.NumInputs |= (uint32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Address", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field InputRoot", wireType)
 			}
-			var stringLen uint64
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMixnet
@@ -1510,74 +3844,95 @@ func (m *PublicServerConfig) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
+				byteLen |= (int(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if byteLen < 0 {
 				return ErrInvalidLengthMixnet
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + byteLen
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Address = string(dAtA[iNdEx:postIndex])
+			m.InputRoot = append(m.InputRoot[:0], dAtA[iNdEx:postIndex]...)
+			if m.InputRoot == nil {
+				m.InputRoot = []byte{}
+			}
 			iNdEx = postIndex
-		default:
-			iNdEx = preIndex
-			skippy, err := skipMixnet(dAtA[iNdEx:])
-			if err != nil {
-				return err
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field NumOutputs", wireType)
 			}
-			if skippy < 0 {
+			m.NumOutputs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.NumOutputs |= (uint32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field OutputRoot", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
 				return ErrInvalidLengthMixnet
 			}
-			if (iNdEx + skippy) > l {
+			postIndex := iNdEx + byteLen
+			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *NewRoundResponse) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowMixnet
+			m.OutputRoot = append(m.OutputRoot[:0], dAtA[iNdEx:postIndex]...)
+			if m.OutputRoot == nil {
+				m.OutputRoot = []byte{}
 			}
-			if iNdEx >= l {
-				return io.ErrUnexpectedEOF
+			iNdEx = postIndex
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field NumNoise", wireType)
 			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= (uint64(b) & 0x7F) << shift
-			if b < 0x80 {
-				break
+			m.NumNoise = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.NumNoise |= (uint32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: NewRoundResponse: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: NewRoundResponse: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
+		case 9:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field OnionKey", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Signature", wireType)
 			}
 			var byteLen int
 			for shift := uint(0); ; shift += 7 {
@@ -1601,9 +3956,9 @@ func (m *NewRoundResponse) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.OnionKey = append(m.OnionKey[:0], dAtA[iNdEx:postIndex]...)
-			if m.OnionKey == nil {
-				m.OnionKey = []byte{}
+			m.Signature = append(m.Signature[:0], dAtA[iNdEx:postIndex]...)
+			if m.Signature == nil {
+				m.Signature = []byte{}
 			}
 			iNdEx = postIndex
 		default:
@@ -1627,7 +3982,7 @@ func (m *NewRoundResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *RoundSettings) Unmarshal(dAtA []byte) error {
+func (m *RevealLinksRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1650,10 +4005,10 @@ func (m *RoundSettings) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: RoundSettings: wiretype end group for non-group")
+			return fmt.Errorf("proto: RevealLinksRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: RoundSettings: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: RevealLinksRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
@@ -1706,38 +4061,9 @@ func (m *RoundSettings) Unmarshal(dAtA []byte) error {
 			}
 		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field OnionKeys", wireType)
-			}
-			var byteLen int
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowMixnet
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				byteLen |= (int(b) & 0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			if byteLen < 0 {
-				return ErrInvalidLengthMixnet
-			}
-			postIndex := iNdEx + byteLen
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.OnionKeys = append(m.OnionKeys, make([]byte, postIndex-iNdEx))
-			copy(m.OnionKeys[len(m.OnionKeys)-1], dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
-		case 4:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ServiceData", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Commitments", wireType)
 			}
-			var byteLen int
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMixnet
@@ -1747,21 +4073,21 @@ func (m *RoundSettings) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= (int(b) & 0x7F) << shift
+				msglen |= (int(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthMixnet
 			}
-			postIndex := iNdEx + byteLen
+			postIndex := iNdEx + msglen
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.ServiceData = append(m.ServiceData[:0], dAtA[iNdEx:postIndex]...)
-			if m.ServiceData == nil {
-				m.ServiceData = []byte{}
+			m.Commitments = append(m.Commitments, &MixCommitment{})
+			if err := m.Commitments[len(m.Commitments)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
 			}
 			iNdEx = postIndex
 		default:
@@ -1785,7 +4111,7 @@ func (m *RoundSettings) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *SetRoundSettingsRequest) Unmarshal(dAtA []byte) error {
+func (m *RevealedLink) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1808,17 +4134,17 @@ func (m *SetRoundSettingsRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: SetRoundSettingsRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: RevealedLink: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: SetRoundSettingsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: RevealedLink: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Settings", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Input", wireType)
 			}
-			var msglen int
+			m.Input = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMixnet
@@ -1828,78 +4154,104 @@ func (m *SetRoundSettingsRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= (int(b) & 0x7F) << shift
+				m.Input |= (uint32(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthMixnet
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Output", wireType)
 			}
-			postIndex := iNdEx + msglen
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
+			m.Output = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Output |= (uint32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			if m.Settings == nil {
-				m.Settings = &RoundSettings{}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Discarded", wireType)
 			}
-			if err := m.Settings.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			iNdEx = postIndex
-		default:
-			iNdEx = preIndex
-			skippy, err := skipMixnet(dAtA[iNdEx:])
-			if err != nil {
-				return err
+			m.Discarded = bool(v != 0)
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Noise", wireType)
 			}
-			if skippy < 0 {
-				return ErrInvalidLengthMixnet
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
+			m.Noise = bool(v != 0)
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Onion", wireType)
 			}
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *RoundSettingsSignature) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowMixnet
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			if iNdEx >= l {
+			if byteLen < 0 {
+				return ErrInvalidLengthMixnet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= (uint64(b) & 0x7F) << shift
-			if b < 0x80 {
-				break
+			m.Onion = append(m.Onion[:0], dAtA[iNdEx:postIndex]...)
+			if m.Onion == nil {
+				m.Onion = []byte{}
 			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: RoundSettingsSignature: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: RoundSettingsSignature: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
+			iNdEx = postIndex
+		case 6:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Signature", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field SharedKey", wireType)
 			}
 			var byteLen int
 			for shift := uint(0); ; shift += 7 {
@@ -1923,66 +4275,94 @@ func (m *RoundSettingsSignature) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Signature = append(m.Signature[:0], dAtA[iNdEx:postIndex]...)
-			if m.Signature == nil {
-				m.Signature = []byte{}
+			m.SharedKey = append(m.SharedKey[:0], dAtA[iNdEx:postIndex]...)
+			if m.SharedKey == nil {
+				m.SharedKey = []byte{}
 			}
 			iNdEx = postIndex
-		default:
-			iNdEx = preIndex
-			skippy, err := skipMixnet(dAtA[iNdEx:])
-			if err != nil {
-				return err
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field InputProof", wireType)
 			}
-			if skippy < 0 {
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
 				return ErrInvalidLengthMixnet
 			}
-			if (iNdEx + skippy) > l {
+			postIndex := iNdEx + byteLen
+			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *AddOnionsRequest) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowMixnet
+			m.InputProof = append(m.InputProof, make([]byte, postIndex-iNdEx))
+			copy(m.InputProof[len(m.InputProof)-1], dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field OutputProof", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			if iNdEx >= l {
+			if byteLen < 0 {
+				return ErrInvalidLengthMixnet
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= (uint64(b) & 0x7F) << shift
-			if b < 0x80 {
-				break
+			m.OutputProof = append(m.OutputProof, make([]byte, postIndex-iNdEx))
+			copy(m.OutputProof[len(m.OutputProof)-1], dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Replay", wireType)
 			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: AddOnionsRequest: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: AddOnionsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Replay = bool(v != 0)
+		case 10:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Offset", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Twin", wireType)
 			}
-			m.Offset = 0
+			m.Twin = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMixnet
@@ -1992,14 +4372,14 @@ func (m *AddOnionsRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Offset |= (uint32(b) & 0x7F) << shift
+				m.Twin |= (uint32(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 2:
+		case 11:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Onions", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field TwinProof", wireType)
 			}
 			var byteLen int
 			for shift := uint(0); ; shift += 7 {
@@ -2023,8 +4403,8 @@ func (m *AddOnionsRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Onions = append(m.Onions, make([]byte, postIndex-iNdEx))
-			copy(m.Onions[len(m.Onions)-1], dAtA[iNdEx:postIndex])
+			m.TwinProof = append(m.TwinProof, make([]byte, postIndex-iNdEx))
+			copy(m.TwinProof[len(m.TwinProof)-1], dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -2047,7 +4427,7 @@ func (m *AddOnionsRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *CloseRoundRequest) Unmarshal(dAtA []byte) error {
+func (m *RevealLinksResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2070,17 +4450,17 @@ func (m *CloseRoundRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: CloseRoundRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: RevealLinksResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: CloseRoundRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: RevealLinksResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Service", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Links", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMixnet
@@ -2090,40 +4470,23 @@ func (m *CloseRoundRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
+				msglen |= (int(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthMixnet
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Service = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
-		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Round", wireType)
-			}
-			m.Round = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowMixnet
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.Round |= (uint32(b) & 0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+			m.Links = append(m.Links, &RevealedLink{})
+			if err := m.Links[len(m.Links)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
 			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMixnet(dAtA[iNdEx:])
@@ -2145,7 +4508,7 @@ func (m *CloseRoundRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *CloseRoundResponse) Unmarshal(dAtA []byte) error {
+func (m *StatusRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2168,41 +4531,12 @@ func (m *CloseRoundResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: CloseRoundResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: StatusRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: CloseRoundResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: StatusRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
-		case 1:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Result", wireType)
-			}
-			var stringLen uint64
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowMixnet
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthMixnet
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Result = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMixnet(dAtA[iNdEx:])
@@ -2224,7 +4558,7 @@ func (m *CloseRoundResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *GetOnionsRequest) Unmarshal(dAtA []byte) error {
+func (m *StatusResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2247,15 +4581,15 @@ func (m *GetOnionsRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: GetOnionsRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: StatusResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: GetOnionsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: StatusResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Service", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Version", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -2280,13 +4614,13 @@ func (m *GetOnionsRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Service = string(dAtA[iNdEx:postIndex])
+			m.Version = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Round", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Services", wireType)
 			}
-			m.Round = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMixnet
@@ -2296,16 +4630,26 @@ func (m *GetOnionsRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Round |= (uint32(b) & 0x7F) << shift
+				stringLen |= (uint64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMixnet
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Services = append(m.Services, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
 		case 3:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Offset", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Rounds", wireType)
 			}
-			m.Offset = 0
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMixnet
@@ -2315,16 +4659,28 @@ func (m *GetOnionsRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Offset |= (uint32(b) & 0x7F) << shift
+				msglen |= (int(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			if msglen < 0 {
+				return ErrInvalidLengthMixnet
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Rounds = append(m.Rounds, &RoundStatus{})
+			if err := m.Rounds[len(m.Rounds)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		case 4:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Count", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field DecryptionQueue", wireType)
 			}
-			m.Count = 0
+			m.DecryptionQueue = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMixnet
@@ -2334,66 +4690,16 @@ func (m *GetOnionsRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Count |= (uint32(b) & 0x7F) << shift
+				m.DecryptionQueue |= (uint32(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		default:
-			iNdEx = preIndex
-			skippy, err := skipMixnet(dAtA[iNdEx:])
-			if err != nil {
-				return err
-			}
-			if skippy < 0 {
-				return ErrInvalidLengthMixnet
-			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
-			}
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *GetOnionsResponse) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowMixnet
-			}
-			if iNdEx >= l {
-				return io.ErrUnexpectedEOF
-			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= (uint64(b) & 0x7F) << shift
-			if b < 0x80 {
-				break
-			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: GetOnionsResponse: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: GetOnionsResponse: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
+		case 5:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Offset", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field UptimeSeconds", wireType)
 			}
-			m.Offset = 0
+			m.UptimeSeconds = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMixnet
@@ -2403,16 +4709,16 @@ func (m *GetOnionsResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Offset |= (uint32(b) & 0x7F) << shift
+				m.UptimeSeconds |= (uint64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Onions", wireType)
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Draining", wireType)
 			}
-			var byteLen int
+			var v int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMixnet
@@ -2422,21 +4728,12 @@ func (m *GetOnionsResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= (int(b) & 0x7F) << shift
+				v |= (int(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
-				return ErrInvalidLengthMixnet
-			}
-			postIndex := iNdEx + byteLen
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Onions = append(m.Onions, make([]byte, postIndex-iNdEx))
-			copy(m.Onions[len(m.Onions)-1], dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
+			m.Draining = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMixnet(dAtA[iNdEx:])
@@ -2458,7 +4755,7 @@ func (m *GetOnionsResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *DeleteRoundRequest) Unmarshal(dAtA []byte) error {
+func (m *RoundStatus) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2481,10 +4778,10 @@ func (m *DeleteRoundRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: DeleteRoundRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: RoundStatus: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: DeleteRoundRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: RoundStatus: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
@@ -2535,6 +4832,35 @@ func (m *DeleteRoundRequest) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Phase", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMixnet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMixnet
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Phase = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMixnet(dAtA[iNdEx:])
@@ -2556,6 +4882,7 @@ func (m *DeleteRoundRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+
 func skipMixnet(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
@@ -2664,42 +4991,73 @@ var (
 func init() { proto.RegisterFile("mixnet.proto", fileDescriptorMixnet) }
 
 var fileDescriptorMixnet = []byte{
-	// 588 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x54, 0xcd, 0x92, 0xd2, 0x40,
-	0x10, 0x26, 0x8b, 0x0b, 0xa4, 0x81, 0x32, 0x4e, 0x6d, 0x61, 0x0c, 0x8a, 0x38, 0x27, 0x0e, 0x0a,
-	0x8a, 0x55, 0x9e, 0x2c, 0xcb, 0x5d, 0x28, 0xf7, 0x60, 0xed, 0x6a, 0x85, 0x83, 0xc7, 0xad, 0x90,
-	0x0c, 0x21, 0x25, 0x3b, 0x83, 0x99, 0x09, 0xba, 0x47, 0xdf, 0xc0, 0x77, 0xf1, 0x25, 0x3c, 0xfa,
-	0x08, 0x16, 0xbe, 0x88, 0xc5, 0x30, 0x99, 0xf0, 0x13, 0x0f, 0xd4, 0xde, 0xf2, 0x4d, 0xf7, 0x74,
-	0x7f, 0x5f, 0xf7, 0x97, 0x81, 0xda, 0x75, 0xf4, 0x8d, 0x12, 0xd1, 0x9d, 0xc7, 0x4c, 0x30, 0x54,
-	0xf6, 0x19, 0x5d, 0xb0, 0xf9, 0xd8, 0x79, 0x16, 0x46, 0x62, 0x9a, 0x8c, 0xbb, 0x3e, 0xbb, 0xee,
-	0x85, 0x2c, 0x64, 0x3d, 0x19, 0x1f, 0x27, 0x13, 0x89, 0x24, 0x90, 0x5f, 0xeb, 0x7b, 0xd8, 0x84,
-	0xf2, 0x25, 0x13, 0xd3, 0x88, 0x86, 0x58, 0xc0, 0xdd, 0x4b, 0xf2, 0xd5, 0x65, 0x09, 0x0d, 0x5c,
-	0xf2, 0x25, 0x21, 0x5c, 0x20, 0x1b, 0xca, 0x9c, 0xc4, 0x8b, 0xc8, 0x27, 0xb6, 0xd1, 0x36, 0x3a,
-	0xa6, 0x9b, 0x42, 0x74, 0x02, 0xc7, 0xf1, 0x2a, 0xd3, 0x3e, 0x6a, 0x1b, 0x9d, 0xba, 0xbb, 0x06,
-	0xe8, 0x05, 0x1c, 0xfb, 0x53, 0x2f, 0xa2, 0x76, 0xb1, 0x5d, 0xec, 0x54, 0xfb, 0xcd, 0xae, 0x62,
-	0xd5, 0xfd, 0x98, 0x8c, 0x67, 0x91, 0x3f, 0x22, 0xf1, 0x82, 0xc4, 0x03, 0x46, 0x27, 0x51, 0xe8,
-	0xae, 0x33, 0xf1, 0x5b, 0x40, 0xfb, 0x41, 0x64, 0x41, 0xf1, 0x33, 0xb9, 0x91, 0x4d, 0x6b, 0xee,
-	0xea, 0x73, 0x45, 0xc5, 0x0b, 0x82, 0x98, 0x70, 0x2e, 0x5b, 0x9a, 0x6e, 0x0a, 0x71, 0x0f, 0xac,
-	0x8c, 0x37, 0x9f, 0x33, 0xca, 0x09, 0x6a, 0x82, 0xc9, 0x68, 0xc4, 0xe8, 0x55, 0x56, 0xa5, 0x22,
-	0x0f, 0xde, 0x93, 0x1b, 0xfc, 0xdd, 0x80, 0xba, 0x4c, 0x1f, 0x11, 0x21, 0x22, 0x1a, 0xf2, 0x83,
-	0x75, 0x3e, 0x02, 0xd0, 0xe5, 0xb9, 0x14, 0x5b, 0x73, 0xcd, 0xb4, 0x3e, 0x47, 0x4f, 0xa0, 0xa6,
-	0xee, 0x5f, 0x05, 0x9e, 0xf0, 0xec, 0x3b, 0x92, 0x40, 0x55, 0x9d, 0x0d, 0x3d, 0xe1, 0xe1, 0x0b,
-	0xb8, 0x3f, 0x22, 0x62, 0x8b, 0x45, 0x3a, 0xf4, 0x3e, 0x54, 0xb8, 0x3a, 0x92, 0x6c, 0xaa, 0xfd,
-	0x86, 0x9e, 0xe3, 0xf6, 0x05, 0x9d, 0x87, 0x5f, 0x41, 0x63, 0x2b, 0x34, 0x8a, 0x42, 0xea, 0x89,
-	0x24, 0x26, 0xe8, 0x21, 0x98, 0x3c, 0x05, 0x6a, 0x12, 0xd9, 0x01, 0x3e, 0x03, 0xeb, 0x34, 0x08,
-	0x3e, 0xac, 0x98, 0xeb, 0xfe, 0x0d, 0x28, 0xb1, 0xc9, 0x84, 0x13, 0x21, 0xd3, 0xeb, 0xae, 0x42,
-	0xf2, 0x5c, 0x26, 0xda, 0x47, 0x52, 0xb0, 0x42, 0x78, 0x00, 0xf7, 0x06, 0x33, 0xc6, 0xc9, 0x6d,
-	0x9c, 0x83, 0x9f, 0x02, 0xda, 0x2c, 0xa2, 0xd6, 0xd8, 0x80, 0x52, 0x4c, 0x78, 0x32, 0x13, 0xaa,
-	0x88, 0x42, 0x78, 0x0e, 0xd6, 0x39, 0x11, 0xdb, 0xb4, 0x0f, 0xdd, 0x61, 0x26, 0xb3, 0xb8, 0x25,
-	0xf3, 0x04, 0x8e, 0x7d, 0x96, 0x50, 0x21, 0xb7, 0x56, 0x77, 0xd7, 0x60, 0x25, 0x72, 0xa3, 0x63,
-	0x46, 0xef, 0xa0, 0x49, 0x0d, 0x01, 0x0d, 0xc9, 0x8c, 0x88, 0x5b, 0x8d, 0xaa, 0xff, 0xb3, 0x08,
-	0xa5, 0x0b, 0xf9, 0xef, 0xa3, 0x53, 0xa8, 0xa4, 0xd6, 0x47, 0xb6, 0x36, 0xc9, 0xce, 0x5f, 0xec,
-	0x3c, 0xc8, 0x89, 0xac, 0x15, 0xe0, 0x02, 0xfa, 0x04, 0xd6, 0xae, 0x11, 0x51, 0x5b, 0x5f, 0xf8,
-	0x8f, 0x47, 0x9d, 0xc7, 0xf9, 0x8e, 0xd4, 0xb6, 0xc3, 0x05, 0xf4, 0x1a, 0x4c, 0x6d, 0x2d, 0x94,
-	0x51, 0xd8, 0xb5, 0x9b, 0x63, 0x65, 0xec, 0xd4, 0x43, 0x54, 0xe8, 0x18, 0xe8, 0x1c, 0x20, 0xf3,
-	0x03, 0x72, 0x74, 0xce, 0x9e, 0xd3, 0x9c, 0x66, 0x6e, 0x4c, 0xeb, 0x7b, 0x07, 0xa6, 0x5e, 0xdc,
-	0x06, 0x8d, 0x5d, 0xfb, 0x38, 0x4e, 0x5e, 0x28, 0xad, 0xf2, 0xdc, 0x40, 0x6f, 0xa0, 0xba, 0xb1,
-	0x3b, 0x94, 0x75, 0xdd, 0xdf, 0x68, 0x9e, 0xa4, 0x33, 0xeb, 0xd7, 0xb2, 0x65, 0xfc, 0x5e, 0xb6,
-	0x8c, 0x3f, 0xcb, 0x96, 0xf1, 0xe3, 0x6f, 0xab, 0x30, 0x2e, 0xc9, 0x17, 0xf8, 0xe5, 0xbf, 0x00,
-	0x00, 0x00, 0xff, 0xff, 0x98, 0x56, 0x56, 0x36, 0xc9, 0x05, 0x00, 0x00,
+	// 1076 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x56, 0xcd, 0x6e, 0x1c, 0x45,
+	0x10, 0xf6, 0xd8, 0xde, 0x9f, 0xa9, 0xf5, 0xc6, 0x4b, 0x63, 0x9c, 0x61, 0x9c, 0x38, 0x66, 0x24,
+	0x24, 0x23, 0x82, 0x0d, 0x46, 0x42, 0x48, 0x20, 0x44, 0x6c, 0x2b, 0x06, 0x25, 0x76, 0x42, 0xef,
+	0x81, 0xe3, 0x6a, 0xbc, 0xd3, 0x5e, 0xb7, 0xb2, 0xdb, 0x3d, 0x99, 0xee, 0x71, 0xe2, 0x0b, 0x12,
+	0x37, 0x8e, 0x9c, 0x78, 0x12, 0x1e, 0x82, 0x23, 0x8f, 0x80, 0xcc, 0x8b, 0x44, 0x5d, 0xdd, 0xf3,
+	0xb3, 0x3f, 0x39, 0x38, 0x39, 0xed, 0x7e, 0x5f, 0x57, 0xd7, 0x7f, 0x75, 0x0d, 0xac, 0x4d, 0xf8,
+	0x6b, 0xc1, 0xf4, 0x5e, 0x9a, 0x49, 0x2d, 0x49, 0x6b, 0x28, 0xc5, 0x95, 0x4c, 0xcf, 0xc3, 0x2f,
+	0x46, 0x5c, 0x5f, 0xe6, 0xe7, 0x7b, 0x43, 0x39, 0xd9, 0x1f, 0xc9, 0x91, 0xdc, 0xc7, 0xf3, 0xf3,
+	0xfc, 0x02, 0x11, 0x02, 0xfc, 0x67, 0xef, 0x45, 0x3e, 0xb4, 0xce, 0xa4, 0xbe, 0xe4, 0x62, 0x14,
+	0x69, 0x58, 0x3f, 0x63, 0xaf, 0xa8, 0xcc, 0x45, 0x42, 0xd9, 0xcb, 0x9c, 0x29, 0x4d, 0x02, 0x68,
+	0x29, 0x96, 0x5d, 0xf1, 0x21, 0x0b, 0xbc, 0x1d, 0x6f, 0xd7, 0xa7, 0x05, 0x24, 0x1b, 0xd0, 0xc8,
+	0x8c, 0x64, 0xb0, 0xbc, 0xe3, 0xed, 0x76, 0xa9, 0x05, 0xe4, 0x2b, 0x68, 0x0c, 0x2f, 0x63, 0x2e,
+	0x82, 0x95, 0x9d, 0x95, 0xdd, 0xce, 0xc1, 0xd6, 0x9e, 0xf3, 0x6a, 0xef, 0x79, 0x7e, 0x3e, 0xe6,
+	0xc3, 0x3e, 0xcb, 0xae, 0x58, 0x76, 0x24, 0xc5, 0x05, 0x1f, 0x51, 0x2b, 0x19, 0xfd, 0x08, 0x64,
+	0xfe, 0x90, 0xf4, 0x60, 0xe5, 0x05, 0xbb, 0x46, 0xa3, 0x6b, 0xd4, 0xfc, 0x35, 0xae, 0xc4, 0x49,
+	0x92, 0x31, 0xa5, 0xd0, 0xa4, 0x4f, 0x0b, 0x18, 0xed, 0x43, 0xaf, 0xf2, 0x5b, 0xa5, 0x52, 0x28,
+	0x46, 0xb6, 0xc0, 0x97, 0x82, 0x4b, 0x31, 0xa8, 0xb4, 0xb4, 0x91, 0x78, 0xc2, 0xae, 0xa3, 0xdf,
+	0x3d, 0xe8, 0xa2, 0x78, 0x9f, 0x69, 0xcd, 0xc5, 0x48, 0xdd, 0x3a, 0xce, 0xfb, 0x00, 0xa5, 0x7a,
+	0x85, 0xc1, 0xae, 0x51, 0xbf, 0xd0, 0xaf, 0xc8, 0x27, 0xb0, 0xe6, 0xee, 0x0f, 0x92, 0x58, 0xc7,
+	0xc1, 0x2a, 0x3a, 0xd0, 0x71, 0xdc, 0x71, 0xac, 0xe3, 0xe8, 0x14, 0xee, 0xf6, 0x99, 0x9e, 0xf2,
+	0xa2, 0x48, 0xfa, 0x01, 0xb4, 0x95, 0xa3, 0xd0, 0x9b, 0xce, 0xc1, 0x66, 0x99, 0xc7, 0xe9, 0x0b,
+	0xa5, 0x5c, 0xf4, 0x0d, 0x6c, 0x4e, 0x1d, 0xf5, 0xf9, 0x48, 0xc4, 0x3a, 0xcf, 0x18, 0xb9, 0x07,
+	0xbe, 0x2a, 0x80, 0xcb, 0x44, 0x45, 0x44, 0x87, 0xd0, 0x7b, 0x94, 0x24, 0xcf, 0x8c, 0xe7, 0xa5,
+	0xfd, 0x4d, 0x68, 0xca, 0x8b, 0x0b, 0xc5, 0x34, 0x8a, 0x77, 0xa9, 0x43, 0xc8, 0xa3, 0x60, 0xb0,
+	0x8c, 0x01, 0x3b, 0x14, 0x1d, 0xc1, 0x07, 0x47, 0x63, 0xa9, 0xd8, 0xfb, 0x74, 0x4e, 0xf4, 0x10,
+	0x48, 0x5d, 0x89, 0x2b, 0xe3, 0x26, 0x34, 0x33, 0xa6, 0xf2, 0xb1, 0x76, 0x4a, 0x1c, 0x8a, 0x52,
+	0xe8, 0x9d, 0x30, 0x3d, 0xed, 0xf6, 0x6d, 0x6b, 0x58, 0x85, 0xb9, 0x32, 0x15, 0xe6, 0x06, 0x34,
+	0x86, 0x32, 0x17, 0x1a, 0xab, 0xd6, 0xa5, 0x16, 0x98, 0x20, 0x6b, 0x16, 0x2b, 0xf7, 0x6e, 0x95,
+	0xa9, 0x63, 0x20, 0xc7, 0x6c, 0xcc, 0xf4, 0xfb, 0xa5, 0xea, 0x31, 0x6c, 0x9c, 0x30, 0x7d, 0x24,
+	0x27, 0x13, 0xae, 0x27, 0x4c, 0xe8, 0x77, 0xd5, 0xf3, 0xd7, 0x32, 0x74, 0x4f, 0xf9, 0xeb, 0x4a,
+	0xd1, 0xad, 0x53, 0x18, 0x42, 0x3b, 0x95, 0x8a, 0x6b, 0x2e, 0x85, 0x4b, 0x62, 0x89, 0xcd, 0x88,
+	0x88, 0x7c, 0x32, 0xe0, 0x22, 0xcd, 0xb5, 0x72, 0xb9, 0xf4, 0x45, 0x3e, 0xf9, 0x19, 0x09, 0x73,
+	0x8c, 0x47, 0x83, 0x4c, 0x4a, 0x1d, 0x34, 0x6c, 0x5f, 0x22, 0x43, 0xa5, 0xd4, 0xe4, 0x01, 0x74,
+	0xcc, 0x6d, 0x99, 0x6b, 0xbc, 0xde, 0xc4, 0xeb, 0x46, 0xe1, 0x33, 0xcb, 0x18, 0x01, 0x7b, 0x68,
+	0x15, 0xb4, 0x50, 0x01, 0x58, 0x0a, 0x35, 0x6c, 0x81, 0xb1, 0x36, 0x10, 0x92, 0x2b, 0x16, 0xb4,
+	0xad, 0x73, 0x22, 0x9f, 0x9c, 0x19, 0x3c, 0x3d, 0x14, 0xfe, 0xec, 0x50, 0xfc, 0x06, 0x84, 0xb2,
+	0x2b, 0x16, 0x8f, 0x9f, 0x72, 0xf1, 0xe2, 0x9d, 0xfb, 0xeb, 0x5b, 0xe8, 0x0c, 0xcb, 0xd4, 0x2a,
+	0xf7, 0x22, 0x56, 0x93, 0x3c, 0x95, 0x79, 0x5a, 0x17, 0x8d, 0xfe, 0x5e, 0x86, 0x35, 0xeb, 0x00,
+	0x4b, 0x8c, 0x0b, 0xc6, 0x00, 0xa6, 0xc6, 0xb5, 0x99, 0x05, 0xd8, 0x65, 0x18, 0xaf, 0xb3, 0xeb,
+	0x90, 0x09, 0x2e, 0xe1, 0x6a, 0x18, 0x67, 0x09, 0x4b, 0xb0, 0x2c, 0x6d, 0x5a, 0x11, 0x46, 0x97,
+	0xcd, 0xc9, 0x2a, 0x9e, 0x58, 0x60, 0x58, 0xec, 0x51, 0x57, 0x09, 0x0b, 0x4c, 0x91, 0xd4, 0x65,
+	0x9c, 0xb1, 0x04, 0x9f, 0xd1, 0xa6, 0xcb, 0x13, 0x32, 0x4f, 0xd8, 0xb5, 0xa9, 0x81, 0xad, 0x61,
+	0x9a, 0x49, 0x79, 0x11, 0xb4, 0xb0, 0xd7, 0x6d, 0x59, 0x9f, 0x1b, 0xc6, 0xbc, 0x83, 0xae, 0x48,
+	0x56, 0xa2, 0x8d, 0x12, 0xae, 0x70, 0x56, 0x04, 0x27, 0x3c, 0x1d, 0xc7, 0xd7, 0x58, 0x86, 0x36,
+	0x75, 0x88, 0x10, 0x58, 0xd5, 0xaf, 0xb8, 0x08, 0x00, 0x43, 0xc3, 0xff, 0xc6, 0x1d, 0xf3, 0xeb,
+	0x94, 0x75, 0xec, 0xab, 0x6b, 0x18, 0x54, 0x15, 0x1d, 0xc2, 0x87, 0x53, 0x65, 0x73, 0x43, 0xfa,
+	0x39, 0x34, 0xc6, 0x86, 0x08, 0x3c, 0xac, 0xc0, 0x47, 0xd5, 0x5b, 0x5a, 0x4b, 0x31, 0xb5, 0x32,
+	0xd1, 0x3a, 0x74, 0xfb, 0x3a, 0xd6, 0x79, 0x51, 0xf5, 0xe8, 0xc6, 0x83, 0x3b, 0x05, 0xe3, 0x14,
+	0x06, 0xd0, 0xba, 0x62, 0x99, 0x32, 0xd9, 0x72, 0x8d, 0xe0, 0xa0, 0x99, 0x07, 0xd7, 0x13, 0x76,
+	0xf2, 0x7d, 0x5a, 0x62, 0xf2, 0x10, 0x9a, 0xd8, 0x17, 0x45, 0x27, 0x6c, 0xcc, 0xbc, 0xe9, 0xd6,
+	0x86, 0x93, 0x21, 0x9f, 0x41, 0x2f, 0x61, 0xc3, 0xec, 0x3a, 0x35, 0xb3, 0x34, 0x78, 0x99, 0xb3,
+	0x9c, 0xb9, 0x19, 0x5a, 0xaf, 0xf8, 0x5f, 0x0c, 0x4d, 0x3e, 0x85, 0x3b, 0x79, 0xaa, 0xf9, 0x84,
+	0x0d, 0x14, 0x1b, 0x4a, 0x63, 0xc0, 0xd4, 0x70, 0x95, 0x76, 0x2d, 0xdb, 0xb7, 0xa4, 0xf1, 0x2d,
+	0xc9, 0x62, 0x2e, 0xb8, 0x18, 0x61, 0x25, 0xdb, 0xb4, 0xc4, 0x51, 0x1f, 0x3a, 0x35, 0x27, 0x6e,
+	0xdd, 0xe9, 0x1b, 0xd0, 0x48, 0x2f, 0x63, 0xc5, 0xb0, 0xd9, 0x7c, 0x6a, 0xc1, 0xc1, 0x1f, 0x0d,
+	0x68, 0x9e, 0xe2, 0x27, 0x0a, 0x79, 0x04, 0xed, 0x62, 0x43, 0x93, 0xa0, 0x8c, 0x7b, 0xe6, 0x63,
+	0x23, 0xfc, 0x78, 0xc1, 0x89, 0x4d, 0x79, 0xb4, 0x44, 0x7e, 0x85, 0xde, 0xec, 0xbe, 0x24, 0x3b,
+	0xe5, 0x85, 0xb7, 0xac, 0xd2, 0xf0, 0xc1, 0xe2, 0xc5, 0x59, 0x6e, 0xc7, 0x68, 0x89, 0x7c, 0x0f,
+	0x7e, 0xb9, 0x01, 0x49, 0xe5, 0xc2, 0xec, 0x56, 0x0c, 0x7b, 0x95, 0x77, 0xee, 0x7b, 0x69, 0x69,
+	0xd7, 0x23, 0x27, 0x00, 0xd5, 0xda, 0x22, 0x61, 0x29, 0x33, 0xb7, 0x10, 0xc3, 0xad, 0x85, 0x67,
+	0x65, 0x7c, 0x8f, 0xc1, 0x2f, 0xf7, 0x4b, 0xcd, 0x8d, 0xd9, 0x2d, 0x17, 0x86, 0x8b, 0x8e, 0x0a,
+	0x2d, 0x5f, 0x7a, 0xe4, 0x07, 0xe8, 0xd4, 0x56, 0x0c, 0xa9, 0xac, 0xce, 0x2f, 0x9e, 0x45, 0x21,
+	0x91, 0x9f, 0xa0, 0x3b, 0xb5, 0x5c, 0xc8, 0xfd, 0xba, 0xc1, 0xb9, 0xa5, 0x13, 0xbe, 0xe5, 0x41,
+	0x8b, 0x96, 0xc8, 0x53, 0xe8, 0xd4, 0xc6, 0xb1, 0xe6, 0xc9, 0xfc, 0xdb, 0x1a, 0xde, 0x5b, 0x7c,
+	0x58, 0x8b, 0xeb, 0x3b, 0x68, 0xba, 0xee, 0xac, 0x2c, 0x4e, 0x4d, 0x6a, 0x78, 0x77, 0x8e, 0x2f,
+	0xae, 0x1f, 0xf6, 0xfe, 0xb9, 0xd9, 0xf6, 0xfe, 0xbd, 0xd9, 0xf6, 0xfe, 0xbb, 0xd9, 0xf6, 0xfe,
+	0xfc, 0x7f, 0x7b, 0xe9, 0xbc, 0x89, 0x5f, 0xbf, 0x5f, 0xbf, 0x19, 0x00, 0x41, 0x1c, 0xad, 0x23,
+	0x45, 0x0b, 0x00, 0x00,
 }
@@ -0,0 +1,87 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package mixnet
+
+import (
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"vuvuzela.io/alpenhorn/log"
+)
+
+// drainPollInterval is how often Shutdown checks for remaining rounds.
+const drainPollInterval = 100 * time.Millisecond
+
+// Drain makes the server refuse to start new rounds. Rounds that were
+// already started can still run to completion. Draining can not be
+// undone; it is meant for stopping or upgrading a mixer without
+// breaking the rounds that are in flight.
+func (srv *Server) Drain() {
+	if atomic.CompareAndSwapInt32(&srv.draining, 0, 1) {
+		log.WithFields(log.Fields{"rounds": srv.numRounds()}).Info("Draining")
+	}
+}
+
+// Draining reports whether Drain has been called.
+func (srv *Server) Draining() bool {
+	return atomic.LoadInt32(&srv.draining) == 1
+}
+
+// Shutdown drains the server and waits until the coordinator deletes
+// the remaining rounds, or until ctx is done. Either way, it erases the
+// key material of all rounds and shuffle audits before returning, so
+// the server must not be used afterwards. Shutdown returns ctx.Err()
+// if some rounds had to be cut short.
+func (srv *Server) Shutdown(ctx context.Context) error {
+	srv.Drain()
+
+	var err error
+	for srv.numRounds() > 0 {
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-time.After(drainPollInterval):
+			continue
+		}
+		break
+	}
+
+	srv.roundsMu.Lock()
+	states := make([]*roundState, 0, len(srv.rounds))
+	for key, st := range srv.rounds {
+		log.WithFields(log.Fields{
+			"service": key.Service,
+			"round":   key.Round,
+			"stage":   roundStage(atomic.LoadInt32(&st.stage)),
+		}).Warn("Abandoning round")
+		states = append(states, st)
+		delete(srv.rounds, key)
+		activeRounds.Dec()
+	}
+	srv.roundsMu.Unlock()
+
+	for _, st := range states {
+		st.zeroize()
+	}
+
+	srv.auditsMu.Lock()
+	for key, audit := range srv.audits {
+		audit.zeroize()
+		delete(srv.audits, key)
+	}
+	srv.auditOrder = nil
+	srv.auditsMu.Unlock()
+
+	return err
+}
+
+func (srv *Server) numRounds() int {
+	srv.roundsMu.RLock()
+	n := len(srv.rounds)
+	srv.roundsMu.RUnlock()
+	return n
+}
@@ -0,0 +1,62 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package mixnet
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestShutdown(t *testing.T) {
+	srv := &Server{
+		rounds: make(map[serviceRound]*roundState),
+	}
+
+	st := &roundState{
+		onionPrivateKey: &[32]byte{1, 2, 3},
+	}
+	srv.enterStage(st, stageOpen)
+	srv.rounds[serviceRound{"Convo", 1}] = st
+
+	// The round is deleted while the server drains.
+	go func() {
+		time.Sleep(2 * drainPollInterval)
+		srv.roundsMu.Lock()
+		delete(srv.rounds, serviceRound{"Convo", 1})
+		srv.roundsMu.Unlock()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %s", err)
+	}
+	if !srv.Draining() {
+		t.Fatalf("server is not draining after Shutdown")
+	}
+
+	// Rounds that do not finish in time are erased.
+	stuck := &roundState{
+		onionPrivateKey: &[32]byte{1, 2, 3},
+		sharedKeys:      [][32]byte{{4, 5, 6}},
+	}
+	srv.enterStage(stuck, stageOpen)
+	srv.rounds[serviceRound{"Convo", 2}] = stuck
+	stuckKey := stuck.onionPrivateKey
+	stuckShared := stuck.sharedKeys
+
+	ctx, cancel = context.WithTimeout(context.Background(), 2*drainPollInterval)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := srv.getRound("Convo", 2); err == nil {
+		t.Fatalf("round was not deleted")
+	}
+	if *stuckKey != [32]byte{} || stuckShared[0] != [32]byte{} {
+		t.Fatalf("key material was not erased")
+	}
+}
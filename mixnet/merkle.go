@@ -0,0 +1,121 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package mixnet
+
+import (
+	"bytes"
+	"crypto/sha256"
+
+	"vuvuzela.io/concurrency"
+)
+
+// merkleTree is a binary hash tree over a batch of onions. Leaves and
+// interior nodes are hashed with different prefixes so that a leaf can
+// not be passed off as an interior node. If a level has an odd number
+// of nodes, the last node is promoted to the next level unchanged.
+type merkleTree struct {
+	// levels[0] holds the leaf hashes; the last level holds the root.
+	levels [][][32]byte
+}
+
+func merkleLeaf(data []byte) [32]byte {
+	h := sha256.New()
+	h.Write([]byte{0})
+	h.Write(data)
+	var sum [32]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+func merkleNode(left, right *[32]byte) [32]byte {
+	var buf [65]byte
+	buf[0] = 1
+	copy(buf[1:33], left[:])
+	copy(buf[33:65], right[:])
+	return sha256.Sum256(buf[:])
+}
+
+func newMerkleTree(data [][]byte) *merkleTree {
+	leaves := make([][32]byte, len(data))
+	concurrency.ParallelFor(len(data), func(p *concurrency.P) {
+		for i, ok := p.Next(); ok; i, ok = p.Next() {
+			leaves[i] = merkleLeaf(data[i])
+		}
+	})
+
+	tree := &merkleTree{
+		levels: [][][32]byte{leaves},
+	}
+	for level := leaves; len(level) > 1; {
+		next := make([][32]byte, (len(level)+1)/2)
+		for i := range next {
+			if 2*i+1 < len(level) {
+				next[i] = merkleNode(&level[2*i], &level[2*i+1])
+			} else {
+				next[i] = level[2*i]
+			}
+		}
+		tree.levels = append(tree.levels, next)
+		level = next
+	}
+	return tree
+}
+
+// Root returns the root hash of the tree. The root of an empty tree
+// is all zeros.
+func (t *merkleTree) Root() []byte {
+	top := t.levels[len(t.levels)-1]
+	if len(top) == 0 {
+		return make([]byte, 32)
+	}
+	root := top[0]
+	return root[:]
+}
+
+// Proof returns the sibling hashes on the path from leaf i to the root.
+func (t *merkleTree) Proof(i int) [][]byte {
+	var proof [][]byte
+	for _, level := range t.levels[:len(t.levels)-1] {
+		sibling := i ^ 1
+		if sibling < len(level) {
+			h := level[sibling]
+			proof = append(proof, h[:])
+		}
+		i /= 2
+	}
+	return proof
+}
+
+// MerkleRoot returns the root of the hash tree over data that mixers
+// use to commit to their input and output batches.
+func MerkleRoot(data [][]byte) []byte {
+	return newMerkleTree(data).Root()
+}
+
+// verifyMerkleProof checks that data is leaf i of the n-leaf tree with
+// the given root.
+func verifyMerkleProof(root []byte, n, i int, data []byte, proof [][]byte) bool {
+	if i < 0 || i >= n {
+		return false
+	}
+	h := merkleLeaf(data)
+	for size := n; size > 1; size = (size + 1) / 2 {
+		if i%2 == 1 || i+1 < size {
+			if len(proof) == 0 || len(proof[0]) != 32 {
+				return false
+			}
+			var sibling [32]byte
+			copy(sibling[:], proof[0])
+			proof = proof[1:]
+			if i%2 == 1 {
+				h = merkleNode(&sibling, &h)
+			} else {
+				h = merkleNode(&h, &sibling)
+			}
+		}
+		i /= 2
+	}
+	return len(proof) == 0 && bytes.Equal(h[:], root)
+}
@@ -0,0 +1,94 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package mixnet
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Prometheus metrics for mixers. The Client metrics are also
+// reported by the coordinator, which uses a Client to talk to
+// the first mixer.
+var (
+	activeRounds = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "vuvuzela",
+		Subsystem: "mixnet",
+		Name:      "active_rounds",
+		Help:      "Number of rounds the mixer is keeping state for.",
+	})
+
+	roundOnions = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "vuvuzela",
+		Subsystem: "mixnet",
+		Name:      "round_onions",
+		Help:      "Number of onions a mixer received per round.",
+		Buckets:   prometheus.ExponentialBuckets(16, 4, 10),
+	}, []string{"service"})
+
+	decryptionFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "vuvuzela",
+		Subsystem: "mixnet",
+		Name:      "decryption_failures_total",
+		Help:      "Number of incoming onions that could not be decrypted, by reason.",
+	}, []string{"service", "reason"})
+
+	duplicateOnions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "vuvuzela",
+		Subsystem: "mixnet",
+		Name:      "duplicates_total",
+		Help:      "Number of replayed onions dropped by the mixer.",
+	}, []string{"service"})
+
+	noiseOnions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "vuvuzela",
+		Subsystem: "mixnet",
+		Name:      "noise_onions_total",
+		Help:      "Number of noise onions generated by the mixer.",
+	}, []string{"service"})
+
+	handleMessagesSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "vuvuzela",
+		Subsystem: "mixnet",
+		Name:      "handle_messages_seconds",
+		Help:      "Time the last mixer spent in HandleMessages.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10),
+	}, []string{"service"})
+
+	rpcSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "vuvuzela",
+		Subsystem: "mixnet",
+		Name:      "client_rpc_seconds",
+		Help:      "Time spent sending onions to (AddOnions) or fetching onions from (GetOnions) the next mixer.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10),
+	}, []string{"service", "rpc"})
+
+	rpcBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "vuvuzela",
+		Subsystem: "mixnet",
+		Name:      "client_rpc_bytes_total",
+		Help:      "Onion bytes sent to (AddOnions) or fetched from (GetOnions) the next mixer.",
+	}, []string{"service", "rpc"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		activeRounds,
+		roundOnions,
+		decryptionFailures,
+		duplicateOnions,
+		noiseOnions,
+		handleMessagesSeconds,
+		rpcSeconds,
+		rpcBytes,
+	)
+}
+
+func onionBytes(onions [][]byte) int {
+	n := 0
+	for _, onion := range onions {
+		n += len(onion)
+	}
+	return n
+}
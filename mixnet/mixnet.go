@@ -64,25 +64,62 @@ type Server struct {
 
 	Services map[string]MixService
 
+	// AuditShuffles makes the server commit to its input and output
+	// batches in every round and answer shuffle audits (see audit.go).
+	AuditShuffles bool
+
+	// RoundTTLs controls when stale rounds are reaped (see reaper.go).
+	RoundTTLs RoundTTLs
+
+	// ReplayFilter drops onions that were already seen in recent rounds
+	// (see replay.go). If nil, only replays within a round are dropped.
+	ReplayFilter *ReplayFilter
+
+	// ChainChecker, if set, makes NewRound reject chains that it does
+	// not accept, such as chains that differ from the signed config or
+	// were not picked from the mixer pool (see pool.go).
+	ChainChecker ChainChecker
+
 	roundsMu sync.RWMutex
 	rounds   map[serviceRound]*roundState
 
+	auditsMu   sync.Mutex
+	audits     map[serviceRound]*roundAudit
+	auditOrder []serviceRound
+
+	// draining is accessed atomically (see drain.go).
+	draining int32
+
 	once           sync.Once
 	mixClient      *Client
 	decryptionJobs chan decryptionJob
 }
 
+// A ChainChecker decides whether a mixer should take part in a round
+// with the given chain. Without one, a mixer accepts any chain that the
+// coordinator sends, as long as the mixer is on it.
+type ChainChecker interface {
+	CheckChain(service string, round uint32, chain []PublicServerConfig) error
+}
+
 type serviceRound struct {
 	Service string
 	Round   uint32
 }
 
 type roundState struct {
+	// expires and stage are accessed atomically by the reaper;
+	// expires is first so that it is 64-bit aligned.
+	expires int64
+	stage   int32
+
 	mu                sync.Mutex
 	settings          RoundSettings
 	settingsSignature []byte
 	numIncoming       uint32
 	incoming          [][]byte
+	rawIncoming       [][]byte
+	tags              []replayTag
 	sharedKeys        [][32]byte
 	incomingIndex     []int
 	replies           [][]byte
@@ -92,6 +129,7 @@ type roundState struct {
 	closed            bool
 	closeResult       string
 	err               error
+	audited           bool
 
 	chain             []PublicServerConfig
 	myPos             int
@@ -111,6 +149,20 @@ type PublicServerConfig struct {
 	Address string
 }
 
+// EqualChains reports whether a and b list the same servers in the
+// same order.
+func EqualChains(a, b []PublicServerConfig) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !bytes.Equal(a[i].Key, b[i].Key) || a[i].Address != b[i].Address {
+			return false
+		}
+	}
+	return true
+}
+
 func (c PublicServerConfig) Proto() *pb.PublicServerConfig {
 	return &pb.PublicServerConfig{
 		Key:     c.Key,
@@ -139,7 +191,7 @@ func (srv *Server) getRound(service string, round uint32) (*roundState, error) {
 	}
 	srv.roundsMu.RUnlock()
 	if !ok {
-		return nil, fmt.Errorf("round %d not found", round)
+		return nil, status.Errorf(codes.NotFound, "round %d not found", round)
 	}
 	return st, nil
 }
@@ -194,6 +246,8 @@ func (srv *Server) NewRound(ctx context.Context, req *pb.NewRoundRequest) (*pb.N
 		for i := 0; i < workers; i++ {
 			go decryptionWorker(srv.decryptionJobs)
 		}
+
+		go srv.reapLoop()
 	})
 
 	log.WithFields(log.Fields{"rpc": "NewRound", "round": req.Round}).Info()
@@ -214,6 +268,9 @@ func (srv *Server) NewRound(ctx context.Context, req *pb.NewRoundRequest) (*pb.N
 			OnionKey: st.onionPublicKey[:],
 		}, nil
 	}
+	if srv.Draining() {
+		return nil, status.Errorf(codes.Unavailable, "server is draining")
+	}
 
 	public, private, err := box.GenerateKey(cryptoRand.Reader)
 	if err != nil {
@@ -234,6 +291,11 @@ func (srv *Server) NewRound(ctx context.Context, req *pb.NewRoundRequest) (*pb.N
 	if myPos == -1 {
 		return nil, errors.New("my key is not in the chain")
 	}
+	if srv.ChainChecker != nil {
+		if err := srv.ChainChecker.CheckChain(req.Service, req.Round, chain); err != nil {
+			return nil, status.Errorf(codes.PermissionDenied, "%s", err)
+		}
+	}
 
 	st = &roundState{
 		encryptDone: make(chan struct{}),
@@ -245,10 +307,12 @@ func (srv *Server) NewRound(ctx context.Context, req *pb.NewRoundRequest) (*pb.N
 		onionPublicKey:    public,
 		onionPrivateKey:   private,
 	}
+	srv.enterStage(st, stageNew)
 
 	srv.roundsMu.Lock()
 	srv.rounds[serviceRound{req.Service, req.Round}] = st
 	srv.roundsMu.Unlock()
+	activeRounds.Inc()
 
 	return &pb.NewRoundResponse{
 		OnionKey: public[:],
@@ -307,6 +371,7 @@ func (srv *Server) SetRoundSettings(ctx context.Context, req *pb.SetRoundSetting
 	st.settings = settings
 	sig := ed25519.Sign(srv.SigningKey, settings.SigningMessage())
 	st.settingsSignature = sig
+	srv.enterStage(st, stageOpen)
 
 	service, ok := srv.Services[settings.Service]
 	if !ok {
@@ -338,16 +403,21 @@ func decryptionWorker(in chan decryptionJob) {
 		offset := int(job.req.Offset)
 		expectedSize := st.incomingOnionSize
 		for i, onion := range job.req.Onions {
+			if st.rawIncoming != nil {
+				st.rawIncoming[offset+i] = onion
+			}
 			if len(onion) != expectedSize {
 				log.Infof("onion is unexpected size: got %d, want %d", len(onion), expectedSize)
+				decryptionFailures.WithLabelValues(st.settings.Service, "size").Inc()
 				continue
 			}
 
 			copy(theirPublic[:], onion[0:32])
 			var msg []byte
 			var ok bool
-			if st.bidirectional {
-				// Precompute and save the key for the reverse direction in bidirectional mode.
+			if st.sharedKeys != nil {
+				// Precompute and save the key for the reverse direction in bidirectional mode,
+				// or to reveal it in a shuffle audit.
 				box.Precompute(&st.sharedKeys[offset+i], &theirPublic, st.onionPrivateKey)
 				// TODO we could avoid allocating here, but is it worth it?
 				msg, ok = box.OpenAfterPrecomputation(nil, onion[32:], nonce, &st.sharedKeys[offset+i])
@@ -355,8 +425,11 @@ func decryptionWorker(in chan decryptionJob) {
 				msg, ok = box.Open(nil, onion[32:], nonce, &theirPublic, st.onionPrivateKey)
 			}
 			st.incoming[offset+i] = msg
-			if !ok {
+			if ok {
+				st.tags[offset+i] = newReplayTag(st.settings.Service, onion)
+			} else {
 				log.WithFields(log.Fields{"srv": st.myPos, "rpc": "AddOnions", "service": st.settings.Service, "round": job.round}).Errorf("Decrypting onion failed (%d bytes)", len(onion))
+				decryptionFailures.WithLabelValues(st.settings.Service, "decrypt").Inc()
 			}
 		}
 
@@ -425,10 +498,14 @@ func (srv *Server) AddOnions(stream pb.Mixnet_AddOnionsServer) error {
 		st.acceptingOnions = true
 		st.numIncoming = md.numIncoming
 		st.incoming = make([][]byte, st.numIncoming)
-		if st.bidirectional {
+		st.tags = make([]replayTag, st.numIncoming)
+		if st.bidirectional || srv.AuditShuffles {
 			// Allocate all the shared keys upfront.
 			st.sharedKeys = make([][32]byte, st.numIncoming)
 		}
+		if srv.AuditShuffles {
+			st.rawIncoming = make([][]byte, st.numIncoming)
+		}
 	} else if st.numIncoming != md.numIncoming {
 		st.mu.Unlock()
 		return errors.New("round %d: multiple values for numIncoming: got %d, want %d", round, md.numIncoming, st.numIncoming)
@@ -463,29 +540,45 @@ func (srv *Server) AddOnions(stream pb.Mixnet_AddOnionsServer) error {
 	return nil
 }
 
+// filterIncoming drops the onions that failed to decrypt or were
+// replayed, and records where the remaining messages came from.
 func (srv *Server) filterIncoming(st *roundState) {
 	incomingValid := make([][]byte, 0, len(st.incoming))
 	incomingIndex := make([]int, len(st.incoming))
 
-	seen := make(map[uint64]bool)
+	tags := make([]replayTag, 0, len(st.incoming))
+	for i, msg := range st.incoming {
+		if msg != nil {
+			tags = append(tags, st.tags[i])
+		}
+	}
+	filter := srv.ReplayFilter
+	if filter == nil {
+		filter = new(ReplayFilter)
+	}
+	replayed := filter.check(st.settings.Service, st.settings.Round, tags)
+
+	duplicates := 0
+	j := 0
 	for i, msg := range st.incoming {
 		if msg == nil {
 			incomingIndex[i] = -1
 			continue
 		}
-		// last 8 bytes because key is at the beginning
-		msgkey := binary.BigEndian.Uint64(msg[len(msg)-8:])
-		if seen[msgkey] {
+		if replayed[j] {
 			incomingIndex[i] = -1
+			duplicates++
 		} else {
-			seen[msgkey] = true
 			incomingIndex[i] = len(incomingValid)
 			incomingValid = append(incomingValid, msg)
 		}
+		j++
 	}
 
 	st.incoming = incomingValid
 	st.incomingIndex = incomingIndex
+	st.tags = nil
+	duplicateOnions.WithLabelValues(st.settings.Service).Add(float64(duplicates))
 }
 
 func (srv *Server) CloseRound(ctx context.Context, req *pb.CloseRoundRequest) (*pb.CloseRoundResponse, error) {
@@ -515,6 +608,7 @@ func (srv *Server) CloseRound(ctx context.Context, req *pb.CloseRoundRequest) (*
 	defer st.mu.Unlock()
 
 	st.closed = true
+	srv.enterStage(st, stageClosed)
 
 	logger := log.WithFields(log.Fields{
 		"srv":    st.myPos,
@@ -523,10 +617,12 @@ func (srv *Server) CloseRound(ctx context.Context, req *pb.CloseRoundRequest) (*
 		"onions": len(st.incoming),
 	})
 	logger.Info("Closing round")
+	roundOnions.WithLabelValues(req.Service).Observe(float64(len(st.incoming)))
 
 	srv.filterIncoming(st)
 
 	<-st.noiseDone
+	noiseOnions.WithLabelValues(req.Service).Add(float64(len(st.noise)))
 	numNonNoise := len(st.incoming)
 	outgoing := st.incoming
 	if len(st.noise) > 0 {
@@ -540,6 +636,10 @@ func (srv *Server) CloseRound(ctx context.Context, req *pb.CloseRoundRequest) (*
 		shuffler := shuffle.New(rand.Reader, len(outgoing))
 		shuffler.Shuffle(outgoing)
 
+		if st.rawIncoming != nil {
+			srv.commitRound(st, numNonNoise, shuffler, outgoing)
+		}
+
 		if st.bidirectional {
 			replies, err := srv.mixClient.RunRoundBidirectional(ctx, st.chain[st.myPos+1], req.Service, req.Round, outgoing)
 			if err != nil {
@@ -569,6 +669,10 @@ func (srv *Server) CloseRound(ctx context.Context, req *pb.CloseRoundRequest) (*
 			}, nil
 		}
 	} else {
+		if st.rawIncoming != nil {
+			srv.commitRound(st, numNonNoise, nil, nil)
+		}
+
 		// Last server doesn't shuffle, but HandleMessages may choose to do so.
 		start := time.Now()
 		result, err := srv.Services[req.Service].HandleMessages(st.settings, outgoing)
@@ -577,6 +681,7 @@ func (srv *Server) CloseRound(ctx context.Context, req *pb.CloseRoundRequest) (*
 			st.err = err
 			return &pb.CloseRoundResponse{}, st.err
 		}
+		handleMessagesSeconds.WithLabelValues(req.Service).Observe(duration.Seconds())
 		logger.WithFields(log.Fields{"duration": duration}).Infof("Handled messages")
 
 		st.incoming = nil
@@ -665,14 +770,25 @@ func (srv *Server) DeleteRound(ctx context.Context, req *pb.DeleteRoundRequest)
 		return nil, err
 	}
 	if err := srv.authPrev(ctx, st); err != nil {
-		return nil, err
+		// The coordinator can also delete rounds that it left
+		// behind by crashing.
+		if srv.auth(ctx, srv.CoordinatorKey) != nil {
+			return nil, err
+		}
 	}
 
 	log.WithFields(log.Fields{"rpc": "DeleteRound", "round": req.Round}).Info()
 
+	key := serviceRound{req.Service, req.Round}
 	srv.roundsMu.Lock()
-	delete(srv.rounds, serviceRound{req.Service, req.Round})
+	if _, ok := srv.rounds[key]; ok {
+		delete(srv.rounds, key)
+		activeRounds.Dec()
+	}
 	srv.roundsMu.Unlock()
+
+	go st.zeroize()
+
 	return nil, nil
 }
 
@@ -881,6 +997,8 @@ func (c *Client) addOnions(ctx context.Context, server PublicServerConfig, servi
 		return nil, errors.Wrap(addErr, "adding onions")
 	}
 	duration := time.Now().Sub(start)
+	rpcSeconds.WithLabelValues(service, "AddOnions").Observe(duration.Seconds())
+	rpcBytes.WithLabelValues(service, "AddOnions").Add(float64(onionBytes(onions)))
 	sizeOnion := 0
 	if len(onions) > 0 {
 		sizeOnion = len(onions[0])
@@ -902,6 +1020,26 @@ func (c *Client) addOnions(ctx context.Context, server PublicServerConfig, servi
 	return closeResp, nil
 }
 
+// DeleteRound deletes the round from the server. It is not an error
+// if the server does not have the round.
+func (c *Client) DeleteRound(ctx context.Context, server PublicServerConfig, service string, round uint32) error {
+	conn, err := c.getConn(server)
+	if err != nil {
+		return err
+	}
+	_, err = conn.DeleteRound(ctx, &pb.DeleteRoundRequest{
+		Service: service,
+		Round:   round,
+	})
+	if s, ok := status.FromError(err); ok && s.Code() == codes.NotFound {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "server %s: DeleteRound", server.Address)
+	}
+	return nil
+}
+
 func (c *Client) RunRoundUnidirectional(ctx context.Context, server PublicServerConfig, service string, round uint32, onions [][]byte) (string, error) {
 	resp, err := c.addOnions(ctx, server, service, round, onions)
 
@@ -981,6 +1119,8 @@ func (c *Client) RunRoundBidirectional(ctx context.Context, server PublicServerC
 		return nil, errors.Wrap(getErr, "fetching onions")
 	}
 	duration := time.Now().Sub(start)
+	rpcSeconds.WithLabelValues(service, "GetOnions").Observe(duration.Seconds())
+	rpcBytes.WithLabelValues(service, "GetOnions").Add(float64(onionBytes(replies)))
 	log.WithFields(log.Fields{"round": round, "duration": duration, "onions": len(onions)}).Infof("RunRound: fetched onions from mixer")
 
 	// Delete the round asynchronously.
@@ -8,6 +8,7 @@ import (
 	"bytes"
 	"context"
 	"crypto/rand"
+	"encoding/json"
 	"flag"
 	"os"
 	"runtime/pprof"
@@ -26,6 +27,7 @@ import (
 	"vuvuzela.io/crypto/onionbox"
 	"vuvuzela.io/vuvuzela/convo"
 	"vuvuzela.io/vuvuzela/mixnet"
+	pb "vuvuzela.io/vuvuzela/mixnet/convopb"
 )
 
 func TestMixnet(t *testing.T) {
@@ -134,6 +136,252 @@ func TestAuth(t *testing.T) {
 	}
 }
 
+func TestStatus(t *testing.T) {
+	coordinatorPublic, coordinatorPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	otherPublic, otherPrivate, _ := ed25519.GenerateKey(rand.Reader)
+
+	mixchain := mock.LaunchMixchain(3, coordinatorPublic)
+
+	coordinatorClient := &mixnet.Client{
+		Key: coordinatorPrivate,
+	}
+	settings := &mixnet.RoundSettings{
+		Service: "Convo",
+		Round:   42,
+	}
+	_, err := coordinatorClient.NewRound(context.Background(), mixchain.Servers, settings)
+	if err != nil {
+		t.Fatalf("mixnet.NewRound: %s", err)
+	}
+
+	// Any client key can ask for the status.
+	client := &mixnet.Client{
+		Key: otherPrivate,
+	}
+	for i, server := range mixchain.Servers {
+		st, err := client.Status(context.Background(), server)
+		if err != nil {
+			t.Fatalf("mixer %d: %s", i, err)
+		}
+		if st.Version != mixnet.Version {
+			t.Fatalf("mixer %d: unexpected version: %q", i, st.Version)
+		}
+		if len(st.Services) != 1 || st.Services[0] != "Convo" {
+			t.Fatalf("mixer %d: unexpected services: %v", i, st.Services)
+		}
+		if len(st.Rounds) != 1 || st.Rounds[0].Round != 42 || st.Rounds[0].Phase != "open" {
+			t.Fatalf("mixer %d: unexpected rounds: %v", i, st.Rounds)
+		}
+	}
+
+	// Status fails if a mixer does not present the expected key.
+	server := mixchain.Servers[1]
+	server.Key = otherPublic
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = client.Status(ctx, server)
+	if err == nil {
+		t.Fatalf("expected error for mixer with the wrong key")
+	}
+}
+
+func TestAudit(t *testing.T) {
+	coordinatorPublic, coordinatorPrivate, _ := ed25519.GenerateKey(rand.Reader)
+
+	mixchain := mock.LaunchAuditedMixchain(4, coordinatorPublic)
+
+	coordinatorClient := &mixnet.Client{
+		Key: coordinatorPrivate,
+	}
+
+	settings := &mixnet.RoundSettings{
+		Service: "Convo",
+		Round:   7,
+	}
+	_, err := coordinatorClient.NewRound(context.Background(), mixchain.Servers, settings)
+	if err != nil {
+		t.Fatalf("mixnet.NewRound: %s", err)
+	}
+
+	_, onions, _ := makeConvoOnions(settings)
+	// A replayed onion and a malformed onion are discarded by the first mixer.
+	onions = append(onions, onions[0], make([]byte, len(onions[0])))
+
+	_, err = coordinatorClient.RunRoundBidirectional(context.Background(), mixchain.Servers[0], "Convo", settings.Round, onions)
+	if err != nil {
+		t.Fatalf("mixnet.RunRound: %s", err)
+	}
+
+	evidence, err := coordinatorClient.Audit(context.Background(), mixchain.Servers, "Convo", settings.Round)
+	if err != nil {
+		t.Fatalf("Audit: %s", err)
+	}
+	evidence.InputRoot = mixnet.MerkleRoot(onions)
+
+	// Evidence is published as JSON.
+	data, err := json.Marshal(evidence)
+	if err != nil {
+		t.Fatal(err)
+	}
+	evidence = new(mixnet.AuditEvidence)
+	if err := json.Unmarshal(data, evidence); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := evidence.Verify()
+	if err != nil {
+		t.Fatalf("Verify: %s", err)
+	}
+	for _, result := range results {
+		if !result.OK() {
+			t.Fatalf("mixer %d failed audit: %s", result.Position, result.Error)
+		}
+	}
+	if results[0].Duplicates != 1 || results[0].Invalid != 1 || results[0].Replays != 0 {
+		t.Fatalf("unexpected discards at first mixer: %+v", results[0])
+	}
+
+	// The replay points to the original instead of revealing the key
+	// that opens both of them.
+	replay := uint32(len(onions) - 2)
+	var replayLink *pb.RevealedLink
+	nonce := mixnet.ForwardNonce(settings.Round)
+	for _, link := range evidence.Links[0] {
+		if link.Input == replay {
+			replayLink = link
+		}
+		if link.Discarded && len(link.SharedKey) == 32 {
+			key := new([32]byte)
+			copy(key[:], link.SharedKey)
+			if _, ok := box.OpenAfterPrecomputation(nil, onions[0][32:], nonce, key); ok {
+				t.Fatalf("audit revealed the key of the replayed onion")
+			}
+		}
+	}
+	if replayLink == nil || !replayLink.Discarded || !replayLink.Replay || replayLink.Twin != 0 || len(replayLink.SharedKey) != 0 {
+		t.Fatalf("unexpected link for the replayed onion: %+v", replayLink)
+	}
+
+	// The replay must point to an identical onion.
+	replayLink.Twin = 1
+	results, err = evidence.Verify()
+	if err != nil {
+		t.Fatalf("Verify: %s", err)
+	}
+	if results[0].OK() {
+		t.Fatalf("expected first mixer to fail audit with the wrong twin")
+	}
+	replayLink.Twin = 0
+	if results[1].Revealed == 0 {
+		t.Fatalf("second mixer revealed nothing: %+v", results[1])
+	}
+
+	// Tamper with a revealed link.
+	for _, link := range evidence.Links[1] {
+		if !link.Noise && !link.Discarded {
+			link.Output++
+			break
+		}
+	}
+	results, err = evidence.Verify()
+	if err != nil {
+		t.Fatalf("Verify: %s", err)
+	}
+	if results[1].OK() {
+		t.Fatalf("expected second mixer to fail audit after tampering")
+	}
+
+	evidence.Commitments[2].NumNoise++
+	if _, err := evidence.Verify(); err == nil {
+		t.Fatalf("expected error for modified commitment")
+	}
+}
+
+func TestReplays(t *testing.T) {
+	coordinatorPublic, coordinatorPrivate, _ := ed25519.GenerateKey(rand.Reader)
+
+	mixchain := mock.LaunchMixchain(3, coordinatorPublic)
+
+	coordinatorClient := &mixnet.Client{
+		Key: coordinatorPrivate,
+	}
+
+	var earlier [][]byte
+	var earlierKeys [][]*[32]byte
+	for round := uint32(1); round <= 2; round++ {
+		settings := &mixnet.RoundSettings{
+			Service: "Convo",
+			Round:   round,
+		}
+		_, err := coordinatorClient.NewRound(context.Background(), mixchain.Servers, settings)
+		if err != nil {
+			t.Fatalf("mixnet.NewRound: %s", err)
+		}
+
+		msgAlice := &convo.DeadDropMessage{}
+		rand.Read(msgAlice.DeadDrop[:])
+		rand.Read(msgAlice.EncryptedMessage[:])
+		// Charlie's message ends with the same bytes as Alice's,
+		// but it is not a replay.
+		msgCharlie := &convo.DeadDropMessage{}
+		rand.Read(msgCharlie.DeadDrop[:])
+		rand.Read(msgCharlie.EncryptedMessage[:])
+		n := len(msgAlice.EncryptedMessage)
+		copy(msgCharlie.EncryptedMessage[n-8:], msgAlice.EncryptedMessage[n-8:])
+
+		nonce := mixnet.ForwardNonce(round)
+		var messages, onions [][]byte
+		var onionKeys [][]*[32]byte
+		for _, ddmsg := range []*convo.DeadDropMessage{msgAlice, msgCharlie} {
+			onion, keys := onionbox.Seal(ddmsg.Marshal(), nonce, settings.OnionKeys)
+			messages = append(messages, ddmsg.EncryptedMessage[:])
+			onions = append(onions, onion)
+			onionKeys = append(onionKeys, keys)
+		}
+		// Replay Charlie's onion in the same round.
+		onions = append(onions, onions[1])
+		if earlier != nil {
+			// Replay Alice's onion from the previous round.
+			onions = append(onions, earlier[0])
+		}
+
+		replies, err := coordinatorClient.RunRoundBidirectional(context.Background(), mixchain.Servers[0], "Convo", round, onions)
+		if err != nil {
+			t.Fatalf("mixnet.RunRound: %s", err)
+		}
+		if len(replies) != len(onions) {
+			t.Fatalf("unexpected number of reply onions: got %d, want %d", len(replies), len(onions))
+		}
+
+		backNonce := mixnet.BackwardNonce(round)
+		for i := range messages {
+			msg, ok := onionbox.Open(replies[i], backNonce, onionKeys[i])
+			if !ok {
+				t.Fatalf("round %d: failed to open reply onion at position %d", round, i)
+			}
+			if !bytes.Equal(msg, messages[i]) {
+				t.Fatalf("round %d: unexpected message at position %d", round, i)
+			}
+		}
+		// The replayed onion gets a random reply instead of Charlie's message.
+		if _, ok := onionbox.Open(replies[2], backNonce, onionKeys[1]); ok {
+			t.Fatalf("round %d: replayed onion was mixed", round)
+		}
+		// So does Alice's onion from the previous round.
+		if earlier != nil {
+			if _, ok := onionbox.Open(replies[3], mixnet.BackwardNonce(round-1), earlierKeys[0]); ok {
+				t.Fatalf("round %d: onion replayed from round %d was mixed", round, round-1)
+			}
+			if _, ok := onionbox.Open(replies[3], backNonce, earlierKeys[0]); ok {
+				t.Fatalf("round %d: onion replayed from round %d was mixed", round, round-1)
+			}
+		}
+
+		earlier = onions
+		earlierKeys = onionKeys
+	}
+}
+
 var chainLen = flag.Int("chainlen", 6, "chain length in TestLongerMixnet")
 var numMsgs = flag.Int("numMsgs", 1000, "number of messages in TestLongerMixnet")
 var createProfile = flag.Bool("profile", false, "create mixnet RunRound profile")
@@ -0,0 +1,150 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package mixnet
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+
+	"golang.org/x/crypto/ed25519"
+
+	"vuvuzela.io/alpenhorn/errors"
+)
+
+// A MixPool is a set of mixers from which every round's chains are
+// chosen, instead of using the same chains in every round.
+//
+// The chains for a round are a deterministic function of the pool, its
+// seed, the service, and the round number, so anyone with the signed
+// config can check them. The seed is random and chosen when the config
+// is signed, so operators can not arrange for their mixers to be picked
+// together by choosing their keys. Chains are predictable once the
+// config is out.
+//
+// The coordinator picks the round numbers, so on its own a pool would
+// let it run only the rounds whose chains it likes. Convo configs
+// therefore pair a pool with a wall-clock schedule, which fixes when
+// every round runs, and clients report scheduled rounds that the
+// coordinator skips.
+//
+// The seed does not include signatures from earlier rounds: the
+// coordinator decides which rounds complete, so it could abort rounds
+// until their signatures pick a chain it likes, and every verifier
+// would need the history of earlier rounds.
+type MixPool struct {
+	Mixers []PublicServerConfig
+
+	// Seed is the public randomness that chains are derived from.
+	Seed []byte
+
+	// NumChains is the number of parallel chains per round, and
+	// ChainLength is the number of mixers on each chain. Chains in a
+	// round never share a mixer.
+	NumChains   int
+	ChainLength int
+}
+
+// minPoolSeedSize is the minimum size of a MixPool's seed.
+const minPoolSeedSize = 16
+
+func (p *MixPool) Validate() error {
+	if len(p.Seed) < minPoolSeedSize {
+		return errors.New("mix pool seed is too short: %d bytes", len(p.Seed))
+	}
+	if p.NumChains < 1 || p.ChainLength < 1 {
+		return errors.New("invalid mix pool chains: %d chains of length %d", p.NumChains, p.ChainLength)
+	}
+	if p.NumChains*p.ChainLength > len(p.Mixers) {
+		return errors.New("mix pool has %d mixers, but needs %d for %d chains of length %d",
+			len(p.Mixers), p.NumChains*p.ChainLength, p.NumChains, p.ChainLength)
+	}
+
+	seen := make(map[string]bool)
+	for i, mix := range p.Mixers {
+		if len(mix.Key) != ed25519.PublicKeySize {
+			return errors.New("invalid key for pool mixer %d: %s", i, mix.Key)
+		}
+		if mix.Address == "" {
+			return errors.New("empty address for pool mixer %d", i)
+		}
+		if seen[string(mix.Key)] {
+			return errors.New("pool mixer %d is listed more than once", i)
+		}
+		seen[string(mix.Key)] = true
+	}
+
+	return nil
+}
+
+// Chains returns the mix chains for the given service and round.
+// The pool must be valid.
+func (p *MixPool) Chains(service string, round uint32) [][]PublicServerConfig {
+	rng := newPoolRNG(p.Seed, service, round)
+
+	// Partial Fisher-Yates shuffle of the pool.
+	n := p.NumChains * p.ChainLength
+	perm := make([]int, len(p.Mixers))
+	for i := range perm {
+		perm[i] = i
+	}
+	for i := 0; i < n; i++ {
+		j := i + rng.intn(len(perm)-i)
+		perm[i], perm[j] = perm[j], perm[i]
+	}
+
+	chains := make([][]PublicServerConfig, p.NumChains)
+	for c := range chains {
+		chains[c] = make([]PublicServerConfig, p.ChainLength)
+		for pos := range chains[c] {
+			chains[c][pos] = p.Mixers[perm[c*p.ChainLength+pos]]
+		}
+	}
+	return chains
+}
+
+// poolRNG is SHA-256 in counter mode, keyed by the pool seed and round.
+type poolRNG struct {
+	key     [32]byte
+	counter uint64
+	buf     []byte
+}
+
+func newPoolRNG(seed []byte, service string, round uint32) *poolRNG {
+	h := sha256.New()
+	h.Write([]byte("MixPool"))
+	h.Write(seed)
+	h.Write([]byte(service))
+	h.Write([]byte{0})
+	binary.Write(h, binary.BigEndian, round)
+
+	rng := new(poolRNG)
+	h.Sum(rng.key[:0])
+	return rng
+}
+
+func (rng *poolRNG) uint64() uint64 {
+	if len(rng.buf) < 8 {
+		var block [40]byte
+		copy(block[0:32], rng.key[:])
+		binary.BigEndian.PutUint64(block[32:40], rng.counter)
+		rng.counter++
+		sum := sha256.Sum256(block[:])
+		rng.buf = sum[:]
+	}
+	v := binary.BigEndian.Uint64(rng.buf[:8])
+	rng.buf = rng.buf[8:]
+	return v
+}
+
+// intn returns a uniform integer in [0, n) using rejection sampling.
+func (rng *poolRNG) intn(n int) int {
+	max := ^uint64(0) - ^uint64(0)%uint64(n)
+	for {
+		v := rng.uint64()
+		if v < max {
+			return int(v % uint64(n))
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package mixnet_test
+
+import (
+	"crypto/rand"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"golang.org/x/crypto/ed25519"
+
+	"vuvuzela.io/vuvuzela/mixnet"
+)
+
+func TestMixPool(t *testing.T) {
+	pool := &mixnet.MixPool{
+		Mixers:      make([]mixnet.PublicServerConfig, 10),
+		Seed:        make([]byte, 32),
+		NumChains:   2,
+		ChainLength: 3,
+	}
+	rand.Read(pool.Seed)
+	for i := range pool.Mixers {
+		key, _, _ := ed25519.GenerateKey(rand.Reader)
+		pool.Mixers[i] = mixnet.PublicServerConfig{
+			Key:     key,
+			Address: fmt.Sprintf("localhost:%d", 2000+i),
+		}
+	}
+	if err := pool.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	chains := pool.Chains("Convo", 42)
+	if len(chains) != 2 || len(chains[0]) != 3 || len(chains[1]) != 3 {
+		t.Fatalf("unexpected chain sizes: %v", chains)
+	}
+	seen := make(map[string]bool)
+	for _, chain := range chains {
+		for _, mixer := range chain {
+			if seen[mixer.Address] {
+				t.Fatalf("mixer %s is picked twice", mixer.Address)
+			}
+			seen[mixer.Address] = true
+		}
+	}
+	if !reflect.DeepEqual(chains, pool.Chains("Convo", 42)) {
+		t.Fatalf("chain selection is not deterministic")
+	}
+
+	differs := false
+	for round := uint32(43); round < 53; round++ {
+		if !reflect.DeepEqual(chains, pool.Chains("Convo", round)) {
+			differs = true
+		}
+	}
+	if !differs {
+		t.Fatalf("same chains picked for 11 rounds")
+	}
+
+	pool.NumChains = 4
+	if err := pool.Validate(); err == nil {
+		t.Fatalf("expected error for pool that is too small")
+	}
+}
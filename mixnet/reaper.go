@@ -0,0 +1,162 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package mixnet
+
+import (
+	"sync/atomic"
+	"time"
+
+	"vuvuzela.io/alpenhorn/log"
+)
+
+// RoundTTLs bounds how long a mixer keeps a round that stops making
+// progress. A round is normally deleted by the previous server in the
+// chain, but if that server crashes or a RunRound call fails early,
+// the round is reaped once it has spent longer than its TTL in its
+// current stage. Zero values use the corresponding DefaultRoundTTLs.
+type RoundTTLs struct {
+	// Settings is the time from NewRound until SetRoundSettings.
+	Settings time.Duration
+
+	// Open is the time from SetRoundSettings until the round closes.
+	Open time.Duration
+
+	// Closed is the time from CloseRound until DeleteRound. It must
+	// be long enough for the round to finish mixing at the servers
+	// further down the chain.
+	Closed time.Duration
+}
+
+var DefaultRoundTTLs = RoundTTLs{
+	Settings: 2 * time.Minute,
+	Open:     10 * time.Minute,
+	Closed:   10 * time.Minute,
+}
+
+// maxReapInterval is the longest the reaper waits between looking
+// for stale rounds.
+const maxReapInterval = 5 * time.Second
+
+func (srv *Server) ttl(stage roundStage) time.Duration {
+	var ttl, def time.Duration
+	switch stage {
+	case stageNew:
+		ttl, def = srv.RoundTTLs.Settings, DefaultRoundTTLs.Settings
+	case stageOpen:
+		ttl, def = srv.RoundTTLs.Open, DefaultRoundTTLs.Open
+	case stageClosed:
+		ttl, def = srv.RoundTTLs.Closed, DefaultRoundTTLs.Closed
+	}
+	if ttl <= 0 {
+		return def
+	}
+	return ttl
+}
+
+type roundStage int
+
+const (
+	stageNew roundStage = iota
+	stageOpen
+	stageClosed
+)
+
+func (s roundStage) String() string {
+	switch s {
+	case stageNew:
+		return "new"
+	case stageOpen:
+		return "open"
+	case stageClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// enterStage moves the round to the given stage and restarts its TTL.
+func (srv *Server) enterStage(st *roundState, stage roundStage) {
+	atomic.StoreInt32(&st.stage, int32(stage))
+	atomic.StoreInt64(&st.expires, time.Now().Add(srv.ttl(stage)).UnixNano())
+}
+
+func (srv *Server) reapLoop() {
+	interval := maxReapInterval
+	for _, stage := range []roundStage{stageNew, stageOpen, stageClosed} {
+		if d := srv.ttl(stage) / 2; d < interval {
+			interval = d
+		}
+	}
+
+	for {
+		time.Sleep(interval)
+		srv.reapRounds(time.Now())
+	}
+}
+
+// reapRounds deletes the rounds that expired before now.
+func (srv *Server) reapRounds(now time.Time) {
+	var reaped []serviceRound
+	var states []*roundState
+
+	srv.roundsMu.Lock()
+	for key, st := range srv.rounds {
+		if now.UnixNano() > atomic.LoadInt64(&st.expires) {
+			reaped = append(reaped, key)
+			states = append(states, st)
+			delete(srv.rounds, key)
+			activeRounds.Dec()
+		}
+	}
+	srv.roundsMu.Unlock()
+
+	for i, key := range reaped {
+		st := states[i]
+		log.WithFields(log.Fields{
+			"service": key.Service,
+			"round":   key.Round,
+			"stage":   roundStage(atomic.LoadInt32(&st.stage)),
+		}).Warn("Reaping stale round")
+		go st.zeroize()
+	}
+}
+
+// zeroize erases the round's key material and drops its onions. It
+// waits for outstanding decryption and encryption jobs to finish, and
+// for any RPC that holds the round's lock.
+func (st *roundState) zeroize() {
+	st.mu.Lock()
+	st.acceptingOnions = false
+	encrypting := st.replies != nil
+	noiseDone := st.noiseDone
+	st.mu.Unlock()
+
+	st.decryptWg.Wait()
+	if encrypting {
+		<-st.encryptDone
+	}
+	if noiseDone != nil {
+		<-noiseDone
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.onionPrivateKey != nil {
+		*st.onionPrivateKey = [32]byte{}
+	}
+	// Shared keys that were handed to a shuffle audit are erased
+	// when the audit is deleted.
+	if !st.audited {
+		for i := range st.sharedKeys {
+			st.sharedKeys[i] = [32]byte{}
+		}
+	}
+	st.sharedKeys = nil
+	st.incoming = nil
+	st.rawIncoming = nil
+	st.tags = nil
+	st.noise = nil
+}
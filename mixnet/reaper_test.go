@@ -0,0 +1,66 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package mixnet
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReapRounds(t *testing.T) {
+	srv := &Server{
+		RoundTTLs: RoundTTLs{
+			Settings: time.Minute,
+			Open:     time.Hour,
+		},
+		rounds: make(map[serviceRound]*roundState),
+	}
+
+	stale := &roundState{
+		onionPrivateKey: &[32]byte{1, 2, 3},
+		sharedKeys:      [][32]byte{{4, 5, 6}},
+	}
+	srv.enterStage(stale, stageNew)
+	staleKey := stale.onionPrivateKey
+	staleShared := stale.sharedKeys
+	srv.rounds[serviceRound{"Convo", 1}] = stale
+
+	open := &roundState{
+		onionPrivateKey: &[32]byte{1, 2, 3},
+	}
+	srv.enterStage(open, stageOpen)
+	srv.rounds[serviceRound{"Convo", 2}] = open
+
+	srv.reapRounds(time.Now().Add(2 * time.Minute))
+
+	if _, err := srv.getRound("Convo", 1); err == nil {
+		t.Fatalf("stale round was not reaped")
+	}
+	if _, err := srv.getRound("Convo", 2); err != nil {
+		t.Fatalf("open round was reaped: %s", err)
+	}
+
+	// Key material is erased in the background.
+	for i := 0; ; i++ {
+		stale.mu.Lock()
+		done := stale.sharedKeys == nil
+		stale.mu.Unlock()
+		if done {
+			break
+		}
+		if i > 100 {
+			t.Fatalf("reaped round was not zeroized")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if *staleKey != [32]byte{} || staleShared[0] != [32]byte{} {
+		t.Fatalf("key material was not erased")
+	}
+
+	srv.reapRounds(time.Now().Add(2 * time.Hour))
+	if _, err := srv.getRound("Convo", 2); err == nil {
+		t.Fatalf("expired open round was not reaped")
+	}
+}
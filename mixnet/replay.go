@@ -0,0 +1,205 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package mixnet
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	"vuvuzela.io/alpenhorn/errors"
+	"vuvuzela.io/internal/ioutil2"
+)
+
+// A ReplayFilter remembers the onions a mixer has accepted so that
+// replayed copies are dropped instead of mixed.
+//
+// An onion is identified by its replay tag: a hash of the service name
+// and the onion as received, which is the ephemeral public key followed
+// by the ciphertext. A replay must match the original byte-for-byte,
+// since changing either part makes decryption fail.
+//
+// Mixers generate a new onion key for every round and bind the box
+// nonce to the round number, so an onion replayed into a later round
+// fails to decrypt and is dropped as invalid before it reaches the
+// filter. What the filter stops is:
+//
+//   - Replays within a round, whether sent by clients, the coordinator,
+//     or the previous mixer. Every copy after the first is dropped, and
+//     different messages are never confused with each other.
+//   - Replays into a round whose onion key is still in use. A mixer
+//     reuses a key when NewRound is repeated for a round it still has
+//     state for, such as a round number that a restarted coordinator
+//     announces again.
+//
+// Tags are kept for Window rounds after the round they were first seen
+// in, and mixers persist the filter so that a restart does not empty
+// the window. The filter costs roughly 64 bytes of memory per onion per
+// round.
+type ReplayFilter struct {
+	// Window is the number of rounds a tag is remembered for, counting
+	// back from the newest round seen for its service. If zero, tags are
+	// forgotten as soon as a newer round is seen.
+	Window uint32
+
+	// PersistPath is where Persist and LoadPersistedState save and
+	// load the filter.
+	PersistPath string
+
+	mu      sync.Mutex
+	tags    map[replayTag]struct{}
+	byRound map[serviceRound][]replayTag
+	newest  map[string]uint32
+}
+
+// replayTag is a truncated SHA-256 hash; 128 bits leaves finding a
+// collision at roughly 2^64 work.
+type replayTag [16]byte
+
+func newReplayTag(service string, onion []byte) replayTag {
+	h := sha256.New()
+	h.Write([]byte(service))
+	h.Write([]byte{0})
+	h.Write(onion)
+
+	var tag replayTag
+	copy(tag[:], h.Sum(nil))
+	return tag
+}
+
+// check records the tags from the given round and reports which of
+// them were seen before, either in an earlier call or earlier in tags.
+func (f *ReplayFilter) check(service string, round uint32, tags []replayTag) []bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.init()
+	if newest, ok := f.newest[service]; !ok || round > newest {
+		f.newest[service] = round
+	}
+	f.expire(service)
+
+	key := serviceRound{service, round}
+	replayed := make([]bool, len(tags))
+	for i, tag := range tags {
+		if _, ok := f.tags[tag]; ok {
+			replayed[i] = true
+			continue
+		}
+		f.tags[tag] = struct{}{}
+		f.byRound[key] = append(f.byRound[key], tag)
+	}
+
+	return replayed
+}
+
+func (f *ReplayFilter) init() {
+	if f.tags == nil {
+		f.tags = make(map[replayTag]struct{})
+		f.byRound = make(map[serviceRound][]replayTag)
+		f.newest = make(map[string]uint32)
+	}
+}
+
+// expire forgets the service's tags that fell out of the window.
+func (f *ReplayFilter) expire(service string) {
+	newest := f.newest[service]
+	for key, tags := range f.byRound {
+		if key.Service != service || uint64(key.Round)+uint64(f.Window) >= uint64(newest) {
+			continue
+		}
+		for _, tag := range tags {
+			delete(f.tags, tag)
+		}
+		delete(f.byRound, key)
+	}
+}
+
+// replayFilterVersion is the current version number of the persisted
+// replay filter format.
+const replayFilterVersion byte = 1
+
+type persistedReplayFilter struct {
+	Rounds []persistedReplayRound
+}
+
+type persistedReplayRound struct {
+	Service string
+	Round   uint32
+	// Tags is the concatenation of the round's tags.
+	Tags []byte
+}
+
+func (f *ReplayFilter) LoadPersistedState() error {
+	data, err := ioutil.ReadFile(f.PersistPath)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return fmt.Errorf("no data: %s", f.PersistPath)
+	}
+	if ver := data[0]; ver != replayFilterVersion {
+		return errors.New("unexpected replay filter version: want version %d, got %d", replayFilterVersion, ver)
+	}
+
+	var st persistedReplayFilter
+	if err := json.Unmarshal(data[1:], &st); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.init()
+	for _, r := range st.Rounds {
+		if len(r.Tags)%len(replayTag{}) != 0 {
+			return errors.New("%s round %d: invalid tags length: %d", r.Service, r.Round, len(r.Tags))
+		}
+		key := serviceRound{r.Service, r.Round}
+		for i := 0; i < len(r.Tags); i += len(replayTag{}) {
+			var tag replayTag
+			copy(tag[:], r.Tags[i:])
+			f.tags[tag] = struct{}{}
+			f.byRound[key] = append(f.byRound[key], tag)
+		}
+		if newest, ok := f.newest[r.Service]; !ok || r.Round > newest {
+			f.newest[r.Service] = r.Round
+		}
+	}
+	for service := range f.newest {
+		f.expire(service)
+	}
+
+	return nil
+}
+
+func (f *ReplayFilter) Persist() error {
+	st := new(persistedReplayFilter)
+
+	f.mu.Lock()
+	for key, tags := range f.byRound {
+		buf := make([]byte, 0, len(tags)*len(replayTag{}))
+		for _, tag := range tags {
+			buf = append(buf, tag[:]...)
+		}
+		st.Rounds = append(st.Rounds, persistedReplayRound{
+			Service: key.Service,
+			Round:   key.Round,
+			Tags:    buf,
+		})
+	}
+	f.mu.Unlock()
+
+	buf := new(bytes.Buffer)
+	buf.WriteByte(replayFilterVersion)
+	if err := json.NewEncoder(buf).Encode(st); err != nil {
+		return err
+	}
+
+	return ioutil2.WriteFileAtomic(f.PersistPath, buf.Bytes(), 0600)
+}
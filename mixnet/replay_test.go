@@ -0,0 +1,70 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package mixnet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReplayFilter(t *testing.T) {
+	onion := make([]byte, 64)
+	tag := newReplayTag("Convo", onion)
+	// Flipping one bit of the ephemeral key or the ciphertext changes the tag.
+	onion[0] ^= 1
+	other := newReplayTag("Convo", onion)
+	if tag == other {
+		t.Fatalf("different onions have the same tag")
+	}
+	if tag == newReplayTag("AddFriend", make([]byte, 64)) {
+		t.Fatalf("tags do not depend on the service")
+	}
+
+	f := &ReplayFilter{Window: 2}
+	replayed := f.check("Convo", 10, []replayTag{tag, other, tag})
+	if !reflect.DeepEqual(replayed, []bool{false, false, true}) {
+		t.Fatalf("round 10: unexpected replays: %v", replayed)
+	}
+	replayed = f.check("Convo", 11, []replayTag{other})
+	if !reflect.DeepEqual(replayed, []bool{true}) {
+		t.Fatalf("round 11: unexpected replays: %v", replayed)
+	}
+	// Other services have their own windows.
+	f.check("AddFriend", 1000, nil)
+	replayed = f.check("Convo", 12, []replayTag{tag})
+	if !reflect.DeepEqual(replayed, []bool{true}) {
+		t.Fatalf("round 12: unexpected replays: %v", replayed)
+	}
+
+	dir, err := ioutil.TempDir("", "vuvuzela_replay_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	f.PersistPath = filepath.Join(dir, "replay-filter")
+	if err := f.Persist(); err != nil {
+		t.Fatal(err)
+	}
+	g := &ReplayFilter{
+		Window:      2,
+		PersistPath: f.PersistPath,
+	}
+	if err := g.LoadPersistedState(); err != nil {
+		t.Fatal(err)
+	}
+	replayed = g.check("Convo", 12, []replayTag{tag})
+	if !reflect.DeepEqual(replayed, []bool{true}) {
+		t.Fatalf("loaded filter: unexpected replays: %v", replayed)
+	}
+
+	// Round 10 falls out of the window.
+	replayed = g.check("Convo", 13, []replayTag{tag, other})
+	if !reflect.DeepEqual(replayed, []bool{false, false}) {
+		t.Fatalf("round 13: unexpected replays: %v", replayed)
+	}
+}
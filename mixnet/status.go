@@ -0,0 +1,74 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package mixnet
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"vuvuzela.io/alpenhorn/errors"
+	pb "vuvuzela.io/vuvuzela/mixnet/convopb"
+)
+
+// Version is reported by the Status RPC. Builds can set it with
+// -ldflags "-X vuvuzela.io/vuvuzela/mixnet.Version=...".
+var Version = "devel"
+
+var startTime = time.Now()
+
+// Status is an RPC that reports the server's version, services, active
+// rounds, and load. It does not require a particular client key, so
+// anyone can use it to check that a mixer is reachable; the edtls
+// handshake proves that the mixer has the key the client expects.
+func (srv *Server) Status(ctx context.Context, req *pb.StatusRequest) (*pb.StatusResponse, error) {
+	services := make([]string, 0, len(srv.Services))
+	for service := range srv.Services {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+
+	srv.roundsMu.RLock()
+	rounds := make([]*pb.RoundStatus, 0, len(srv.rounds))
+	for key, st := range srv.rounds {
+		rounds = append(rounds, &pb.RoundStatus{
+			Service: key.Service,
+			Round:   key.Round,
+			Phase:   roundStage(atomic.LoadInt32(&st.stage)).String(),
+		})
+	}
+	srv.roundsMu.RUnlock()
+	sort.Slice(rounds, func(i, j int) bool {
+		if rounds[i].Service != rounds[j].Service {
+			return rounds[i].Service < rounds[j].Service
+		}
+		return rounds[i].Round < rounds[j].Round
+	})
+
+	return &pb.StatusResponse{
+		Version:         Version,
+		Services:        services,
+		Rounds:          rounds,
+		DecryptionQueue: uint32(len(srv.decryptionJobs)),
+		UptimeSeconds:   uint64(time.Since(startTime) / time.Second),
+		Draining:        srv.Draining(),
+	}, nil
+}
+
+// Status fetches the status of the given server. It fails if the
+// server can not be reached or does not present server.Key.
+func (c *Client) Status(ctx context.Context, server PublicServerConfig) (*pb.StatusResponse, error) {
+	conn, err := c.getConn(server)
+	if err != nil {
+		return nil, err
+	}
+	status, err := conn.Status(ctx, &pb.StatusRequest{})
+	if err != nil {
+		return nil, errors.Wrap(err, "server %s: Status", server.Address)
+	}
+	return status, nil
+}
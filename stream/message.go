@@ -0,0 +1,85 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package stream
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"vuvuzela.io/alpenhorn/errors"
+	"vuvuzela.io/vuvuzela/convo"
+)
+
+// SizeData is the number of stream bytes that fit in one message.
+const SizeData = convo.SizeMessageBody - 4 - 4 - 1 - 1
+
+const (
+	flagLowest = 1 << 0
+
+	// flagLength is set if msg[9] has the length of the data, which
+	// follows it. Otherwise the data starts at msg[9] and is padded
+	// with zeros, as in the clients before this package.
+	flagLength = 1 << 1
+)
+
+// Message is the plaintext of a dead drop message in a session. Its
+// layout is the one that conversations used before this package, in
+// which the data is padded with zeros, unless the data ends in a zero
+// byte. Then the message records the length of the data, since the
+// padding would hide the trailing zeros. Earlier clients read such a
+// message as Lowest.
+type Message struct {
+	// Seq is the sequence number of the message, or 0 if the message
+	// carries no data (cover traffic).
+	Seq uint32
+
+	// Ack is the highest sequence number such that the sender has
+	// received every message up to and including it.
+	Ack uint32
+
+	// Lowest is set if Seq is the lowest sequence number that the
+	// sender has not seen acknowledged.
+	Lowest bool
+
+	// Data is at most SizeData bytes of the stream.
+	Data []byte
+}
+
+func (m *Message) Marshal() (msg [convo.SizeMessageBody]byte) {
+	binary.BigEndian.PutUint32(msg[0:4], m.Seq)
+	binary.BigEndian.PutUint32(msg[4:8], m.Ack)
+	var flags byte
+	if m.Lowest {
+		flags |= flagLowest
+	}
+	if len(m.Data) > 0 && m.Data[len(m.Data)-1] == 0 {
+		flags |= flagLength
+		msg[9] = byte(copy(msg[10:], m.Data))
+	} else {
+		copy(msg[9:], m.Data)
+	}
+	msg[8] = flags
+	return
+}
+
+func (m *Message) Unmarshal(msg []byte) error {
+	if len(msg) != convo.SizeMessageBody {
+		return errors.New("bad message length: want %d bytes, got %d", convo.SizeMessageBody, len(msg))
+	}
+	m.Seq = binary.BigEndian.Uint32(msg[0:4])
+	m.Ack = binary.BigEndian.Uint32(msg[4:8])
+	flags := msg[8]
+	m.Lowest = flags&flagLowest != 0
+	if flags&flagLength == 0 {
+		m.Data = bytes.TrimRight(msg[9:], "\x00")
+		return nil
+	}
+	n := int(msg[9])
+	if n > SizeData {
+		return errors.New("bad data length: %d bytes", n)
+	}
+	m.Data = msg[10 : 10+n]
+	return nil
+}
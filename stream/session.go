@@ -0,0 +1,547 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+// Package stream carries a reliable byte stream over a Vuvuzela
+// conversation. A Session splits the stream into dead drop messages,
+// one per round, and sends them again until the peer acknowledges them.
+// The application passes the session's messages to the convo client:
+// NextMessage from its ConvoHandler's Outgoing method and Reply from
+// its Replies method.
+package stream
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/nacl/secretbox"
+
+	"vuvuzela.io/alpenhorn/errors"
+	"vuvuzela.io/vuvuzela/convo"
+)
+
+// window is the number of messages that can be in flight: Write blocks
+// while this many messages are unacknowledged, and messages that are
+// further ahead of the acknowledged ones are dropped.
+const window = 64
+
+// maxBuffered is the number of received bytes that a session buffers
+// for Read. Messages that do not fit are not acknowledged, so the peer
+// sends them again.
+const maxBuffered = window * SizeData
+
+var ErrClosed = errors.New("stream: session closed")
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "stream: deadline exceeded" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// Addr is the username at one end of a session.
+type Addr string
+
+func (a Addr) Network() string { return "vuvuzela" }
+func (a Addr) String() string  { return string(a) }
+
+// Session is a reliable byte stream between two users. It implements
+// net.Conn. The peer must run a session with the same key and keywheel
+// round, and with the usernames swapped.
+type Session struct {
+	myUsername   string
+	peerUsername string
+
+	mu      sync.Mutex
+	changed chan struct{}
+	closed  bool
+
+	sessionKey      *[32]byte
+	sessionKeyRound uint32
+	rounds          map[uint32]*sentRound
+
+	outQueue    []seqMsg
+	lastOut     int
+	relativeSeq uint32
+	seqBase     uint32
+
+	inQueue  map[uint32][]byte // seq number -> data
+	ack      uint32
+	readMsgs [][]byte // delivered messages, not yet read
+	buffered int      // bytes in readMsgs
+
+	readDeadline  time.Time
+	writeDeadline time.Time
+
+	peerResponding bool
+	latency        time.Duration
+}
+
+var _ net.Conn = (*Session)(nil)
+
+type seqMsg struct {
+	relativeSeq uint32
+	data        []byte
+}
+
+type sentRound struct {
+	sentMessage []byte
+	roundKey    *[32]byte
+	created     time.Time
+}
+
+// NewSession returns a session between myUsername and peerUsername
+// whose keywheel starts with sessionKey in round keywheelRound, as
+// agreed on by an Alpenhorn call.
+func NewSession(myUsername, peerUsername string, sessionKey *[32]byte, keywheelRound uint32) *Session {
+	s := &Session{
+		myUsername:   myUsername,
+		peerUsername: peerUsername,
+		changed:      make(chan struct{}),
+		rounds:       make(map[uint32]*sentRound),
+		inQueue:      make(map[uint32][]byte),
+	}
+	s.Rekey(sessionKey, keywheelRound)
+	return s
+}
+
+// Rekey starts a new keywheel, for example when the peer calls again.
+// Data that was written but not acknowledged is sent again with the
+// new keys.
+func (s *Session) Rekey(sessionKey *[32]byte, keywheelRound uint32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessionKey = sessionKey
+	s.sessionKeyRound = keywheelRound
+	s.lastOut = -1
+	s.peerResponding = false
+	s.latency = 0
+}
+
+// Solo returns true if the session is between a user and themself.
+func (s *Session) Solo() bool {
+	return s.peerUsername == s.myUsername
+}
+
+// NextMessage returns the session's message for the round. It sends
+// the next unacknowledged part of the stream, or cover traffic.
+func (s *Session) NextMessage(round uint32) *convo.DeadDropMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Cover traffic message by default
+	msg := &Message{
+		Seq:    0,
+		Ack:    s.ack,
+		Lowest: false,
+	}
+
+	if len(s.outQueue) > 0 {
+		if s.seqBase == 0 {
+			// Use the conversation round as an initial seq#
+			// to avoid seq# duplicates across client restart
+			s.seqBase = round + 1
+		}
+
+		if s.lastOut != -1 && s.lastOut+1 < len(s.outQueue) {
+			s.lastOut++
+		} else {
+			s.lastOut = 0
+			msg.Lowest = true
+		}
+
+		msg.Seq = s.outQueue[s.lastOut].relativeSeq + s.seqBase
+		msg.Data = s.outQueue[s.lastOut].data
+	}
+
+	msgdata := msg.Marshal()
+
+	roundKey := s.rollAndReplaceKey(round)
+	if roundKey == nil {
+		// We've rolled past this round so generate cover traffic.
+		dummy := new(convo.DeadDropMessage)
+		rand.Read(dummy.DeadDrop[:])
+		rand.Read(dummy.EncryptedMessage[:])
+		return dummy
+	}
+	ctxt := seal(msgdata[:], round, s.peerUsername, roundKey)
+
+	var encmsg [convo.SizeEncryptedMessageBody]byte
+	copy(encmsg[:], ctxt)
+
+	s.rounds[round] = &sentRound{
+		sentMessage: encmsg[:],
+		roundKey:    roundKey,
+		created:     time.Now(),
+	}
+
+	return &convo.DeadDropMessage{
+		DeadDrop:         deadDrop(round, roundKey),
+		EncryptedMessage: encmsg,
+	}
+}
+
+// Reply processes the reply to the message that NextMessage returned
+// for the round.
+func (s *Session) Reply(round uint32, encmsg []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.peerResponding = false
+	st, ok := s.rounds[round]
+	delete(s.rounds, round)
+	// Delete old rounds to ensure forward secrecy.
+	for r := range s.rounds {
+		if r+10 < round {
+			delete(s.rounds, r)
+		}
+	}
+	if !ok {
+		return errors.New("round %d not found", round)
+	}
+
+	if bytes.Equal(encmsg, st.sentMessage) && !s.Solo() {
+		return nil
+	}
+
+	msgdata, ok := open(encmsg, round, s.myUsername, st.roundKey)
+	if !ok {
+		return errors.New("round %d: decrypting peer message failed", round)
+	}
+
+	msg := new(Message)
+	if err := msg.Unmarshal(msgdata); err != nil {
+		return errors.Wrap(err, "round %d: unmarshaling peer message failed", round)
+	}
+
+	s.peerResponding = true
+	s.latency = time.Now().Sub(st.created)
+
+	newOutQueue := s.outQueue[:0]
+	for _, out := range s.outQueue {
+		if s.seqBase > 0 && out.relativeSeq+s.seqBase <= msg.Ack {
+			// We removed something from the queue, adjust lastOut.
+			if s.lastOut > 0 {
+				s.lastOut -= 1
+			}
+			continue
+		}
+		newOutQueue = append(newOutQueue, out)
+	}
+	s.outQueue = newOutQueue
+
+	if msg.Seq > s.ack {
+		// This message is not cover traffic (msg.Seq != 0) and we have
+		// not processed it yet (msg.Seq > s.ack).
+
+		// If this is the lowest-numbered message the peer knows about,
+		// then don't bother waiting for any lower-numbered messages.
+		if msg.Lowest {
+			for seq := range s.inQueue {
+				if seq < msg.Seq {
+					delete(s.inQueue, seq)
+				}
+			}
+			s.ack = msg.Seq - 1
+		}
+
+		// Queue the message, unless it is too far ahead.
+		if msg.Seq <= s.ack+window {
+			s.inQueue[msg.Seq] = append([]byte(nil), msg.Data...)
+		}
+	}
+	s.deliver()
+
+	s.broadcast()
+	return nil
+}
+
+// deliver moves the longest consecutive chain of messages starting
+// from the current ack value to the read buffer, while it has room,
+// and acknowledges them.
+func (s *Session) deliver() {
+	for s.buffered < maxBuffered {
+		data, ok := s.inQueue[s.ack+1]
+		if !ok {
+			break
+		}
+		if len(data) > 0 {
+			s.readMsgs = append(s.readMsgs, data)
+			s.buffered += len(data)
+		}
+		delete(s.inQueue, s.ack+1)
+		s.ack++
+	}
+}
+
+// broadcast wakes up the calls that are waiting for the session to
+// change. It must be called with s.mu held.
+func (s *Session) broadcast() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// wait waits until the session changes or the deadline passes. It must
+// be called with s.mu held, which it releases while waiting.
+func (s *Session) wait(deadline time.Time) error {
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		d := time.Until(deadline)
+		if d <= 0 {
+			return timeoutError{}
+		}
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	changed := s.changed
+	s.mu.Unlock()
+	select {
+	case <-changed:
+	case <-timeout:
+	}
+	s.mu.Lock()
+	return nil
+}
+
+func expired(deadline time.Time) bool {
+	return !deadline.IsZero() && !time.Now().Before(deadline)
+}
+
+// waitRead waits until there is data to read. It must be called with
+// s.mu held.
+func (s *Session) waitRead() error {
+	for {
+		if s.closed {
+			return ErrClosed
+		}
+		if expired(s.readDeadline) {
+			return timeoutError{}
+		}
+		if len(s.readMsgs) > 0 {
+			return nil
+		}
+		if err := s.wait(s.readDeadline); err != nil {
+			return err
+		}
+	}
+}
+
+// Read reads data that the peer wrote to the session. It blocks until
+// data arrives, the session is closed, or the read deadline passes.
+func (s *Session) Read(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if err := s.waitRead(); err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for n < len(p) && len(s.readMsgs) > 0 {
+		m := copy(p[n:], s.readMsgs[0])
+		if m == len(s.readMsgs[0]) {
+			s.readMsgs = s.readMsgs[1:]
+		} else {
+			s.readMsgs[0] = s.readMsgs[0][m:]
+		}
+		n += m
+	}
+	s.buffered -= n
+	// Make room for messages that did not fit in the buffer.
+	s.deliver()
+	return n, nil
+}
+
+// ReadMessage is like Read, but it returns the data of the next message
+// that the peer sent, or what is left of it after an earlier Read. Each
+// Write of at most SizeData bytes is sent in one message.
+func (s *Session) ReadMessage() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.waitRead(); err != nil {
+		return nil, err
+	}
+
+	data := s.readMsgs[0]
+	s.readMsgs = s.readMsgs[1:]
+	s.buffered -= len(data)
+	s.deliver()
+	return data, nil
+}
+
+// Write queues data to send to the peer. It blocks while too many
+// messages are unacknowledged, until the session is closed or the
+// write deadline passes. Each round sends at most SizeData bytes, and
+// the data of different Writes is never sent in the same message.
+func (s *Session) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for n < len(p) {
+		if s.closed {
+			return n, ErrClosed
+		}
+		if expired(s.writeDeadline) {
+			return n, timeoutError{}
+		}
+		if len(s.outQueue) >= window {
+			if err := s.wait(s.writeDeadline); err != nil {
+				return n, err
+			}
+			continue
+		}
+
+		size := len(p) - n
+		if size > SizeData {
+			size = SizeData
+		}
+		s.outQueue = append(s.outQueue, seqMsg{
+			relativeSeq: s.relativeSeq,
+			data:        append([]byte(nil), p[n:n+size]...),
+		})
+		s.relativeSeq++
+		n += size
+	}
+	return n, nil
+}
+
+// Close closes the session: blocked and later calls to Read and Write
+// return ErrClosed. It does not discard data that was already written;
+// NextMessage keeps sending it.
+func (s *Session) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	s.closed = true
+	s.broadcast()
+	return nil
+}
+
+func (s *Session) LocalAddr() net.Addr {
+	return Addr(s.myUsername)
+}
+
+func (s *Session) RemoteAddr() net.Addr {
+	return Addr(s.peerUsername)
+}
+
+func (s *Session) SetDeadline(t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.readDeadline = t
+	s.writeDeadline = t
+	s.broadcast()
+	return nil
+}
+
+func (s *Session) SetReadDeadline(t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.readDeadline = t
+	s.broadcast()
+	return nil
+}
+
+func (s *Session) SetWriteDeadline(t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writeDeadline = t
+	s.broadcast()
+	return nil
+}
+
+type Status struct {
+	// PeerResponding is true if the peer answered in the last round.
+	PeerResponding bool
+
+	// Latency is how long the last round took.
+	Latency time.Duration
+
+	// Unacked is the number of messages that the peer has not
+	// acknowledged.
+	Unacked int
+}
+
+func (s *Session) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Status{
+		PeerResponding: s.peerResponding,
+		Latency:        s.latency,
+		Unacked:        len(s.outQueue),
+	}
+}
+
+func seal(message []byte, round uint32, recipient string, roundKey *[32]byte) []byte {
+	var nonce [24]byte
+	binary.BigEndian.PutUint32(nonce[:], round)
+	nameHash := sha256.Sum256([]byte(recipient))
+	copy(nonce[4:], nameHash[:16])
+
+	ctxt := secretbox.Seal(nil, message, &nonce, roundKey)
+	return ctxt
+}
+
+func open(ctxt []byte, round uint32, recipient string, roundKey *[32]byte) ([]byte, bool) {
+	var nonce [24]byte
+	binary.BigEndian.PutUint32(nonce[:], round)
+	nameHash := sha256.Sum256([]byte(recipient))
+	copy(nonce[4:], nameHash[:16])
+
+	return secretbox.Open(nil, ctxt, &nonce, roundKey)
+}
+
+// rollAndReplaceKey rolls the session key forward to targetRound. It
+// must be called with s.mu held.
+func (s *Session) rollAndReplaceKey(targetRound uint32) *[32]byte {
+	newKey := rollKey(s.sessionKey, s.sessionKeyRound, targetRound)
+	if newKey == nil {
+		return nil
+	}
+	s.sessionKey, s.sessionKeyRound = newKey, targetRound
+	return newKey
+}
+
+func rollKey(currentKey *[32]byte, keyRound, targetRound uint32) *[32]byte {
+	if keyRound > targetRound {
+		return nil
+	}
+
+	newKey := new([32]byte)
+	copy(newKey[:], currentKey[:])
+
+	hash := sha512.New512_256()
+	key := newKey[:]
+	for r := keyRound; r < targetRound; r++ {
+		hash.Reset()
+		binary.Write(hash, binary.BigEndian, r)
+		hash.Write(key)
+		key = hash.Sum(key[:0])
+	}
+
+	return newKey
+}
+
+func deadDrop(round uint32, roundKey *[32]byte) (id convo.DeadDrop) {
+	h := hmac.New(sha256.New, roundKey[:])
+	h.Write([]byte("DeadDrop"))
+	binary.Write(h, binary.BigEndian, round)
+	r := h.Sum(nil)
+	copy(id[:], r)
+	return
+}
@@ -0,0 +1,224 @@
+// Copyright 2018 The Vuvuzela Authors. All rights reserved.
+// Use of this source code is governed by the GNU AGPL
+// license that can be found in the LICENSE file.
+
+package stream
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/davidlazar/go-crypto/encoding/base32"
+)
+
+func TestSoloSession(t *testing.T) {
+	key := new([32]byte)
+	rand.Read(key[:])
+	s := NewSession("alice@example.org", "alice@example.org", key, 23)
+
+	msg := make([]byte, 256)
+	rand.Read(msg)
+
+	var round uint32 = 42
+	roundKey := s.rollAndReplaceKey(round)
+	ctxt := seal(msg, round, s.peerUsername, roundKey)
+	xmsg, ok := open(ctxt, round, s.myUsername, roundKey)
+	if !ok {
+		t.Fatalf("failed to decrypt message")
+	}
+
+	if bytes.Compare(msg, xmsg) != 0 {
+		t.Fatalf("messages don't match")
+	}
+}
+
+func TestMarshalMessage(t *testing.T) {
+	m := &Message{
+		Seq:    55555,
+		Ack:    22222,
+		Lowest: true,
+		Data:   []byte("hello world"),
+	}
+	data := m.Marshal()
+	// Earlier clients read this layout.
+	if data[8] != 1 || !bytes.Equal(data[9:20], m.Data) {
+		t.Fatalf("data is not at offset 9: %x", data)
+	}
+	if !bytes.Equal(data[20:], make([]byte, len(data)-20)) {
+		t.Fatalf("data is not padded with zeros: %x", data)
+	}
+
+	for _, m := range []*Message{
+		m,
+		{Seq: 1, Data: []byte("binary\x00\x00")},
+		{Seq: 2, Lowest: true, Data: make([]byte, SizeData)},
+		{Ack: 3, Data: []byte{}},
+	} {
+		data := m.Marshal()
+		xm := new(Message)
+		if err := xm.Unmarshal(data[:]); err != nil {
+			t.Fatalf("Unmarshal error: %s", err)
+		}
+		if !reflect.DeepEqual(m, xm) {
+			t.Fatalf("%#v != %#v", m, xm)
+		}
+	}
+}
+
+func TestRollKey(t *testing.T) {
+	k0 := new([32]byte)
+	k1 := rollKey(k0, 0, 1)
+	k2 := rollKey(k1, 1, 2)
+
+	k2a := rollKey(k0, 0, 2)
+
+	if !bytes.Equal(k2[:], k2a[:]) {
+		t.Fatalf("%v != %v", k2, k2a)
+	}
+
+	k2strExpected := "8sjdg6x3a7g78293d5n470y20myqhj723bp0687637sdt89gkrq0"
+	k2strActual := base32.EncodeToString(k2[:])
+	if k2strActual != k2strExpected {
+		t.Fatalf("got %q, want %q", k2strActual, k2strExpected)
+	}
+}
+
+// exchange runs a round in which both sessions send a message. The
+// messages meet in the same dead drop, so each is the other's reply.
+// If drop is set, alice's message is lost.
+func exchange(t *testing.T, alice, bob *Session, round uint32, drop bool) {
+	a := alice.NextMessage(round)
+	b := bob.NextMessage(round)
+	if a.DeadDrop != b.DeadDrop {
+		t.Fatalf("round %d: dead drops differ", round)
+	}
+	if drop {
+		a = b
+	}
+	if err := alice.Reply(round, b.EncryptedMessage[:]); err != nil {
+		t.Fatal(err)
+	}
+	if err := bob.Reply(round, a.EncryptedMessage[:]); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSessionStream(t *testing.T) {
+	key := new([32]byte)
+	rand.Read(key[:])
+	alice := NewSession("alice", "bob", key, 100)
+	bob := NewSession("bob", "alice", key, 100)
+
+	data := make([]byte, 3*window*SizeData+17)
+	rand.Read(data)
+	// Some messages end in zero bytes.
+	for i := SizeData - 1; i < len(data); i += 3 * SizeData {
+		data[i] = 0
+		data[i-1] = 0
+	}
+
+	// The first writes fill the window; the rest block until bob
+	// acknowledges messages.
+	written := make(chan error, 1)
+	go func() {
+		_, err := alice.Write(data)
+		written <- err
+	}()
+
+	received := make(chan []byte, 1)
+	go func() {
+		buf := make([]byte, len(data))
+		io.ReadFull(bob, buf)
+		received <- buf
+	}()
+
+	var got []byte
+	for round := uint32(101); got == nil; round++ {
+		if round > 1000 {
+			t.Fatal("stream did not finish")
+		}
+		// Lose some of alice's messages so that she sends them again.
+		exchange(t, alice, bob, round, round%7 == 0)
+		select {
+		case got = <-received:
+		case <-time.After(time.Millisecond):
+		}
+	}
+	if err := <-written; err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("bob received different data")
+	}
+	if st := bob.Status(); !st.PeerResponding {
+		t.Fatalf("unexpected status: %#v", st)
+	}
+}
+
+func TestSessionMessages(t *testing.T) {
+	key := new([32]byte)
+	alice := NewSession("alice", "bob", key, 0)
+	bob := NewSession("bob", "alice", key, 0)
+
+	long := bytes.Repeat([]byte("x"), SizeData+1)
+	binary := []byte{1, 0, 0}
+	for _, msg := range [][]byte{[]byte("hello"), binary, long} {
+		if _, err := alice.Write(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for round := uint32(1); round <= 4; round++ {
+		exchange(t, alice, bob, round, false)
+	}
+
+	// The trailing zeros of a message are not mistaken for padding,
+	// and a long Write is split across messages.
+	for _, want := range []string{"hello", string(binary), string(long[:SizeData]), "x"} {
+		msg, err := bob.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(msg) != want {
+			t.Fatalf("got message %q, want %q", msg, want)
+		}
+	}
+}
+
+func TestSessionDeadline(t *testing.T) {
+	key := new([32]byte)
+	s := NewSession("alice", "bob", key, 0)
+
+	s.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	_, err := s.Read(make([]byte, 1))
+	if err, ok := err.(net.Error); !ok || !err.Timeout() {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+
+	// Nobody acknowledges the messages, so the write times out when
+	// the window is full.
+	s.SetWriteDeadline(time.Now().Add(10 * time.Millisecond))
+	n, err := s.Write(make([]byte, (window+1)*SizeData))
+	if err, ok := err.(net.Error); !ok || !err.Timeout() {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+	if n != window*SizeData {
+		t.Fatalf("wrote %d bytes, want %d", n, window*SizeData)
+	}
+
+	s.SetDeadline(time.Time{})
+	closed := make(chan error, 1)
+	go func() {
+		_, err := s.Read(make([]byte, 1))
+		closed <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	s.Close()
+	if err := <-closed; err != ErrClosed {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+}
@@ -34,6 +34,16 @@ func (m *Mixchain) Close() error {
 }
 
 func LaunchMixchain(length int, coordinatorKey ed25519.PublicKey) *Mixchain {
+	return launchMixchain(length, coordinatorKey, false)
+}
+
+// LaunchAuditedMixchain is like LaunchMixchain, but the mixers
+// commit to their shuffles so that they can be audited.
+func LaunchAuditedMixchain(length int, coordinatorKey ed25519.PublicKey) *Mixchain {
+	return launchMixchain(length, coordinatorKey, true)
+}
+
+func launchMixchain(length int, coordinatorKey ed25519.PublicKey, auditShuffles bool) *Mixchain {
 	publicKeys := make([]ed25519.PublicKey, length)
 	privateKeys := make([]ed25519.PrivateKey, length)
 	listeners := make([]net.Listener, length)
@@ -65,6 +75,9 @@ func LaunchMixchain(length int, coordinatorKey ed25519.PublicKey) *Mixchain {
 					},
 				},
 			},
+
+			AuditShuffles: auditShuffles,
+			ReplayFilter:  &mixnet.ReplayFilter{Window: 4},
 		}
 
 		creds := credentials.NewTLS(edtls.NewTLSServerConfig(privateKeys[pos]))